@@ -2,27 +2,32 @@ package api
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"time"
 
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/token"
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
 type Bid struct {
-	ID           string `json:"id"`
-	BookingID    string `json:"booking_id"`
-	BidAmount    int    `json:"bid_amount"`
-	DriverID     int64  `json:"driver_id"`
-	DriverName   string `json:"driver_name"`
-	DriverRating int    `json:"driver_rating"`
-	DriverMobile string `json:"driver_mobile"`
-	CarID        int64  `json:"car_id"`
-	CarType      string `json:"car_type"`
-	CarImage     string `json:"car_image"`
+	ID           string  `json:"id"`
+	BookingID    string  `json:"booking_id"`
+	BidAmount    int     `json:"bid_amount"`
+	DriverID     int64   `json:"driver_id"`
+	DriverName   string  `json:"driver_name"`
+	DriverRating float64 `json:"driver_rating"`
+	DriverMobile string  `json:"driver_mobile"`
+	CarID        int64   `json:"car_id"`
+	CarType      string  `json:"car_type"`
+	CarImage     string  `json:"car_image"`
 }
 
 func (server *Server) bidSubmitHandler(redisClient *redis.Client) gin.HandlerFunc {
@@ -31,16 +36,97 @@ func (server *Server) bidSubmitHandler(redisClient *redis.Client) gin.HandlerFun
 	}
 }
 
+type SubmitBidRequest struct {
+	BookingID string `json:"booking_id" binding:"required"`
+	BidAmount int    `json:"bid_amount" binding:"required,min=1"`
+}
+
 func (server *Server) bidSubmit(ctx *gin.Context, redisClient *redis.Client) {
-	var bid Bid
-	if err := ctx.BindJSON(&bid); err != nil {
-		ctx.JSON(400, finalResponse(FinalResponse{
+	var req SubmitBidRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
 			Status:  false,
 			Message: "Invalid bid",
 			Data:    nil}))
 		return
 	}
-	err := AddBid(redisClient, bid.BookingID, bid, ctx)
+
+	driver, isDriver, err := server.getAuthDriver(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	if !isDriver {
+		ctx.JSON(http.StatusForbidden, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Only drivers can submit bids"}))
+		return
+	}
+
+	if driver.IsBusy {
+		ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Driver is already assigned to another trip"}))
+		return
+	}
+
+	trip, err := server.store.GetTripByBookingID(ctx, req.BookingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Trip not found"}))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	if trip.TripStatus != db.TripStatusRequested && trip.TripStatus != db.TripStatusBidding {
+		ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Trip is no longer open for bids"}))
+		return
+	}
+
+	// the first bid opens bidding; losing the race to another bidder is fine
+	if trip.TripStatus == db.TripStatusRequested {
+		_, err = server.store.UpdateTripStatusTx(ctx, db.UpdateTripStatusTxParams{
+			UpdateTripStatusParams: db.UpdateTripStatusParams{
+				TripStatus:    db.TripStatusBidding,
+				BookingID:     trip.BookingID,
+				CurrentStatus: db.TripStatusRequested,
+			},
+			Actor: server.driverActor(ctx, driver.ID),
+		})
+		if err != nil && err != sql.ErrNoRows {
+			ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+				Status:  false,
+				Message: err.Error()}))
+			return
+		}
+	}
+
+	bid := Bid{
+		ID:           uuid.NewString(),
+		BookingID:    trip.BookingID,
+		BidAmount:    req.BidAmount,
+		DriverID:     driver.ID,
+		DriverName:   driver.FullName,
+		DriverRating: driver.Rating,
+		DriverMobile: driver.Mobile,
+		CarID:        driver.CarID,
+		CarType:      driver.CarType,
+		CarImage:     driver.CarImage,
+	}
+
+	err = AddBid(redisClient, bid.BookingID, bid, ctx)
 	if err != nil {
 		ctx.JSON(500, finalResponse(FinalResponse{
 			Status:  false,
@@ -50,9 +136,9 @@ func (server *Server) bidSubmit(ctx *gin.Context, redisClient *redis.Client) {
 	}
 	_ = PublishBid(redisClient, bid.BookingID, bid, ctx)
 	ctx.JSON(200, finalResponse(FinalResponse{
-		Status:  false,
+		Status:  true,
 		Message: "Bid submitted successfully",
-		Data:    nil}))
+		Data:    bid}))
 }
 
 func AddBid(client *redis.Client, bookingID string, bid Bid, ctx *gin.Context) error {
@@ -69,9 +155,34 @@ func AddBid(client *redis.Client, bookingID string, bid Bid, ctx *gin.Context) e
 	return err
 }
 
+// getBidListHandler lists the bids on a trip to its passenger.
 func (server *Server) getBidListHandler(redisClient *redis.Client) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		bookingID := ctx.Param("booking_id")
+		authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+
+		trip, err := server.store.GetTripByBookingID(ctx, bookingID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+					Status:  false,
+					Message: "Trip not found"}))
+				return
+			}
+
+			ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+				Status:  false,
+				Message: err.Error()}))
+			return
+		}
+
+		// bids are only shown to the passenger who asked for the trip
+		if !isTripPassenger(trip, authPayload) {
+			ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Trip not found"}))
+			return
+		}
 
 		bids, err := GetBids(redisClient, bookingID, ctx)
 		if err != nil {
@@ -104,6 +215,11 @@ func GetBids(client *redis.Client, bookingID string, ctx *gin.Context) ([]Bid, e
 	return bids, nil
 }
 
+func ClearBids(client *redis.Client, bookingID string, ctx *gin.Context) error {
+	key := "bids:" + bookingID
+	return client.Del(ctx, key).Err()
+}
+
 func PublishBid(client *redis.Client, bookingID string, bid Bid, ctx *gin.Context) error {
 	channel := "bids_channel:" + bookingID
 	msg, err := json.Marshal(bid)
@@ -113,6 +229,119 @@ func PublishBid(client *redis.Client, bookingID string, bid Bid, ctx *gin.Contex
 	return client.Publish(ctx, channel, msg).Err()
 }
 
+type AcceptBidRequest struct {
+	BookingID string `json:"booking_id" binding:"required"`
+	BidID     string `json:"bid_id" binding:"required"`
+}
+
+func (server *Server) bidAcceptHandler(redisClient *redis.Client) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		server.bidAccept(ctx, redisClient)
+	}
+}
+
+func (server *Server) bidAccept(ctx *gin.Context, redisClient *redis.Client) {
+	var req AcceptBidRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+
+	bids, err := GetBids(redisClient, req.BookingID, ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Failed to retrieve bids"}))
+		return
+	}
+
+	var acceptedBid *Bid
+	for i := range bids {
+		if bids[i].ID == req.BidID {
+			acceptedBid = &bids[i]
+			break
+		}
+	}
+
+	if acceptedBid == nil {
+		ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Bid not found"}))
+		return
+	}
+
+	result, err := server.store.AcceptBidTx(ctx, db.AcceptBidTxParams{
+		BookingID:    req.BookingID,
+		PassengerID:  authPayload.UserID,
+		DriverID:     acceptedBid.DriverID,
+		DriverName:   acceptedBid.DriverName,
+		DriverMobile: acceptedBid.DriverMobile,
+		CarID:        acceptedBid.CarID,
+		CarType:      acceptedBid.CarType,
+		CarImage:     acceptedBid.CarImage,
+		Fare:         int64(acceptedBid.BidAmount),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows || errors.Is(err, db.ErrTripNotOwned) {
+			ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Trip not found"}))
+			return
+		}
+
+		if errors.Is(err, db.ErrInvalidTripStatusTransition) || errors.Is(err, db.ErrDriverBusy) {
+			ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+				Status:  false,
+				Message: err.Error()}))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	if err := ClearBids(redisClient, req.BookingID, ctx); err != nil {
+		log.Println("Failed to clear bids for", req.BookingID, err)
+	}
+
+	finalTrip := newTripResponse(result.Trip)
+
+	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Bid accepted successfully",
+		Data:    finalTrip,
+	}))
+
+	for _, bid := range bids {
+		if bid.ID == acceptedBid.ID {
+			server.webSocketManager.Broadcast(driverChannel(bid.DriverID), finalResponse(FinalResponse{
+				Status:  true,
+				Message: "Your bid has been accepted",
+				Data:    finalTrip,
+			}))
+			continue
+		}
+
+		server.webSocketManager.Broadcast(driverChannel(bid.DriverID), finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Your bid has been rejected",
+			Data:    bid,
+		}))
+	}
+
+	server.webSocketManager.Broadcast("trip_status: "+result.Trip.BookingID, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Trip accepted",
+		Data:    finalTrip,
+	}))
+}
+
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool { return true },
 }
@@ -123,6 +352,7 @@ func (server *Server) BidWebSocketHandler(redisClient *redis.Client) gin.Handler
 	}
 }
 
+// bidWebSocket streams new bids on a trip to its passenger.
 func (server *Server) bidWebSocket(ctx *gin.Context, redisClient *redis.Client) {
 	bookingID := ctx.Param("booking_id")
 	tokenString := ctx.Query("token")
@@ -132,7 +362,7 @@ func (server *Server) bidWebSocket(ctx *gin.Context, redisClient *redis.Client)
 		return
 	}
 
-	_, err := server.tokenMaker.VerifyToken(tokenString)
+	payload, err := server.verifyToken(ctx, tokenString)
 	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, finalResponse(FinalResponse{
 			Status:  false,
@@ -140,6 +370,27 @@ func (server *Server) bidWebSocket(ctx *gin.Context, redisClient *redis.Client)
 		return
 	}
 
+	trip, err := server.store.GetTripByBookingID(ctx, bookingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Trip not found"}))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	if !isTripPassenger(trip, payload) {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Trip not found"}))
+		return
+	}
+
 	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
 	if err != nil {
 		return
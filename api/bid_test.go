@@ -0,0 +1,633 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/emonoid/toribook.git/db/mock"
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/emonoid/toribook.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func randomBid(bookingID string, driver db.Driver) Bid {
+	return Bid{
+		ID:           utils.RandomString(12),
+		BookingID:    bookingID,
+		BidAmount:    int(utils.RandomInt(100, 1000)),
+		DriverID:     driver.ID,
+		DriverName:   driver.FullName,
+		DriverMobile: driver.Mobile,
+		CarID:        driver.CarID,
+		CarType:      driver.CarType,
+		CarImage:     driver.CarImage,
+		DriverRating: driver.Rating,
+	}
+}
+
+// seedBids pushes bids into the test server's redis the same way bidSubmit does.
+func seedBids(t *testing.T, server *Server, bookingID string, bids ...Bid) {
+	redisClient := utils.NewRedisClient(server.config.RedisAddress)
+	defer redisClient.Close()
+
+	for _, bid := range bids {
+		bidJSON, err := json.Marshal(bid)
+		require.NoError(t, err)
+
+		err = redisClient.RPush(context.Background(), "bids:"+bookingID, bidJSON).Err()
+		require.NoError(t, err)
+	}
+}
+
+func requireBidCount(t *testing.T, server *Server, bookingID string, n int64) {
+	redisClient := utils.NewRedisClient(server.config.RedisAddress)
+	defer redisClient.Close()
+
+	count, err := redisClient.LLen(context.Background(), "bids:"+bookingID).Result()
+	require.NoError(t, err)
+	require.Equal(t, n, count)
+}
+
+func TestBidSubmitAPI(t *testing.T) {
+	driver, _ := randomDriver(t)
+	passenger, _ := randomPassenger(t)
+	trip := randomTrip(passenger.ID, db.TripStatusRequested)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		username      string
+		role          string
+		userID        int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, server *Server, recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"bid_amount": 420,
+				// identity fields in the body are ignored
+				"driver_id":   driver.ID + 1,
+				"driver_name": "Someone Else",
+			},
+			username: driver.Mobile,
+			role:     token.RoleDriver,
+			userID:   driver.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDriverByMobile(gomock.Any(), gomock.Eq(driver.Mobile)).
+					Times(1).
+					Return(driver, nil)
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					UpdateTripStatusTx(gomock.Any(), gomock.Eq(db.UpdateTripStatusTxParams{
+						UpdateTripStatusParams: db.UpdateTripStatusParams{
+							TripStatus:    db.TripStatusBidding,
+							BookingID:     trip.BookingID,
+							CurrentStatus: db.TripStatusRequested,
+						},
+						Actor: unlocatedDriverActor(driver.ID),
+					})).
+					Times(1).
+					Return(db.UpdateTripStatusTxResult{Trip: trip}, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotBid Bid
+				requireBodyData(t, recorder.Body, &gotBid)
+				require.NotEmpty(t, gotBid.ID)
+				require.Equal(t, 420, gotBid.BidAmount)
+				require.Equal(t, driver.ID, gotBid.DriverID)
+				require.Equal(t, driver.FullName, gotBid.DriverName)
+				require.Equal(t, driver.Rating, gotBid.DriverRating)
+				require.Equal(t, driver.CarType, gotBid.CarType)
+
+				requireBidCount(t, server, trip.BookingID, 1)
+			},
+		},
+		{
+			name: "AlreadyBidding",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"bid_amount": 420,
+			},
+			username: driver.Mobile,
+			role:     token.RoleDriver,
+			userID:   driver.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				biddingTrip := trip
+				biddingTrip.TripStatus = db.TripStatusBidding
+
+				store.EXPECT().
+					GetDriverByMobile(gomock.Any(), gomock.Eq(driver.Mobile)).
+					Times(1).
+					Return(driver, nil)
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(biddingTrip, nil)
+				store.EXPECT().
+					UpdateTripStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBidCount(t, server, trip.BookingID, 1)
+			},
+		},
+		{
+			name: "NotDriver",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"bid_amount": 420,
+			},
+			username: passenger.Email,
+			role:     token.RolePassenger,
+			userID:   passenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDriverByMobile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBidCount(t, server, trip.BookingID, 0)
+			},
+		},
+		{
+			name: "DriverBusy",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"bid_amount": 420,
+			},
+			username: driver.Mobile,
+			role:     token.RoleDriver,
+			userID:   driver.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				busyDriver := driver
+				busyDriver.IsBusy = true
+
+				store.EXPECT().
+					GetDriverByMobile(gomock.Any(), gomock.Eq(driver.Mobile)).
+					Times(1).
+					Return(busyDriver, nil)
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "TripClosed",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"bid_amount": 420,
+			},
+			username: driver.Mobile,
+			role:     token.RoleDriver,
+			userID:   driver.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				acceptedTrip := trip
+				acceptedTrip.TripStatus = db.TripStatusAccepted
+
+				store.EXPECT().
+					GetDriverByMobile(gomock.Any(), gomock.Eq(driver.Mobile)).
+					Times(1).
+					Return(driver, nil)
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(acceptedTrip, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireBidCount(t, server, trip.BookingID, 0)
+			},
+		},
+		{
+			name: "TripNotFound",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"bid_amount": 420,
+			},
+			username: driver.Mobile,
+			role:     token.RoleDriver,
+			userID:   driver.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDriverByMobile(gomock.Any(), gomock.Eq(driver.Mobile)).
+					Times(1).
+					Return(driver, nil)
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(db.Trip{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidAmount",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"bid_amount": 0,
+			},
+			username: driver.Mobile,
+			role:     token.RoleDriver,
+			userID:   driver.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDriverByMobile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/v1/bid/submit"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, tc.role, tc.userID, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, server, recorder)
+		})
+	}
+}
+
+func TestGetBidListAPI(t *testing.T) {
+	driver, _ := randomDriver(t)
+	passenger, _ := randomPassenger(t)
+	otherPassenger, _ := randomPassenger(t)
+	trip := randomTrip(passenger.ID, db.TripStatusBidding)
+	bid := randomBid(trip.BookingID, driver)
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		userID        int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: passenger.Email,
+			role:     token.RolePassenger,
+			userID:   passenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotBids []Bid
+				requireBodyData(t, recorder.Body, &gotBids)
+				require.Equal(t, []Bid{bid}, gotBids)
+			},
+		},
+		{
+			name:     "OtherPassenger",
+			username: otherPassenger.Email,
+			role:     token.RolePassenger,
+			userID:   otherPassenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "Driver",
+			username: driver.Mobile,
+			role:     token.RoleDriver,
+			userID:   driver.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "TripNotFound",
+			username: passenger.Email,
+			role:     token.RolePassenger,
+			userID:   passenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(db.Trip{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			seedBids(t, server, bid.BookingID, bid)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/bids/%s", bid.BookingID), nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, tc.role, tc.userID, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestBidWebSocket(t *testing.T) {
+	driver, _ := randomDriver(t)
+	passenger, _ := randomPassenger(t)
+	otherPassenger, _ := randomPassenger(t)
+	trip := randomTrip(passenger.ID, db.TripStatusBidding)
+
+	testCases := []struct {
+		name     string
+		username string
+		role     string
+		userID   int64
+		wantCode int
+	}{
+		{
+			name:     "Passenger",
+			username: passenger.Email,
+			role:     token.RolePassenger,
+			userID:   passenger.ID,
+			wantCode: http.StatusSwitchingProtocols,
+		},
+		{
+			name:     "OtherPassenger",
+			username: otherPassenger.Email,
+			role:     token.RolePassenger,
+			userID:   otherPassenger.ID,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Driver",
+			username: driver.Mobile,
+			role:     token.RoleDriver,
+			userID:   driver.ID,
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+				Times(1).
+				Return(trip, nil)
+
+			server := newTestServer(t, store)
+			httpServer := httptest.NewServer(server.router)
+			defer httpServer.Close()
+
+			accessToken, _, err := server.tokenMaker.CreateToken(tc.username, tc.role, tc.userID, token.TokenTypeAccess, uuid.Nil, time.Minute)
+			require.NoError(t, err)
+
+			wsURL := fmt.Sprintf("%s/api/v1/ws/bids/%s?token=%s",
+				strings.Replace(httpServer.URL, "http", "ws", 1), trip.BookingID, accessToken)
+			conn, rsp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+			if conn != nil {
+				conn.Close()
+			}
+			if tc.wantCode != http.StatusSwitchingProtocols {
+				require.ErrorIs(t, err, websocket.ErrBadHandshake)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.wantCode, rsp.StatusCode)
+		})
+	}
+}
+
+func TestBidAcceptAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	winner, _ := randomDriver(t)
+	loser, _ := randomDriver(t)
+	trip := randomTrip(passenger.ID, db.TripStatusBidding)
+
+	winningBid := randomBid(trip.BookingID, winner)
+	losingBid := randomBid(trip.BookingID, loser)
+
+	testCases := []struct {
+		name          string
+		bidID         string
+		username      string
+		role          string
+		userID        int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, server *Server, recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			bidID:    winningBid.ID,
+			username: passenger.Email,
+			role:     token.RolePassenger,
+			userID:   passenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				acceptedTrip := trip
+				acceptedTrip.TripStatus = db.TripStatusAccepted
+
+				store.EXPECT().
+					AcceptBidTx(gomock.Any(), gomock.Eq(db.AcceptBidTxParams{
+						BookingID:    trip.BookingID,
+						PassengerID:  passenger.ID,
+						DriverID:     winner.ID,
+						DriverName:   winner.FullName,
+						DriverMobile: winner.Mobile,
+						CarID:        winner.CarID,
+						CarType:      winner.CarType,
+						CarImage:     winner.CarImage,
+						Fare:         int64(winningBid.BidAmount),
+					})).
+					Times(1).
+					Return(db.AcceptBidTxResult{Trip: acceptedTrip}, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBidCount(t, server, trip.BookingID, 0)
+			},
+		},
+		{
+			name:     "BidNotFound",
+			bidID:    "missing",
+			username: passenger.Email,
+			role:     token.RolePassenger,
+			userID:   passenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AcceptBidTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireBidCount(t, server, trip.BookingID, 2)
+			},
+		},
+		{
+			name:     "NotPassenger",
+			bidID:    winningBid.ID,
+			username: winner.Mobile,
+			role:     token.RoleDriver,
+			userID:   winner.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AcceptBidTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "TripNotOwned",
+			bidID:    winningBid.ID,
+			username: passenger.Email,
+			role:     token.RolePassenger,
+			userID:   passenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AcceptBidTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AcceptBidTxResult{}, db.ErrTripNotOwned)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "DriverBusy",
+			bidID:    winningBid.ID,
+			username: passenger.Email,
+			role:     token.RolePassenger,
+			userID:   passenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AcceptBidTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AcceptBidTxResult{}, db.ErrDriverBusy)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireBidCount(t, server, trip.BookingID, 2)
+			},
+		},
+		{
+			name:     "AlreadyAccepted",
+			bidID:    winningBid.ID,
+			username: passenger.Email,
+			role:     token.RolePassenger,
+			userID:   passenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AcceptBidTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AcceptBidTxResult{}, db.ValidateTripStatusTransition(db.TripStatusAccepted, db.TripStatusAccepted))
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireBidCount(t, server, trip.BookingID, 2)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			seedBids(t, server, trip.BookingID, winningBid, losingBid)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"booking_id": trip.BookingID,
+				"bid_id":     tc.bidID,
+			})
+			require.NoError(t, err)
+
+			url := "/api/v1/bid/accept"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, tc.role, tc.userID, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, server, recorder)
+		})
+	}
+}
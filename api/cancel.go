@@ -0,0 +1,93 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/gin-gonic/gin"
+)
+
+type CancelTripRequest struct {
+	BookingID string `json:"booking_id" binding:"required"`
+	Reason    string `json:"reason" binding:"required"`
+}
+
+func (server *Server) passengerCancelTrip(ctx *gin.Context) {
+	server.cancelTrip(ctx, db.CancelledByPassenger)
+}
+
+func (server *Server) driverCancelTrip(ctx *gin.Context) {
+	server.cancelTrip(ctx, db.CancelledByDriver)
+}
+
+// cancelTrip cancels the trip on behalf of the passenger or driver in the
+// token, settles a payment held for it and tells everyone listening on the
+// trip status channel.
+func (server *Server) cancelTrip(ctx *gin.Context, cancelledBy string) {
+	var req CancelTripRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	if !db.IsValidCancelReason(cancelledBy, req.Reason) {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Invalid cancel reason"}))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+
+	actor := passengerActor(authPayload.UserID)
+	if cancelledBy == db.CancelledByDriver {
+		actor = server.driverActor(ctx, authPayload.UserID)
+	}
+
+	result, err := server.store.CancelTripTx(ctx, db.CancelTripTxParams{
+		BookingID: req.BookingID,
+		Actor:     actor,
+		Reason:    req.Reason,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows || errors.Is(err, db.ErrTripNotOwned) || errors.Is(err, db.ErrTripNotAssigned) {
+			ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Trip not found"}))
+			return
+		}
+
+		if errors.Is(err, db.ErrInvalidTripStatusTransition) {
+			ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+				Status:  false,
+				Message: err.Error()}))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	server.releaseTripPayment(ctx, result.Trip)
+
+	finalTrip := newTripResponse(result.Trip)
+
+	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Trip cancelled successfully",
+		Data:    finalTrip,
+	}))
+
+	server.webSocketManager.Broadcast("trip_status: "+result.Trip.BookingID, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Trip cancelled",
+		Data:    finalTrip,
+	}))
+}
@@ -0,0 +1,285 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/emonoid/toribook.git/db/mock"
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/payments"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func cancelledTrip(trip db.Trip, cancelledBy, reason string, fee *int64) db.Trip {
+	trip.TripStatus = db.TripStatusCancelled
+	trip.CancelledBy = sql.NullString{String: cancelledBy, Valid: true}
+	trip.CancelReason = sql.NullString{String: reason, Valid: true}
+	trip.CancelledAt = sql.NullTime{Time: time.Now(), Valid: true}
+	if fee != nil {
+		trip.CancellationFee = sql.NullInt64{Int64: *fee, Valid: true}
+	}
+	return trip
+}
+
+func TestCancelTripAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	driver, _ := randomDriver(t)
+
+	trip := randomTrip(passenger.ID, db.TripStatusDriverArrived)
+	trip.DriverID = sql.NullInt64{Int64: driver.ID, Valid: true}
+
+	fee := int64(50)
+
+	passengerAuth := func(t *testing.T, request *http.Request, server *Server) {
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+	}
+	driverAuth := func(t *testing.T, request *http.Request, server *Server) {
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+	}
+
+	testCases := []struct {
+		name          string
+		url           string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "PassengerWithFee",
+			url:  "/api/v1/trip/cancel",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"reason":     db.CancelReasonChangedPlans,
+			},
+			setupAuth: passengerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CancelTripTx(gomock.Any(), gomock.Eq(db.CancelTripTxParams{
+						BookingID: trip.BookingID,
+						Actor:     passengerActor(passenger.ID),
+						Reason:    db.CancelReasonChangedPlans,
+					})).
+					Times(1).
+					Return(db.CancelTripTxResult{
+						Trip: cancelledTrip(trip, db.CancelledByPassenger, db.CancelReasonChangedPlans, &fee),
+					}, nil)
+
+				// the fee is taken from what was held for the fare
+				intent := tripPaymentIntent(trip, payments.ProviderCash, db.PaymentStatusAuthorized)
+				intent.Amount = 350
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(sql.NullInt64{Int64: trip.ID, Valid: true})).
+					Times(1).
+					Return(intent, nil)
+				store.EXPECT().
+					CapturePaymentTx(gomock.Any(), gomock.Eq(db.CapturePaymentTxParams{
+						PaymentIntentID: intent.ID,
+						Amount:          fee,
+						CollectedBy:     db.AccountOwnerDriver,
+					})).
+					Times(1).
+					Return(db.CapturePaymentTxResult{PaymentIntent: intent}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got TripResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.Equal(t, db.TripStatusCancelled, got.TripStatus)
+				require.Equal(t, db.CancelledByPassenger, *got.CancelledBy)
+				require.Equal(t, db.CancelReasonChangedPlans, *got.CancelReason)
+				require.Equal(t, fee, *got.CancellationFee)
+				require.NotNil(t, got.CancelledAt)
+			},
+		},
+		{
+			name: "Driver",
+			url:  "/api/v1/trip/driver-cancel",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"reason":     db.CancelReasonVehicleIssue,
+			},
+			setupAuth: driverAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CancelTripTx(gomock.Any(), gomock.Eq(db.CancelTripTxParams{
+						BookingID: trip.BookingID,
+						Actor:     unlocatedDriverActor(driver.ID),
+						Reason:    db.CancelReasonVehicleIssue,
+					})).
+					Times(1).
+					Return(db.CancelTripTxResult{
+						Trip: cancelledTrip(trip, db.CancelledByDriver, db.CancelReasonVehicleIssue, nil),
+					}, nil)
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PaymentIntent{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got TripResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.Equal(t, db.CancelledByDriver, *got.CancelledBy)
+				require.Nil(t, got.CancellationFee)
+			},
+		},
+		{
+			name: "ReasonOfOtherSide",
+			url:  "/api/v1/trip/cancel",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"reason":     db.CancelReasonPassengerNoShow,
+			},
+			setupAuth: passengerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CancelTripTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "MissingReason",
+			url:  "/api/v1/trip/driver-cancel",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+			},
+			setupAuth: driverAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CancelTripTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotOwnTrip",
+			url:  "/api/v1/trip/cancel",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"reason":     db.CancelReasonOther,
+			},
+			setupAuth: passengerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CancelTripTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CancelTripTxResult{}, db.ErrTripNotOwned)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "NotAssignedDriver",
+			url:  "/api/v1/trip/driver-cancel",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"reason":     db.CancelReasonOther,
+			},
+			setupAuth: driverAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CancelTripTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CancelTripTxResult{}, db.ErrTripNotAssigned)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyInProgress",
+			url:  "/api/v1/trip/cancel",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"reason":     db.CancelReasonOther,
+			},
+			setupAuth: passengerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CancelTripTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CancelTripTxResult{}, db.ValidateTripStatusTransition(db.TripStatusInProgress, db.TripStatusCancelled))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "DriverOnPassengerEndpoint",
+			url:  "/api/v1/trip/cancel",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"reason":     db.CancelReasonOther,
+			},
+			setupAuth: driverAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CancelTripTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			url:  "/api/v1/trip/cancel",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"reason":     db.CancelReasonOther,
+			},
+			setupAuth: passengerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CancelTripTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CancelTripTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, tc.url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
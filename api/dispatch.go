@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	db "github.com/emonoid/toribook.git/db/sqlc"
+)
+
+// New trips are offered to the closest drivers first. While nobody bids the
+// search radius widens step by step until the configured maximum is reached.
+const (
+	defaultDispatchRadiusKm     = 3
+	defaultDispatchRadiusStepKm = 2
+	defaultDispatchMaxRadiusKm  = 10
+	defaultDispatchBidTimeout   = 30 * time.Second
+	// dispatchBatchSize caps how many drivers are offered a trip per step.
+	dispatchBatchSize = 20
+)
+
+type dispatchSettings struct {
+	radiusKm    float64
+	stepKm      float64
+	maxRadiusKm float64
+	bidTimeout  time.Duration
+}
+
+// dispatchSettings reads the dispatch options from the config and falls back
+// to the defaults for anything left unset.
+func (server *Server) dispatchSettings() dispatchSettings {
+	settings := dispatchSettings{
+		radiusKm:    server.config.DispatchRadiusKm,
+		stepKm:      server.config.DispatchRadiusStepKm,
+		maxRadiusKm: server.config.DispatchMaxRadiusKm,
+		bidTimeout:  server.config.DispatchBidTimeout,
+	}
+
+	if settings.radiusKm <= 0 {
+		settings.radiusKm = defaultDispatchRadiusKm
+	}
+	if settings.stepKm <= 0 {
+		settings.stepKm = defaultDispatchRadiusStepKm
+	}
+	if settings.maxRadiusKm < settings.radiusKm {
+		settings.maxRadiusKm = math.Max(settings.radiusKm, defaultDispatchMaxRadiusKm)
+	}
+	if settings.bidTimeout <= 0 {
+		settings.bidTimeout = defaultDispatchBidTimeout
+	}
+
+	return settings
+}
+
+// dispatchTrip offers a requested trip to online, subscribed, free drivers
+// around the pickup point who are connected to their driver channel and
+// drive the requested car type. Each driver is offered the trip at most once. It
+// returns once the trip has left the requested status, the maximum radius
+// has been searched or ctx is done.
+func (server *Server) dispatchTrip(ctx context.Context, trip db.Trip, message interface{}) {
+	settings := server.dispatchSettings()
+	offered := make(map[int64]bool)
+
+	radius := settings.radiusKm
+	for {
+		drivers, err := server.findNearbyDrivers(ctx, trip.PickupLat, trip.PickupLong, radius, trip.CarType.String, dispatchBatchSize)
+		if err != nil {
+			log.Println("Cannot find drivers for trip", trip.BookingID, ":", err)
+			return
+		}
+
+		for _, driver := range drivers {
+			channel := driverChannel(driver.DriverID)
+			if offered[driver.DriverID] || server.webSocketManager.ClientCount(channel) == 0 {
+				continue
+			}
+
+			offered[driver.DriverID] = true
+			server.webSocketManager.Broadcast(channel, message)
+		}
+
+		if radius >= settings.maxRadiusKm {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(settings.bidTimeout):
+		}
+
+		// the first bid moves the trip to bidding, so anything other than
+		// requested means drivers have already responded
+		current, err := server.store.GetTripByBookingID(ctx, trip.BookingID)
+		if err != nil {
+			log.Println("Cannot reload trip", trip.BookingID, "for dispatch:", err)
+			return
+		}
+		if current.TripStatus != db.TripStatusRequested {
+			return
+		}
+
+		radius = math.Min(radius+settings.stepKm, settings.maxRadiusKm)
+	}
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/emonoid/toribook.git/db/mock"
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/location"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// connectDriver subscribes driver to the trips websocket and waits until the
+// connection is registered on the driver channel.
+func connectDriver(t *testing.T, server *Server, httpServer *httptest.Server, driver db.Driver) *websocket.Conn {
+	driverToken, _, err := server.tokenMaker.CreateToken(driver.Mobile, token.RoleDriver, driver.ID, token.TokenTypeAccess, uuid.Nil, time.Minute)
+	require.NoError(t, err)
+
+	wsURL := fmt.Sprintf("%s/api/v1/ws/trips?token=%s", strings.Replace(httpServer.URL, "http", "ws", 1), driverToken)
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	require.Eventually(t, func() bool {
+		return server.webSocketManager.ClientCount(driverChannel(driver.ID)) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	return conn
+}
+
+// readOfferedTrip returns the trip offered on conn, or false if nothing
+// arrives within wait.
+func readOfferedTrip(t *testing.T, conn *websocket.Conn, wait time.Duration) (TripResponse, bool) {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(wait)))
+	_, message, err := conn.ReadMessage()
+	if err != nil {
+		return TripResponse{}, false
+	}
+
+	var trip TripResponse
+	requireBodyData(t, bytes.NewReader(message), &trip)
+	return trip, true
+}
+
+func TestDispatchTrip(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	nearDriver, _ := randomDriver(t)
+	farDriver, _ := randomDriver(t)
+
+	// roughly 0.5km and 2km north of the pickup point
+	fixes := []location.Fix{
+		{DriverID: nearDriver.ID, Lat: 23.7982, Long: 90.4066},
+		{DriverID: farDriver.ID, Lat: 23.8117, Long: 90.4066},
+	}
+	available := map[int64]db.Driver{
+		nearDriver.ID: nearDriver,
+		farDriver.ID:  farDriver,
+	}
+
+	listAvailable := func(_ context.Context, arg db.ListAvailableDriversParams) ([]db.Driver, error) {
+		require.Equal(t, sql.NullString{String: "sedan", Valid: true}, arg.CarType)
+
+		var drivers []db.Driver
+		for _, id := range arg.Ids {
+			drivers = append(drivers, available[id])
+		}
+		return drivers, nil
+	}
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore, trip db.Trip)
+		checkOffer func(t *testing.T, nearConn, farConn *websocket.Conn, trip db.Trip)
+	}{
+		{
+			name: "WidensRadiusWithoutBids",
+			buildStubs: func(store *mockdb.MockStore, trip db.Trip) {
+				store.EXPECT().
+					ListAvailableDrivers(gomock.Any(), gomock.Any()).
+					Times(2).
+					DoAndReturn(listAvailable)
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+			},
+			checkOffer: func(t *testing.T, nearConn, farConn *websocket.Conn, trip db.Trip) {
+				offered, ok := readOfferedTrip(t, nearConn, time.Second)
+				require.True(t, ok)
+				require.Equal(t, trip.BookingID, offered.BookingID)
+
+				offered, ok = readOfferedTrip(t, farConn, time.Second)
+				require.True(t, ok)
+				require.Equal(t, trip.BookingID, offered.BookingID)
+
+				// the near driver is not offered the same trip twice
+				_, ok = readOfferedTrip(t, nearConn, 50*time.Millisecond)
+				require.False(t, ok)
+			},
+		},
+		{
+			name: "StopsOnceBidding",
+			buildStubs: func(store *mockdb.MockStore, trip db.Trip) {
+				bidding := trip
+				bidding.TripStatus = db.TripStatusBidding
+
+				store.EXPECT().
+					ListAvailableDrivers(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(listAvailable)
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(bidding, nil)
+			},
+			checkOffer: func(t *testing.T, nearConn, farConn *websocket.Conn, trip db.Trip) {
+				_, ok := readOfferedTrip(t, nearConn, time.Second)
+				require.True(t, ok)
+
+				_, ok = readOfferedTrip(t, farConn, 50*time.Millisecond)
+				require.False(t, ok)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			trip := randomTrip(passenger.ID, db.TripStatusRequested)
+			trip.CarType = sql.NullString{String: "sedan", Valid: true}
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store, trip)
+
+			server := newTestServer(t, store)
+			server.config.DispatchRadiusKm = 1
+			server.config.DispatchRadiusStepKm = 2
+			server.config.DispatchMaxRadiusKm = 3
+			server.config.DispatchBidTimeout = 20 * time.Millisecond
+
+			for _, fix := range fixes {
+				fix.RecordedAt = time.Now().UTC()
+				require.NoError(t, server.locations.SaveLocation(context.Background(), fix))
+			}
+
+			httpServer := httptest.NewServer(server.router)
+			defer httpServer.Close()
+
+			nearConn := connectDriver(t, server, httpServer, nearDriver)
+			farConn := connectDriver(t, server, httpServer, farDriver)
+
+			server.dispatchTrip(context.Background(), trip, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Trip created successfully",
+				Data:    newTripResponse(trip)}))
+
+			tc.checkOffer(t, nearConn, farConn, trip)
+		})
+	}
+}
+
+func TestTripWebSocketRejectsPassengers(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+
+	server := newTestServer(t, nil)
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	passengerToken, _, err := server.tokenMaker.CreateToken(passenger.Email, token.RolePassenger, passenger.ID, token.TokenTypeAccess, uuid.Nil, time.Minute)
+	require.NoError(t, err)
+
+	wsURL := fmt.Sprintf("%s/api/v1/ws/trips?token=%s", strings.Replace(httpServer.URL, "http", "ws", 1), passengerToken)
+	_, rsp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.ErrorIs(t, err, websocket.ErrBadHandshake)
+	require.Equal(t, http.StatusForbidden, rsp.StatusCode)
+}
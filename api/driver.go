@@ -2,30 +2,33 @@ package api
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/token"
 	"github.com/emonoid/toribook.git/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
 
 type CreateDriverRequest struct {
-	Password             string  `json:"password" binding:"required,min=6"`
-	FullName             string  `json:"full_name" binding:"required"`
-	DrivingLicense       string  `json:"driving_license" binding:"required"`
-	Mobile               string  `json:"mobile" binding:"required"`
-	CarID                int64   `json:"car_id" binding:"required"`
-	CarType              string  `json:"car_type" binding:"required"`
-	CarImage             string  `json:"car_image" binding:"required"`
-	OnlineStatus         bool    `json:"online_status" binding:"required"`
-	Rating               float64 `json:"rating" binding:"required"`
-	ProfileStatus        int32   `json:"profile_status" binding:"required"`
-	SubscriptionStatus   bool    `json:"subscription_status" binding:"required"`
-	SubscriptionPackage  string  `json:"subscription_package" binding:"required"`
-	SubscriptionAmount   string  `json:"subscription_amount" binding:"required"`
-	SubscriptionValidity int32   `json:"subscription_validity" binding:"required"`
+	Password             string `json:"password" binding:"required,min=6"`
+	FullName             string `json:"full_name" binding:"required"`
+	DrivingLicense       string `json:"driving_license" binding:"required"`
+	Mobile               string `json:"mobile" binding:"required"`
+	CarID                int64  `json:"car_id" binding:"required"`
+	CarType              string `json:"car_type" binding:"required"`
+	CarImage             string `json:"car_image" binding:"required"`
+	OnlineStatus         bool   `json:"online_status" binding:"required"`
+	ProfileStatus        int32  `json:"profile_status" binding:"required"`
+	SubscriptionStatus   bool   `json:"subscription_status" binding:"required"`
+	SubscriptionPackage  string `json:"subscription_package" binding:"required"`
+	SubscriptionAmount   string `json:"subscription_amount" binding:"required"`
+	SubscriptionValidity int32  `json:"subscription_validity" binding:"required"`
 }
 
 type DriverResponse struct {
@@ -83,7 +86,6 @@ func (server *Server) createDriver(ctx *gin.Context) {
 		HashedPassword:       hashedPass,
 		FullName:             req.FullName,
 		DrivingLicense:       req.DrivingLicense,
-		Rating:               req.Rating,
 		Mobile:               req.Mobile,
 		CarID:                req.CarID,
 		CarType:              req.CarType,
@@ -123,6 +125,27 @@ func (server *Server) createDriver(ctx *gin.Context) {
 		Data:    driverResponse}))
 }
 
+// driverChannel is the websocket channel carrying messages addressed to one driver.
+func driverChannel(driverID int64) string {
+	return fmt.Sprintf("driver: %d", driverID)
+}
+
+// getAuthDriver loads the driver owning the request's access token.
+// isDriver is false when the token was issued to someone else, e.g. a passenger.
+func (server *Server) getAuthDriver(ctx *gin.Context) (driver db.Driver, isDriver bool, err error) {
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+
+	driver, err = server.store.GetDriverByMobile(ctx, authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return driver, false, nil
+		}
+		return driver, false, err
+	}
+
+	return driver, true, nil
+}
+
 type GetDriverRequest struct {
 	ID int64 `uri:"id" binding:"required"`
 }
@@ -163,8 +186,12 @@ type LoginDriverRequest struct {
 }
 
 type LoginDriverResponse struct {
-	AccessToken string         `json:"access_token"`
-	User        DriverResponse `json:"user"`
+	SessionID             uuid.UUID      `json:"session_id"`
+	AccessToken           string         `json:"access_token"`
+	AccessTokenExpiresAt  time.Time      `json:"access_token_expires_at"`
+	RefreshToken          string         `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time      `json:"refresh_token_expires_at"`
+	User                  DriverResponse `json:"user"`
 }
 
 func (server *Server) loginDriver(ctx *gin.Context) {
@@ -199,7 +226,7 @@ func (server *Server) loginDriver(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, err := server.tokenMaker.CreateToken(driver.Mobile, server.config.AccessTokenDuration)
+	tokens, err := server.createSession(ctx, driver.Mobile, token.RoleDriver, driver.ID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
 			Status:  false,
@@ -208,8 +235,12 @@ func (server *Server) loginDriver(ctx *gin.Context) {
 	}
 
 	rsp := LoginDriverResponse{
-		AccessToken: accessToken,
-		User:        newDriverResponse(driver),
+		SessionID:             tokens.SessionID,
+		AccessToken:           tokens.AccessToken,
+		AccessTokenExpiresAt:  tokens.AccessTokenExpiresAt,
+		RefreshToken:          tokens.RefreshToken,
+		RefreshTokenExpiresAt: tokens.RefreshTokenExpiresAt,
+		User:                  newDriverResponse(driver),
 	}
 
 	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
@@ -0,0 +1,369 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/emonoid/toribook.git/db/mock"
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/emonoid/toribook.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func randomDriver(t *testing.T) (driver db.Driver, password string) {
+	password = utils.RandomString(6)
+	hashedPassword, err := utils.HashPassword(password)
+	require.NoError(t, err)
+
+	driver = db.Driver{
+		ID:                   utils.RandomInt(1, 1000),
+		HashedPassword:       hashedPassword,
+		FullName:             utils.RandomName(),
+		DrivingLicense:       utils.RandomString(10),
+		Mobile:               utils.RandomMobile(),
+		CarID:                utils.RandomInt(1, 10),
+		CarType:              "sedan",
+		CarImage:             "https://example.com/car.png",
+		OnlineStatus:         true,
+		Rating:               4.5,
+		ProfileStatus:        1,
+		SubscriptionStatus:   true,
+		SubscriptionPackage:  "monthly",
+		SubscriptionAmount:   "500",
+		SubscriptionValidity: 30,
+	}
+	return
+}
+
+func createDriverBody(driver db.Driver, password string) gin.H {
+	return gin.H{
+		"password":              password,
+		"full_name":             driver.FullName,
+		"driving_license":       driver.DrivingLicense,
+		"mobile":                driver.Mobile,
+		"car_id":                driver.CarID,
+		"car_type":              driver.CarType,
+		"car_image":             driver.CarImage,
+		"online_status":         driver.OnlineStatus,
+		"profile_status":        driver.ProfileStatus,
+		"subscription_status":   driver.SubscriptionStatus,
+		"subscription_package":  driver.SubscriptionPackage,
+		"subscription_amount":   driver.SubscriptionAmount,
+		"subscription_validity": driver.SubscriptionValidity,
+	}
+}
+
+func requireBodyMatchDriver(t *testing.T, recorder *httptest.ResponseRecorder, driver db.Driver) {
+	var gotDriver DriverResponse
+	requireBodyData(t, recorder.Body, &gotDriver)
+
+	require.Equal(t, newDriverResponse(driver), gotDriver)
+}
+
+func TestCreateDriverAPI(t *testing.T) {
+	driver, password := randomDriver(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: createDriverBody(driver, password),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateDriver(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateDriverParams) (db.Driver, error) {
+						require.NoError(t, utils.CheckPassword(password, arg.HashedPassword))
+						require.Equal(t, driver.Mobile, arg.Mobile)
+						return driver, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchDriver(t, recorder, driver)
+			},
+		},
+		{
+			name: "DuplicateDriver",
+			body: createDriverBody(driver, password),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateDriver(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Driver{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: createDriverBody(driver, password),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateDriver(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Driver{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "MissingMobile",
+			body: gin.H{
+				"password":  password,
+				"full_name": driver.FullName,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateDriver(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/v1/driver/registration"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetDriverAPI(t *testing.T) {
+	driver, _ := randomDriver(t)
+
+	testCases := []struct {
+		name          string
+		driverID      int64
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			driverID: driver.ID,
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDriver(gomock.Any(), gomock.Eq(driver.ID)).
+					Times(1).
+					Return(driver, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchDriver(t, recorder, driver)
+			},
+		},
+		{
+			name:     "NotFound",
+			driverID: driver.ID,
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDriver(gomock.Any(), gomock.Eq(driver.ID)).
+					Times(1).
+					Return(db.Driver{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			driverID: driver.ID,
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDriver(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Driver{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:     "NoAuthorization",
+			driverID: driver.ID,
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDriver(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/v1/driver/%d", tc.driverID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestLoginDriverAPI(t *testing.T) {
+	driver, password := randomDriver(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"mobile":   driver.Mobile,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDriverByMobile(gomock.Any(), gomock.Eq(driver.Mobile)).
+					Times(1).
+					Return(driver, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateSessionParams) (db.Session, error) {
+						require.Equal(t, driver.Mobile, arg.Username)
+						require.Equal(t, token.RoleDriver, arg.Role)
+						require.Equal(t, driver.ID, arg.UserID)
+						require.NotEmpty(t, arg.RefreshToken)
+						return db.Session{ID: arg.ID, ExpiresAt: arg.ExpiresAt}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp LoginDriverResponse
+				requireBodyData(t, recorder.Body, &rsp)
+				require.NotEmpty(t, rsp.SessionID)
+				require.NotEmpty(t, rsp.AccessToken)
+				require.NotEmpty(t, rsp.RefreshToken)
+				require.True(t, rsp.RefreshTokenExpiresAt.After(rsp.AccessTokenExpiresAt))
+				require.Equal(t, newDriverResponse(driver), rsp.User)
+			},
+		},
+		{
+			name: "DriverNotFound",
+			body: gin.H{
+				"mobile":   driver.Mobile,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDriverByMobile(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Driver{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "IncorrectPassword",
+			body: gin.H{
+				"mobile":   driver.Mobile,
+				"password": "incorrect",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDriverByMobile(gomock.Any(), gomock.Eq(driver.Mobile)).
+					Times(1).
+					Return(driver, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/v1/driver/login"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/fare"
+	"github.com/gin-gonic/gin"
+)
+
+type EstimateFareRequest struct {
+	PickupLat   *float64 `json:"pickup_lat" binding:"required,min=-90,max=90"`
+	PickupLong  *float64 `json:"pickup_long" binding:"required,min=-180,max=180"`
+	DropoffLat  *float64 `json:"dropoff_lat" binding:"required,min=-90,max=90"`
+	DropoffLong *float64 `json:"dropoff_long" binding:"required,min=-180,max=180"`
+	CarType     string   `json:"car_type"`
+	// Stops are priced as extra legs between pickup and dropoff.
+	Stops []TripStopRequest `json:"stops" binding:"max=5,dive"`
+}
+
+type EstimateFareResponse struct {
+	fare.Route
+	SurgeMultiplier float64         `json:"surge_multiplier"`
+	Estimates       []fare.Estimate `json:"estimates"`
+}
+
+func (server *Server) estimateFare(ctx *gin.Context) {
+	var req EstimateFareRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	var rules []db.FareRule
+	if req.CarType != "" {
+		rule, err := server.store.GetFareRule(ctx, req.CarType)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+					Status:  false,
+					Message: "No fare rule for this car type"}))
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+				Status:  false,
+				Message: err.Error()}))
+			return
+		}
+		rules = append(rules, rule)
+	} else {
+		var err error
+		rules, err = server.store.ListFareRules(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+				Status:  false,
+				Message: err.Error()}))
+			return
+		}
+	}
+
+	surge, err := server.surgeMultiplier(ctx, *req.PickupLat, *req.PickupLong)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	route := fare.EstimateStops(routePoints(*req.PickupLat, *req.PickupLong, req.Stops, *req.DropoffLat, *req.DropoffLong))
+
+	rsp := EstimateFareResponse{
+		Route:           route,
+		SurgeMultiplier: surge,
+		Estimates:       make([]fare.Estimate, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		rsp.Estimates = append(rsp.Estimates, db.NewFareRule(rule).Estimate(route, surge))
+	}
+
+	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Fare estimated successfully",
+		Data:    rsp}))
+}
@@ -0,0 +1,281 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/emonoid/toribook.git/db/mock"
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/fare"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomFareRule(carType string) db.FareRule {
+	return db.FareRule{
+		CarType:     carType,
+		BaseFare:    50,
+		PerKm:       20,
+		PerMinute:   3,
+		MinimumFare: 120,
+		BookingFee:  10,
+		UpdatedAt:   time.Now(),
+	}
+}
+
+func estimateFareBody() gin.H {
+	return gin.H{
+		"pickup_lat":   23.7937,
+		"pickup_long":  90.4066,
+		"dropoff_lat":  23.7330,
+		"dropoff_long": 90.4172,
+	}
+}
+
+func TestEstimateFareAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	sedan := randomFareRule("sedan")
+	bike := randomFareRule("bike")
+	bike.BaseFare, bike.PerKm, bike.PerMinute, bike.MinimumFare = 20, 10, 1, 50
+
+	route := fare.EstimateRoute(23.7937, 90.4066, 23.7330, 90.4172)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "AllCarTypes",
+			body: estimateFareBody(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListFareRules(gomock.Any()).
+					Times(1).
+					Return([]db.FareRule{bike, sedan}, nil)
+				store.EXPECT().
+					CountOpenTripsInArea(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp EstimateFareResponse
+				requireBodyData(t, recorder.Body, &rsp)
+				require.InDelta(t, route.DistanceKm, rsp.DistanceKm, 1e-9)
+				require.Equal(t, []fare.Estimate{
+					db.NewFareRule(bike).Estimate(route, 1),
+					db.NewFareRule(sedan).Estimate(route, 1),
+				}, rsp.Estimates)
+				require.Less(t, rsp.Estimates[0].MinFare, rsp.Estimates[1].MinFare)
+				require.LessOrEqual(t, rsp.Estimates[1].MinFare, rsp.Estimates[1].MaxFare)
+			},
+		},
+		{
+			name: "OneCarType",
+			body: func() gin.H {
+				body := estimateFareBody()
+				body["car_type"] = "sedan"
+				return body
+			}(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFareRule(gomock.Any(), gomock.Eq("sedan")).
+					Times(1).
+					Return(sedan, nil)
+				store.EXPECT().
+					ListFareRules(gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CountOpenTripsInArea(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp EstimateFareResponse
+				requireBodyData(t, recorder.Body, &rsp)
+				require.Equal(t, []fare.Estimate{db.NewFareRule(sedan).Estimate(route, 1)}, rsp.Estimates)
+			},
+		},
+		{
+			name: "WithStops",
+			body: func() gin.H {
+				body := estimateFareBody()
+				body["car_type"] = "sedan"
+				body["stops"] = []gin.H{{"location": "Tejgaon", "lat": 23.7639, "long": 90.3889}}
+				return body
+			}(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFareRule(gomock.Any(), gomock.Eq("sedan")).
+					Times(1).
+					Return(sedan, nil)
+				store.EXPECT().
+					CountOpenTripsInArea(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				stopped := fare.EstimateStops([]fare.Point{
+					{Lat: 23.7937, Long: 90.4066},
+					{Lat: 23.7639, Long: 90.3889},
+					{Lat: 23.7330, Long: 90.4172},
+				})
+
+				var rsp EstimateFareResponse
+				requireBodyData(t, recorder.Body, &rsp)
+				require.Len(t, rsp.Legs, 2)
+				require.InDelta(t, stopped.DistanceKm, rsp.DistanceKm, 1e-9)
+				require.Greater(t, rsp.DistanceKm, route.DistanceKm)
+				require.Equal(t, []fare.Estimate{db.NewFareRule(sedan).Estimate(stopped, 1)}, rsp.Estimates)
+			},
+		},
+		{
+			name: "Surge",
+			body: func() gin.H {
+				body := estimateFareBody()
+				body["car_type"] = "sedan"
+				return body
+			}(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFareRule(gomock.Any(), gomock.Eq("sedan")).
+					Times(1).
+					Return(sedan, nil)
+				store.EXPECT().
+					CountOpenTripsInArea(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(2), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp EstimateFareResponse
+				requireBodyData(t, recorder.Body, &rsp)
+				require.Equal(t, 1.5, rsp.SurgeMultiplier)
+				require.Equal(t, []fare.Estimate{db.NewFareRule(sedan).Estimate(route, 1.5)}, rsp.Estimates)
+			},
+		},
+		{
+			name: "UnknownCarType",
+			body: func() gin.H {
+				body := estimateFareBody()
+				body["car_type"] = "boat"
+				return body
+			}(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetFareRule(gomock.Any(), gomock.Eq("boat")).
+					Times(1).
+					Return(db.FareRule{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCoordinates",
+			body: func() gin.H {
+				body := estimateFareBody()
+				body["dropoff_lat"] = 91
+				return body
+			}(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListFareRules(gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: estimateFareBody(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListFareRules(gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			body: estimateFareBody(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListFareRules(gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/v1/trip/estimate"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultHistoryPageSize = 20
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+type TripHistoryRequest struct {
+	TripStatus string `form:"status"`
+	CarType    string `form:"car_type"`
+	// From and To limit the history to trips created in [From, To).
+	From     time.Time `form:"from"`
+	To       time.Time `form:"to"`
+	PageSize int32     `form:"page_size" binding:"omitempty,min=1,max=50"`
+	Cursor   string    `form:"cursor"`
+}
+
+type TripHistoryResponse struct {
+	Trips      []TripResponse `json:"trips"`
+	TotalCount int64          `json:"total_count"`
+	// NextCursor fetches the following page and is null on the last one.
+	NextCursor *string `json:"next_cursor"`
+}
+
+// encodeTripCursor returns an opaque cursor pointing just past trip in the
+// newest first history order.
+func encodeTripCursor(trip db.Trip) string {
+	raw := fmt.Sprintf("%d:%d", trip.CreatedAt.UnixNano(), trip.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeTripCursor(cursor string) (time.Time, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, errInvalidCursor
+	}
+
+	var createdAt, id int64
+	if _, err := fmt.Sscanf(string(raw), "%d:%d", &createdAt, &id); err != nil {
+		return time.Time{}, 0, errInvalidCursor
+	}
+
+	return time.Unix(0, createdAt).UTC(), id, nil
+}
+
+func (server *Server) passengerTripHistory(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+	server.tripHistory(ctx, sql.NullInt64{Int64: authPayload.UserID, Valid: true}, sql.NullInt64{})
+}
+
+func (server *Server) driverTripHistory(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+	server.tripHistory(ctx, sql.NullInt64{}, sql.NullInt64{Int64: authPayload.UserID, Valid: true})
+}
+
+// tripHistory lists the trips of one passenger or driver newest first, one
+// page at a time. Pages are keyed on (created_at, id) so trips booked while
+// scrolling never shift or repeat entries.
+func (server *Server) tripHistory(ctx *gin.Context, passengerID, driverID sql.NullInt64) {
+	var req TripHistoryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	if req.TripStatus != "" && !db.IsValidTripStatus(req.TripStatus) {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Invalid trip status"}))
+		return
+	}
+
+	if !req.From.IsZero() && !req.To.IsZero() && !req.From.Before(req.To) {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "from must be before to"}))
+		return
+	}
+
+	pageSize := req.PageSize
+	if pageSize == 0 {
+		pageSize = defaultHistoryPageSize
+	}
+
+	arg := db.ListTripHistoryParams{
+		PassengerID: passengerID,
+		DriverID:    driverID,
+		TripStatus:  sql.NullString{String: req.TripStatus, Valid: req.TripStatus != ""},
+		CarType:     sql.NullString{String: req.CarType, Valid: req.CarType != ""},
+		CreatedFrom: sql.NullTime{Time: req.From, Valid: !req.From.IsZero()},
+		CreatedTo:   sql.NullTime{Time: req.To, Valid: !req.To.IsZero()},
+		// one extra row tells whether another page follows
+		PageSize: pageSize + 1,
+	}
+
+	if req.Cursor != "" {
+		createdAt, id, err := decodeTripCursor(req.Cursor)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+				Status:  false,
+				Message: err.Error()}))
+			return
+		}
+		arg.CursorCreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+		arg.CursorID = sql.NullInt64{Int64: id, Valid: true}
+	}
+
+	trips, err := server.store.ListTripHistory(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	total, err := server.store.CountTripHistory(ctx, db.CountTripHistoryParams{
+		PassengerID: arg.PassengerID,
+		DriverID:    arg.DriverID,
+		TripStatus:  arg.TripStatus,
+		CarType:     arg.CarType,
+		CreatedFrom: arg.CreatedFrom,
+		CreatedTo:   arg.CreatedTo,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	rsp := TripHistoryResponse{
+		Trips:      make([]TripResponse, 0, len(trips)),
+		TotalCount: total,
+	}
+
+	if len(trips) > int(pageSize) {
+		trips = trips[:pageSize]
+		next := encodeTripCursor(trips[len(trips)-1])
+		rsp.NextCursor = &next
+	}
+
+	for _, trip := range trips {
+		rsp.Trips = append(rsp.Trips, newTripResponse(trip))
+	}
+
+	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Trips retrieved successfully",
+		Data:    rsp,
+	}))
+}
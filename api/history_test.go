@@ -0,0 +1,299 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	mockdb "github.com/emonoid/toribook.git/db/mock"
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTripCursor(t *testing.T) {
+	trip := randomTrip(1, db.TripStatusCompleted)
+	trip.CreatedAt = time.Date(2026, 5, 1, 8, 30, 0, 123456000, time.UTC)
+
+	createdAt, id, err := decodeTripCursor(encodeTripCursor(trip))
+	require.NoError(t, err)
+	require.True(t, trip.CreatedAt.Equal(createdAt))
+	require.Equal(t, trip.ID, id)
+
+	_, _, err = decodeTripCursor("not a cursor")
+	require.ErrorIs(t, err, errInvalidCursor)
+}
+
+func TestTripHistoryAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	driver, _ := randomDriver(t)
+
+	trips := make([]db.Trip, 3)
+	for i := range trips {
+		trips[i] = randomTrip(passenger.ID, db.TripStatusCompleted)
+		trips[i].ID = int64(300 - i)
+		trips[i].CreatedAt = time.Now().Add(-time.Duration(i) * time.Hour)
+	}
+
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	passengerAuth := func(t *testing.T, request *http.Request, server *Server) {
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+	}
+	driverAuth := func(t *testing.T, request *http.Request, server *Server) {
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+	}
+
+	testCases := []struct {
+		name          string
+		url           string
+		query         url.Values
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "PassengerFirstPage",
+			url:       "/api/v1/passenger/trips",
+			query:     url.Values{"page_size": {"2"}},
+			setupAuth: passengerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTripHistory(gomock.Any(), gomock.Eq(db.ListTripHistoryParams{
+						PassengerID: sql.NullInt64{Int64: passenger.ID, Valid: true},
+						PageSize:    3,
+					})).
+					Times(1).
+					Return(trips, nil)
+				store.EXPECT().
+					CountTripHistory(gomock.Any(), gomock.Eq(db.CountTripHistoryParams{
+						PassengerID: sql.NullInt64{Int64: passenger.ID, Valid: true},
+					})).
+					Times(1).
+					Return(int64(7), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp TripHistoryResponse
+				requireBodyData(t, recorder.Body, &rsp)
+				require.Len(t, rsp.Trips, 2)
+				require.Equal(t, trips[0].BookingID, rsp.Trips[0].BookingID)
+				require.Equal(t, int64(7), rsp.TotalCount)
+				require.NotNil(t, rsp.NextCursor)
+				require.Equal(t, encodeTripCursor(trips[1]), *rsp.NextCursor)
+			},
+		},
+		{
+			name:      "LastPageFromCursor",
+			url:       "/api/v1/passenger/trips",
+			query:     url.Values{"page_size": {"2"}, "cursor": {encodeTripCursor(trips[1])}},
+			setupAuth: passengerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTripHistory(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.ListTripHistoryParams) ([]db.Trip, error) {
+						require.True(t, arg.CursorCreatedAt.Valid)
+						require.True(t, trips[1].CreatedAt.Equal(arg.CursorCreatedAt.Time))
+						require.Equal(t, sql.NullInt64{Int64: trips[1].ID, Valid: true}, arg.CursorID)
+						return trips[2:], nil
+					})
+				store.EXPECT().
+					CountTripHistory(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(3), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp TripHistoryResponse
+				requireBodyData(t, recorder.Body, &rsp)
+				require.Len(t, rsp.Trips, 1)
+				require.Nil(t, rsp.NextCursor)
+			},
+		},
+		{
+			name: "Filters",
+			url:  "/api/v1/passenger/trips",
+			query: url.Values{
+				"status":   {db.TripStatusCompleted},
+				"car_type": {"sedan"},
+				"from":     {from.Format(time.RFC3339)},
+				"to":       {to.Format(time.RFC3339)},
+			},
+			setupAuth: passengerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTripHistory(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.ListTripHistoryParams) ([]db.Trip, error) {
+						require.Equal(t, sql.NullString{String: db.TripStatusCompleted, Valid: true}, arg.TripStatus)
+						require.Equal(t, sql.NullString{String: "sedan", Valid: true}, arg.CarType)
+						require.True(t, from.Equal(arg.CreatedFrom.Time))
+						require.True(t, to.Equal(arg.CreatedTo.Time))
+						require.Equal(t, int32(defaultHistoryPageSize+1), arg.PageSize)
+						return nil, nil
+					})
+				store.EXPECT().
+					CountTripHistory(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp TripHistoryResponse
+				requireBodyData(t, recorder.Body, &rsp)
+				require.NotNil(t, rsp.Trips)
+				require.Empty(t, rsp.Trips)
+				require.Zero(t, rsp.TotalCount)
+				require.Nil(t, rsp.NextCursor)
+			},
+		},
+		{
+			name:      "Driver",
+			url:       "/api/v1/driver/trips",
+			setupAuth: driverAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTripHistory(gomock.Any(), gomock.Eq(db.ListTripHistoryParams{
+						DriverID: sql.NullInt64{Int64: driver.ID, Valid: true},
+						PageSize: defaultHistoryPageSize + 1,
+					})).
+					Times(1).
+					Return(trips, nil)
+				store.EXPECT().
+					CountTripHistory(gomock.Any(), gomock.Eq(db.CountTripHistoryParams{
+						DriverID: sql.NullInt64{Int64: driver.ID, Valid: true},
+					})).
+					Times(1).
+					Return(int64(3), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp TripHistoryResponse
+				requireBodyData(t, recorder.Body, &rsp)
+				require.Len(t, rsp.Trips, 3)
+				require.Nil(t, rsp.NextCursor)
+			},
+		},
+		{
+			name:      "InvalidStatus",
+			url:       "/api/v1/passenger/trips",
+			query:     url.Values{"status": {"lost"}},
+			setupAuth: passengerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTripHistory(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidCursor",
+			url:       "/api/v1/passenger/trips",
+			query:     url.Values{"cursor": {"!!"}},
+			setupAuth: passengerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTripHistory(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "FromAfterTo",
+			url:  "/api/v1/passenger/trips",
+			query: url.Values{
+				"from": {to.Format(time.RFC3339)},
+				"to":   {from.Format(time.RFC3339)},
+			},
+			setupAuth: passengerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTripHistory(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "PageSizeTooLarge",
+			url:       "/api/v1/passenger/trips",
+			query:     url.Values{"page_size": {"500"}},
+			setupAuth: passengerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTripHistory(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "PassengerOnDriverEndpoint",
+			url:       "/api/v1/driver/trips",
+			setupAuth: passengerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTripHistory(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "InternalError",
+			url:       "/api/v1/passenger/trips",
+			setupAuth: passengerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTripHistory(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+				store.EXPECT().
+					CountTripHistory(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, tc.url+"?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
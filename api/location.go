@@ -0,0 +1,363 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/location"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gorilla/websocket"
+)
+
+type UpdateLocationRequest struct {
+	Lat     *float64 `json:"lat" binding:"required,min=-90,max=90"`
+	Long    *float64 `json:"long" binding:"required,min=-180,max=180"`
+	Heading float64  `json:"heading" binding:"min=0,max=360"`
+	Speed   float64  `json:"speed" binding:"min=0"`
+}
+
+// tripLocationChannel is the websocket channel carrying the assigned driver's
+// position to the passenger of a trip.
+func tripLocationChannel(bookingID string) string {
+	return "trip_location: " + bookingID
+}
+
+// recordDriverLocation stores a driver's latest position and forwards it to
+// the passenger of the trip the driver is currently serving, if any.
+func (server *Server) recordDriverLocation(ctx context.Context, driverID int64, req UpdateLocationRequest) (location.Fix, error) {
+	fix := location.Fix{
+		DriverID:   driverID,
+		Lat:        *req.Lat,
+		Long:       *req.Long,
+		Heading:    req.Heading,
+		Speed:      req.Speed,
+		RecordedAt: time.Now().UTC(),
+	}
+
+	if err := server.locations.SaveLocation(ctx, fix); err != nil {
+		return fix, err
+	}
+
+	trip, err := server.store.GetDriverActiveTrip(ctx, sql.NullInt64{Int64: driverID, Valid: true})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fix, nil
+		}
+		return fix, err
+	}
+
+	server.webSocketManager.Broadcast(tripLocationChannel(trip.BookingID), finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Driver location updated",
+		Data:    fix,
+	}))
+
+	return fix, nil
+}
+
+func (server *Server) updateDriverLocation(ctx *gin.Context) {
+	var req UpdateLocationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+
+	fix, err := server.recordDriverLocation(ctx, authPayload.UserID, req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Location updated",
+		Data:    fix}))
+}
+
+var locationUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// driverLocationWebSocket lets a driver app stream GPS fixes over one
+// connection instead of posting each of them.
+func (server *Server) driverLocationWebSocket(ctx *gin.Context) {
+	tokenString := ctx.Query("token")
+
+	if tokenString == "" {
+		ctx.JSON(http.StatusUnauthorized, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Missing token"}))
+		return
+	}
+
+	payload, err := server.verifyToken(ctx, tokenString)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	if payload.Role != token.RoleDriver {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Only drivers can share their location"}))
+		return
+	}
+
+	conn, err := locationUpgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	for {
+		var req UpdateLocationRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) {
+				log.Println("Invalid location message:", err)
+			}
+			break
+		}
+
+		if err := binding.Validator.ValidateStruct(&req); err != nil {
+			_ = conn.WriteJSON(finalResponse(FinalResponse{
+				Status:  false,
+				Message: err.Error()}))
+			continue
+		}
+
+		if _, err := server.recordDriverLocation(context.Background(), payload.UserID, req); err != nil {
+			log.Println("Failed to record driver location:", err)
+		}
+	}
+}
+
+// tripLocationWebSocket streams the assigned driver's position to the
+// passenger of a trip, starting with the last known one.
+func (server *Server) tripLocationWebSocket(ctx *gin.Context) {
+	tokenString := ctx.Query("token")
+	bookingID := ctx.Query("booking_id")
+
+	if tokenString == "" || bookingID == "" {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Missing token or booking_id"}))
+		return
+	}
+
+	payload, err := server.verifyToken(ctx, tokenString)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	trip, err := server.store.GetTripByBookingID(ctx, bookingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Trip not found"}))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	if !isTripParticipant(trip, payload) {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Trip not found"}))
+		return
+	}
+
+	conn, err := locationUpgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		return
+	}
+
+	if trip.DriverID.Valid {
+		fix, err := server.locations.GetLocation(ctx, trip.DriverID.Int64)
+		if err == nil {
+			_ = conn.WriteJSON(finalResponse(FinalResponse{
+				Status:  true,
+				Message: "Driver location updated",
+				Data:    fix}))
+		} else if !errors.Is(err, location.ErrLocationNotFound) {
+			log.Println("Failed to load driver location:", err)
+		}
+	}
+
+	channel := tripLocationChannel(trip.BookingID)
+	server.webSocketManager.AddClient(channel, conn)
+	defer func() {
+		server.webSocketManager.RemoveClient(channel, conn)
+		conn.Close()
+	}()
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+}
+
+// isTripParticipant reports whether the token belongs to the trip's
+// passenger or to its assigned driver.
+func isTripParticipant(trip db.Trip, payload *token.Payload) bool {
+	switch payload.Role {
+	case token.RolePassenger:
+		return isTripPassenger(trip, payload)
+	case token.RoleDriver:
+		return trip.DriverID.Valid && trip.DriverID.Int64 == payload.UserID
+	}
+	return false
+}
+
+// canViewTrip reports whether the token may read trip: its passenger, its
+// assigned driver, or any driver while the trip is still open for offers.
+func canViewTrip(trip db.Trip, payload *token.Payload) bool {
+	if isTripParticipant(trip, payload) {
+		return true
+	}
+	return payload.Role == token.RoleDriver && !trip.DriverID.Valid &&
+		(trip.TripStatus == db.TripStatusRequested || trip.TripStatus == db.TripStatusBidding)
+}
+
+// isTripPassenger reports whether the token belongs to the trip's passenger.
+func isTripPassenger(trip db.Trip, payload *token.Payload) bool {
+	return payload.Role == token.RolePassenger && trip.PassengerID.Valid && trip.PassengerID.Int64 == payload.UserID
+}
+
+const (
+	defaultNearbyRadiusKm = 5
+	defaultNearbyLimit    = 10
+	// nearbyCandidates is how many positions are pulled from the geo index
+	// before offline, unsubscribed, busy and other car type drivers are
+	// filtered out.
+	nearbyCandidates = 100
+)
+
+type NearbyDriversRequest struct {
+	Lat     *float64 `form:"lat" binding:"required,min=-90,max=90"`
+	Long    *float64 `form:"long" binding:"required,min=-180,max=180"`
+	Radius  float64  `form:"radius" binding:"omitempty,gt=0,max=50"`
+	CarType string   `form:"car_type"`
+	Limit   int      `form:"limit" binding:"omitempty,min=1,max=50"`
+}
+
+type NearbyDriverResponse struct {
+	DriverID   int64   `json:"driver_id"`
+	FullName   string  `json:"full_name"`
+	CarID      int64   `json:"car_id"`
+	CarType    string  `json:"car_type"`
+	CarImage   string  `json:"car_image"`
+	Rating     float64 `json:"rating"`
+	Lat        float64 `json:"lat"`
+	Long       float64 `json:"long"`
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// findNearbyDrivers returns up to limit online, subscribed, free drivers
+// within radiusKm of the point, closest first. An empty carType matches every car type.
+func (server *Server) findNearbyDrivers(ctx context.Context, lat, long, radiusKm float64, carType string, limit int) ([]NearbyDriverResponse, error) {
+	candidates, err := server.locations.Nearby(ctx, lat, long, radiusKm, nearbyCandidates)
+	if err != nil || len(candidates) == 0 {
+		return nil, err
+	}
+
+	ids := make([]int64, len(candidates))
+	for i, candidate := range candidates {
+		ids[i] = candidate.DriverID
+	}
+
+	drivers, err := server.store.ListAvailableDrivers(ctx, db.ListAvailableDriversParams{
+		Ids:     ids,
+		CarType: sql.NullString{String: carType, Valid: carType != ""},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	available := make(map[int64]db.Driver, len(drivers))
+	for _, driver := range drivers {
+		available[driver.ID] = driver
+	}
+
+	var nearby []NearbyDriverResponse
+	for _, candidate := range candidates {
+		driver, ok := available[candidate.DriverID]
+		if !ok {
+			continue
+		}
+
+		nearby = append(nearby, NearbyDriverResponse{
+			DriverID:   driver.ID,
+			FullName:   driver.FullName,
+			CarID:      driver.CarID,
+			CarType:    driver.CarType,
+			CarImage:   driver.CarImage,
+			Rating:     driver.Rating,
+			Lat:        candidate.Lat,
+			Long:       candidate.Long,
+			DistanceKm: candidate.DistanceKm,
+		})
+		if len(nearby) == limit {
+			break
+		}
+	}
+
+	return nearby, nil
+}
+
+func (server *Server) getNearbyDrivers(ctx *gin.Context) {
+	var req NearbyDriversRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	if req.Radius == 0 {
+		req.Radius = defaultNearbyRadiusKm
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultNearbyLimit
+	}
+
+	drivers, err := server.findNearbyDrivers(ctx, *req.Lat, *req.Long, req.Radius, req.CarType, req.Limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	if drivers == nil {
+		drivers = []NearbyDriverResponse{}
+	}
+
+	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Nearby drivers retrieved successfully",
+		Data:    drivers}))
+}
@@ -0,0 +1,422 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/emonoid/toribook.git/db/mock"
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/location"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateDriverLocationAPI(t *testing.T) {
+	driver, _ := randomDriver(t)
+	passenger, _ := randomPassenger(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, server *Server, recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"lat":     23.7937,
+				"long":    90.4066,
+				"heading": 90,
+				"speed":   8.5,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDriverActiveTrip(gomock.Any(), gomock.Eq(sql.NullInt64{Int64: driver.ID, Valid: true})).
+					Times(1).
+					Return(db.Trip{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				fix, err := server.locations.GetLocation(context.Background(), driver.ID)
+				require.NoError(t, err)
+				require.Equal(t, 23.7937, fix.Lat)
+				require.Equal(t, 90.4066, fix.Long)
+				require.Equal(t, 8.5, fix.Speed)
+			},
+		},
+		{
+			name: "ZeroCoordinates",
+			body: gin.H{
+				"lat":  0,
+				"long": 0,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDriverActiveTrip(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Trip{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "LatitudeOutOfRange",
+			body: gin.H{
+				"lat":  123.4,
+				"long": 90.4066,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDriverActiveTrip(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+				_, err := server.locations.GetLocation(context.Background(), driver.ID)
+				require.ErrorIs(t, err, location.ErrLocationNotFound)
+			},
+		},
+		{
+			name: "MissingLongitude",
+			body: gin.H{
+				"lat": 23.7937,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDriverActiveTrip(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Passenger",
+			body: gin.H{
+				"lat":  23.7937,
+				"long": 90.4066,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetDriverActiveTrip(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/v1/driver/location"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, server, recorder)
+		})
+	}
+}
+
+func TestTripLocationWebSocket(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	driver, _ := randomDriver(t)
+
+	trip := randomTrip(passenger.ID, db.TripStatusDriverArriving)
+	trip.DriverID = sql.NullInt64{Int64: driver.ID, Valid: true}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+		Times(1).
+		Return(trip, nil)
+	store.EXPECT().
+		GetDriverActiveTrip(gomock.Any(), gomock.Eq(trip.DriverID)).
+		Times(1).
+		Return(trip, nil)
+
+	server := newTestServer(t, store)
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	// the last known position is sent as soon as the passenger connects
+	lastFix := location.Fix{DriverID: driver.ID, Lat: 23.79, Long: 90.40, RecordedAt: time.Now().UTC()}
+	require.NoError(t, server.locations.SaveLocation(context.Background(), lastFix))
+
+	passengerToken, _, err := server.tokenMaker.CreateToken(passenger.Email, token.RolePassenger, passenger.ID, token.TokenTypeAccess, uuid.Nil, time.Minute)
+	require.NoError(t, err)
+
+	wsURL := fmt.Sprintf("%s/api/v1/ws/trip/location?token=%s&booking_id=%s",
+		strings.Replace(httpServer.URL, "http", "ws", 1), passengerToken, trip.BookingID)
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	readFix := func() location.Fix {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		_, message, err := conn.ReadMessage()
+		require.NoError(t, err)
+
+		var fix location.Fix
+		requireBodyData(t, bytes.NewReader(message), &fix)
+		return fix
+	}
+
+	require.Equal(t, lastFix.Lat, readFix().Lat)
+
+	// wait until the passenger is subscribed before the driver moves
+	require.Eventually(t, func() bool {
+		return server.webSocketManager.ClientCount(tripLocationChannel(trip.BookingID)) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	data, err := json.Marshal(gin.H{"lat": 23.8, "long": 90.41})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/driver/location", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	fix := readFix()
+	require.Equal(t, driver.ID, fix.DriverID)
+	require.Equal(t, 23.8, fix.Lat)
+	require.Equal(t, 90.41, fix.Long)
+}
+
+func TestNearbyDriversAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	nearDriver, _ := randomDriver(t)
+	farDriver, _ := randomDriver(t)
+	busyDriver, _ := randomDriver(t)
+
+	// roughly 0.5km, 2km and 1km north of the pickup point
+	fixes := []location.Fix{
+		{DriverID: nearDriver.ID, Lat: 23.7982, Long: 90.4066},
+		{DriverID: farDriver.ID, Lat: 23.8117, Long: 90.4066},
+		{DriverID: busyDriver.ID, Lat: 23.8027, Long: 90.4066},
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "lat=23.7937&long=90.4066",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAvailableDriversParams{
+					Ids: []int64{nearDriver.ID, busyDriver.ID, farDriver.ID},
+				}
+
+				store.EXPECT().
+					ListAvailableDrivers(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.Driver{farDriver, nearDriver}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var drivers []NearbyDriverResponse
+				requireBodyData(t, recorder.Body, &drivers)
+				require.Len(t, drivers, 2)
+				require.Equal(t, nearDriver.ID, drivers[0].DriverID)
+				require.Equal(t, farDriver.ID, drivers[1].DriverID)
+				require.InDelta(t, 0.5, drivers[0].DistanceKm, 0.05)
+				require.InDelta(t, 2, drivers[1].DistanceKm, 0.05)
+			},
+		},
+		{
+			name:  "RadiusCarTypeAndLimit",
+			query: "lat=23.7937&long=90.4066&radius=1.5&car_type=sedan&limit=1",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAvailableDriversParams{
+					Ids:     []int64{nearDriver.ID, busyDriver.ID},
+					CarType: sql.NullString{String: "sedan", Valid: true},
+				}
+
+				store.EXPECT().
+					ListAvailableDrivers(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.Driver{nearDriver, busyDriver}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var drivers []NearbyDriverResponse
+				requireBodyData(t, recorder.Body, &drivers)
+				require.Len(t, drivers, 1)
+				require.Equal(t, nearDriver.ID, drivers[0].DriverID)
+			},
+		},
+		{
+			name:  "NoDriversInRadius",
+			query: "lat=24.8949&long=91.8687",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAvailableDrivers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var drivers []NearbyDriverResponse
+				requireBodyData(t, recorder.Body, &drivers)
+				require.NotNil(t, drivers)
+				require.Empty(t, drivers)
+			},
+		},
+		{
+			name:  "MissingLatitude",
+			query: "long=90.4066",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAvailableDrivers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "RadiusTooLarge",
+			query: "lat=23.7937&long=90.4066&radius=500",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAvailableDrivers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: "lat=23.7937&long=90.4066",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAvailableDrivers(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:  "NoAuthorization",
+			query: "lat=23.7937&long=90.4066",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAvailableDrivers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			for _, fix := range fixes {
+				fix.RecordedAt = time.Now().UTC()
+				require.NoError(t, server.locations.SaveLocation(context.Background(), fix))
+			}
+
+			recorder := httptest.NewRecorder()
+
+			url := "/api/v1/driver/nearby?" + tc.query
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, store db.Store) *Server {
+	redisServer := miniredis.RunT(t)
+
+	config := utils.Config{
+		TokenSymmetricKey:    utils.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+		RedisAddress:         redisServer.Addr(),
+		PaymentWebhookSecret: utils.RandomString(32),
+		PaymentFakeGateway:   true,
+		AdminAPIKey:          utils.RandomString(32),
+	}
+
+	server, err := NewServer(config, store)
+	require.NoError(t, err)
+
+	return server
+}
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+
+	os.Exit(m.Run())
+}
+
+type testResponse struct {
+	Status  bool            `json:"status"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// requireBodyData decodes the data field of a finalResponse body into out.
+func requireBodyData(t *testing.T, body io.Reader, out interface{}) {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var rsp testResponse
+	err = json.Unmarshal(data, &rsp)
+	require.NoError(t, err)
+
+	err = json.Unmarshal(rsp.Data, out)
+	require.NoError(t, err)
+}
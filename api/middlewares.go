@@ -1,6 +1,7 @@
 package api
 
 import (
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"net/http"
@@ -14,9 +15,10 @@ const (
 	authorizationHeaderKey  = "authorization"
 	authorizationTypeBearer = "bearer"
 	authorizationPayloadkey = "authorization_payload"
+	adminKeyHeaderKey       = "x-admin-key"
 )
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+func authMiddleware(tokenMaker token.Maker, revocations revocationList) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 		if len(authorizationHeader) == 0 {
@@ -47,9 +49,13 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 
 		accessToken := fields[1]
 
-		payload, err := tokenMaker.VerifyToken(accessToken)
+		payload, err := verifyUnrevokedToken(ctx, tokenMaker, revocations, accessToken, token.TokenTypeAccess)
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, finalResponse(FinalResponse{
+			status := http.StatusUnauthorized
+			if !isTokenError(err) {
+				status = http.StatusInternalServerError
+			}
+			ctx.AbortWithStatusJSON(status, finalResponse(FinalResponse{
 				Status:  false,
 				Message: err.Error()}))
 			return
@@ -60,3 +66,47 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// isTokenError reports whether err means the client sent a bad token, as
+// opposed to the revocation list being unavailable.
+func isTokenError(err error) bool {
+	return errors.Is(err, token.ErrInvalidToken) || errors.Is(err, token.ErrExpiredToken) || errors.Is(err, ErrRevokedToken)
+}
+
+// requireRole aborts requests whose access token was not issued for one of roles.
+// It must run after authMiddleware.
+func requireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+
+		for _, role := range roles {
+			if payload.Role == role {
+				ctx.Next()
+				return
+			}
+		}
+
+		err := fmt.Errorf("this action is not allowed for role %q", payload.Role)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+	}
+}
+
+// requireAdminKey only lets through requests carrying the platform's admin
+// API key, so moves of platform money like refunds are out of reach of any
+// passenger or driver token. With no key configured every request is refused.
+func requireAdminKey(adminKey string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(adminKeyHeaderKey)
+		if adminKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) != 1 {
+			err := errors.New("admin key is missing or invalid")
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, finalResponse(FinalResponse{
+				Status:  false,
+				Message: err.Error()}))
+			return
+		}
+
+		ctx.Next()
+	}
+}
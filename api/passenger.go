@@ -4,10 +4,13 @@ import (
 	"database/sql"
 	"log"
 	"net/http"
+	"time"
 
 	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/token"
 	"github.com/emonoid/toribook.git/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
 
@@ -127,8 +130,12 @@ type LoginPassengerRequest struct {
 }
 
 type LoginPassengerResponse struct {
-	AccessToken string            `json:"access_token"`
-	User        PassengerResponse `json:"user"`
+	SessionID             uuid.UUID         `json:"session_id"`
+	AccessToken           string            `json:"access_token"`
+	AccessTokenExpiresAt  time.Time         `json:"access_token_expires_at"`
+	RefreshToken          string            `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time         `json:"refresh_token_expires_at"`
+	User                  PassengerResponse `json:"user"`
 }
 
 func (server *Server) loginPassenger(ctx *gin.Context) {
@@ -169,7 +176,7 @@ func (server *Server) loginPassenger(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, err := server.tokenMaker.CreateToken(passenger.Email, server.config.AccessTokenDuration)
+	tokens, err := server.createSession(ctx, passenger.Email, token.RolePassenger, passenger.ID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
 			Status:  false,
@@ -178,8 +185,12 @@ func (server *Server) loginPassenger(ctx *gin.Context) {
 	}
 
 	rsp := LoginPassengerResponse{
-		AccessToken: accessToken,
-		User:        newPassengerResponse(passenger),
+		SessionID:             tokens.SessionID,
+		AccessToken:           tokens.AccessToken,
+		AccessTokenExpiresAt:  tokens.AccessTokenExpiresAt,
+		RefreshToken:          tokens.RefreshToken,
+		RefreshTokenExpiresAt: tokens.RefreshTokenExpiresAt,
+		User:                  newPassengerResponse(passenger),
 	}
 
 	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
@@ -0,0 +1,428 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	mockdb "github.com/emonoid/toribook.git/db/mock"
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/emonoid/toribook.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+type eqCreatePassengerParamsMatcher struct {
+	arg      db.CreatePassengerParams
+	password string
+}
+
+func (e eqCreatePassengerParamsMatcher) Matches(x interface{}) bool {
+	arg, ok := x.(db.CreatePassengerParams)
+	if !ok {
+		return false
+	}
+
+	err := utils.CheckPassword(e.password, arg.HashedPassword)
+	if err != nil {
+		return false
+	}
+
+	e.arg.HashedPassword = arg.HashedPassword
+	return reflect.DeepEqual(e.arg, arg)
+}
+
+func (e eqCreatePassengerParamsMatcher) String() string {
+	return fmt.Sprintf("matches arg %v and password %v", e.arg, e.password)
+}
+
+func EqCreatePassengerParams(arg db.CreatePassengerParams, password string) gomock.Matcher {
+	return eqCreatePassengerParamsMatcher{arg, password}
+}
+
+func randomPassenger(t *testing.T) (passenger db.Passenger, password string) {
+	password = utils.RandomString(6)
+	hashedPassword, err := utils.HashPassword(password)
+	require.NoError(t, err)
+
+	passenger = db.Passenger{
+		ID:             utils.RandomInt(1, 1000),
+		HashedPassword: hashedPassword,
+		FullName:       utils.RandomName(),
+		Email:          utils.RandomEmail(),
+	}
+	return
+}
+
+func requireBodyMatchPassenger(t *testing.T, recorder *httptest.ResponseRecorder, passenger db.Passenger) {
+	var gotPassenger PassengerResponse
+	requireBodyData(t, recorder.Body, &gotPassenger)
+
+	require.Equal(t, newPassengerResponse(passenger), gotPassenger)
+}
+
+func TestCreatePassengerAPI(t *testing.T) {
+	passenger, password := randomPassenger(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"full_name": passenger.FullName,
+				"email":     passenger.Email,
+				"password":  password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreatePassengerParams{
+					FullName: passenger.FullName,
+					Email:    passenger.Email,
+				}
+				store.EXPECT().
+					CreatePassenger(gomock.Any(), EqCreatePassengerParams(arg, password)).
+					Times(1).
+					Return(passenger, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchPassenger(t, recorder, passenger)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"full_name": passenger.FullName,
+				"email":     passenger.Email,
+				"password":  password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePassenger(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Passenger{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "DuplicateEmail",
+			body: gin.H{
+				"full_name": passenger.FullName,
+				"email":     passenger.Email,
+				"password":  password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePassenger(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Passenger{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: gin.H{
+				"full_name": passenger.FullName,
+				"email":     "invalid-email",
+				"password":  password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePassenger(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "TooShortPassword",
+			body: gin.H{
+				"full_name": passenger.FullName,
+				"email":     passenger.Email,
+				"password":  "123",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePassenger(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/v1/passenger/registration"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetPassengerAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+
+	testCases := []struct {
+		name          string
+		passengerID   int64
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "OK",
+			passengerID: passenger.ID,
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPassenger(gomock.Any(), gomock.Eq(passenger.ID)).
+					Times(1).
+					Return(passenger, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchPassenger(t, recorder, passenger)
+			},
+		},
+		{
+			name:        "NotFound",
+			passengerID: passenger.ID,
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPassenger(gomock.Any(), gomock.Eq(passenger.ID)).
+					Times(1).
+					Return(db.Passenger{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "InternalError",
+			passengerID: passenger.ID,
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPassenger(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Passenger{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:        "InvalidID",
+			passengerID: 0,
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPassenger(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "NoAuthorization",
+			passengerID: passenger.ID,
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPassenger(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/v1/passenger/%d", tc.passengerID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestLoginPassengerAPI(t *testing.T) {
+	passenger, password := randomPassenger(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"email":    passenger.Email,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPassengerByEmail(gomock.Any(), gomock.Eq(passenger.Email)).
+					Times(1).
+					Return(passenger, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateSessionParams) (db.Session, error) {
+						require.Equal(t, passenger.Email, arg.Username)
+						require.Equal(t, token.RolePassenger, arg.Role)
+						require.Equal(t, passenger.ID, arg.UserID)
+						require.NotEmpty(t, arg.RefreshToken)
+						return db.Session{ID: arg.ID, ExpiresAt: arg.ExpiresAt}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp LoginPassengerResponse
+				requireBodyData(t, recorder.Body, &rsp)
+				require.NotEmpty(t, rsp.SessionID)
+				require.NotEmpty(t, rsp.AccessToken)
+				require.NotEmpty(t, rsp.RefreshToken)
+				require.True(t, rsp.RefreshTokenExpiresAt.After(rsp.AccessTokenExpiresAt))
+				require.Equal(t, newPassengerResponse(passenger), rsp.User)
+			},
+		},
+		{
+			name: "UserNotFound",
+			body: gin.H{
+				"email":    passenger.Email,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPassengerByEmail(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Passenger{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "IncorrectPassword",
+			body: gin.H{
+				"email":    passenger.Email,
+				"password": "incorrect",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPassengerByEmail(gomock.Any(), gomock.Eq(passenger.Email)).
+					Times(1).
+					Return(passenger, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: gin.H{
+				"email":    "invalid-email",
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPassengerByEmail(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/v1/passenger/login"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,658 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/helpers"
+	"github.com/emonoid/toribook.git/payments"
+	"github.com/emonoid/toribook.git/receipt"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/gin-gonic/gin"
+)
+
+// paymentSignatureHeader carries the provider's signature of a webhook body.
+const paymentSignatureHeader = "X-Payment-Signature"
+
+type PayRequest struct {
+	Provider string `json:"provider" binding:"required"`
+	// PaymentMethod is the card token from the provider, unused for cash.
+	PaymentMethod string `json:"payment_method"`
+}
+
+type PayTripRequest struct {
+	BookingID string `json:"booking_id" binding:"required"`
+	PayRequest
+}
+
+type PaySubscriptionRequest struct {
+	SubscriptionID int64 `json:"subscription_id" binding:"required,min=1"`
+	PayRequest
+}
+
+type RefundTripPaymentRequest struct {
+	BookingID string `json:"booking_id" binding:"required"`
+	Amount    int64  `json:"amount" binding:"required,min=1"`
+	Reason    string `json:"reason" binding:"max=200"`
+}
+
+type PaymentIntentResponse struct {
+	ID                  int64     `json:"id"`
+	Provider            string    `json:"provider"`
+	Status              string    `json:"status"`
+	Amount              int64     `json:"amount"`
+	CapturedAmount      int64     `json:"captured_amount"`
+	RefundedAmount      int64     `json:"refunded_amount"`
+	RefundPendingAmount int64     `json:"refund_pending_amount"`
+	Currency            string    `json:"currency"`
+	FailureReason       *string   `json:"failure_reason"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+func newPaymentIntentResponse(intent db.PaymentIntent) PaymentIntentResponse {
+	return PaymentIntentResponse{
+		ID:                  intent.ID,
+		Provider:            intent.Provider,
+		Status:              intent.Status,
+		Amount:              intent.Amount,
+		CapturedAmount:      intent.CapturedAmount,
+		RefundedAmount:      intent.RefundedAmount,
+		RefundPendingAmount: intent.RefundPendingAmount,
+		Currency:            intent.Currency,
+		FailureReason:       helpers.NullStringToPtr(intent.FailureReason),
+		CreatedAt:           intent.CreatedAt,
+	}
+}
+
+// payTrip authorizes the fare of a trip of the passenger in the token. The
+// money is captured when the driver completes the trip.
+func (server *Server) payTrip(ctx *gin.Context) {
+	var req PayTripRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	provider, err := server.payments.Get(req.Provider)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	trip, err := server.store.GetTripByBookingID(ctx, req.BookingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Trip not found"}))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+
+	if !trip.PassengerID.Valid || trip.PassengerID.Int64 != authPayload.UserID {
+		ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Trip not found"}))
+		return
+	}
+
+	switch trip.TripStatus {
+	case db.TripStatusCompleted, db.TripStatusCancelled, db.TripStatusExpired:
+		ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Trip can no longer be paid"}))
+		return
+	}
+
+	if !trip.Fare.Valid || trip.Fare.Int64 <= 0 {
+		ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Trip has no fare to pay yet"}))
+		return
+	}
+
+	existing, err := server.store.GetTripPaymentIntent(ctx, sql.NullInt64{Int64: trip.ID, Valid: true})
+	if err != nil && err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+	if err == nil && existing.Status != db.PaymentStatusRefunded {
+		ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Trip is already paid"}))
+		return
+	}
+
+	intent, ok := server.authorizePayment(ctx, provider, req.PayRequest, db.CreatePaymentIntentParams{
+		TripID:    sql.NullInt64{Int64: trip.ID, Valid: true},
+		PayerType: db.AccountOwnerPassenger,
+		PayerID:   authPayload.UserID,
+		Amount:    trip.Fare.Int64,
+	}, trip.BookingID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Payment authorized successfully",
+		Data:    newPaymentIntentResponse(intent),
+	}))
+}
+
+// paySubscription charges the driver in the token for a subscription
+// package. Subscriptions are paid up front, so the charge is captured right
+// away.
+func (server *Server) paySubscription(ctx *gin.Context) {
+	var req PaySubscriptionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	provider, err := server.payments.Get(req.Provider)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	subscription, err := server.store.GetSubscription(ctx, req.SubscriptionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Subscription not found"}))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	amount, err := subscriptionAmount(subscription)
+	if !subscription.Status || err != nil {
+		ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Subscription is not available"}))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+
+	intent, ok := server.authorizePayment(ctx, provider, req.PayRequest, db.CreatePaymentIntentParams{
+		SubscriptionID: sql.NullInt64{Int64: subscription.ID, Valid: true},
+		PayerType:      db.AccountOwnerDriver,
+		PayerID:        authPayload.UserID,
+		Amount:         amount,
+	}, subscription.SubscriptionPackage)
+	if !ok {
+		return
+	}
+
+	intent, err = server.capturePayment(ctx, provider, intent, intent.Amount)
+	if err != nil {
+		ctx.JSON(http.StatusPaymentRequired, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error(),
+			Data:    newPaymentIntentResponse(intent)}))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Subscription paid successfully",
+		Data:    newPaymentIntentResponse(intent),
+	}))
+}
+
+// subscriptionAmount parses the price of a subscription package, which is
+// stored as text, into whole taka.
+func subscriptionAmount(subscription db.Subscription) (int64, error) {
+	amount, err := strconv.ParseFloat(strings.TrimSpace(subscription.SubscriptionAmount), 64)
+	if err != nil {
+		return 0, err
+	}
+	if amount < 1 {
+		return 0, payments.ErrInvalidAmount
+	}
+	return int64(math.Round(amount)), nil
+}
+
+// authorizePayment asks provider to hold arg.Amount and stores the payment
+// intent. Declined payments are stored too, as failed. It writes the error
+// response and returns false when the payment was not authorized.
+func (server *Server) authorizePayment(ctx *gin.Context, provider payments.Provider, req PayRequest, arg db.CreatePaymentIntentParams, reference string) (db.PaymentIntent, bool) {
+	arg.Provider = provider.Name()
+
+	auth, err := provider.Authorize(ctx, payments.AuthorizeRequest{
+		Amount:        arg.Amount,
+		Currency:      receipt.Currency,
+		PaymentMethod: req.PaymentMethod,
+		Reference:     reference,
+	})
+	if err != nil && !errors.Is(err, payments.ErrDeclined) {
+		ctx.JSON(http.StatusBadGateway, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return db.PaymentIntent{}, false
+	}
+
+	if err != nil {
+		arg.Status = db.PaymentStatusFailed
+		arg.FailureReason = sql.NullString{String: err.Error(), Valid: true}
+	} else {
+		arg.Status = db.PaymentStatusAuthorized
+		arg.ProviderRef = sql.NullString{String: auth.ProviderRef, Valid: true}
+	}
+
+	intent, createErr := server.store.CreatePaymentIntent(ctx, arg)
+	if createErr != nil {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: createErr.Error()}))
+		return db.PaymentIntent{}, false
+	}
+
+	if err != nil {
+		ctx.JSON(http.StatusPaymentRequired, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error(),
+			Data:    newPaymentIntentResponse(intent)}))
+		return db.PaymentIntent{}, false
+	}
+
+	return intent, true
+}
+
+// paymentCollector is the owner type of the wallet a payment goes to. The
+// driver keeps cash, anything else is collected by the platform.
+func paymentCollector(intent db.PaymentIntent) string {
+	if intent.Provider == payments.ProviderCash {
+		return db.AccountOwnerDriver
+	}
+	return db.AccountOwnerPlatform
+}
+
+// capturePayment takes amount of an authorized payment. A capture the
+// provider refuses fails the payment for good.
+func (server *Server) capturePayment(ctx context.Context, provider payments.Provider, intent db.PaymentIntent, amount int64) (db.PaymentIntent, error) {
+	captureErr := provider.Capture(ctx, intent.ProviderRef.String, amount)
+	if captureErr != nil {
+		updated, err := server.store.UpdatePaymentIntentStatus(ctx, db.UpdatePaymentIntentStatusParams{
+			Status:        db.PaymentStatusFailed,
+			FailureReason: sql.NullString{String: captureErr.Error(), Valid: true},
+			ID:            intent.ID,
+			CurrentStatus: db.PaymentStatusAuthorized,
+		})
+		if err != nil {
+			return intent, err
+		}
+		return updated, captureErr
+	}
+
+	result, err := server.store.CapturePaymentTx(ctx, db.CapturePaymentTxParams{
+		PaymentIntentID: intent.ID,
+		Amount:          amount,
+		CollectedBy:     paymentCollector(intent),
+	})
+	if err != nil {
+		return intent, err
+	}
+	return result.PaymentIntent, nil
+}
+
+// captureTripPayment captures the final fare of a completed trip that was
+// paid up front. The trip is already completed when it runs, so failures
+// are logged and left on the payment intent rather than reported. A fare
+// above what was authorized is captured up to the authorization, and the
+// rest stays owed on the passenger's wallet.
+func (server *Server) captureTripPayment(ctx context.Context, trip db.Trip) {
+	intent, err := server.store.GetTripPaymentIntent(ctx, sql.NullInt64{Int64: trip.ID, Valid: true})
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Println("Cannot load payment of trip", trip.BookingID, ":", err)
+		}
+		return
+	}
+
+	if intent.Status != db.PaymentStatusAuthorized {
+		return
+	}
+
+	provider, err := server.payments.Get(intent.Provider)
+	if err != nil {
+		log.Println("Cannot capture payment of trip", trip.BookingID, ":", err)
+		return
+	}
+
+	// the fare may have changed since it was authorized, but never take more
+	amount := intent.Amount
+	if trip.Fare.Valid && trip.Fare.Int64 > 0 && trip.Fare.Int64 < amount {
+		amount = trip.Fare.Int64
+	}
+
+	if _, err := server.capturePayment(ctx, provider, intent, amount); err != nil {
+		log.Println("Cannot capture payment of trip", trip.BookingID, ":", err)
+		return
+	}
+
+	if trip.Fare.Int64 > amount {
+		log.Println("Payment of trip", trip.BookingID, "is", trip.Fare.Int64-amount, "short of the fare, left owed on the passenger's wallet")
+	}
+}
+
+// releaseTripPayment settles the payment of a trip that was cancelled or
+// expired. The cancellation fee, when one was charged, is captured from the
+// hold and the rest of it is released; otherwise the whole hold is voided.
+// Failures are logged and leave the payment authorized.
+func (server *Server) releaseTripPayment(ctx context.Context, trip db.Trip) {
+	intent, err := server.store.GetTripPaymentIntent(ctx, sql.NullInt64{Int64: trip.ID, Valid: true})
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Println("Cannot load payment of trip", trip.BookingID, ":", err)
+		}
+		return
+	}
+
+	if intent.Status != db.PaymentStatusAuthorized {
+		return
+	}
+
+	provider, err := server.payments.Get(intent.Provider)
+	if err != nil {
+		log.Println("Cannot release payment of trip", trip.BookingID, ":", err)
+		return
+	}
+
+	if trip.CancellationFee.Valid && trip.CancellationFee.Int64 > 0 {
+		// capturing part of a hold releases the rest of it
+		amount := min(trip.CancellationFee.Int64, intent.Amount)
+		if _, err := server.capturePayment(ctx, provider, intent, amount); err != nil {
+			log.Println("Cannot capture cancellation fee of trip", trip.BookingID, ":", err)
+		}
+		return
+	}
+
+	if err := provider.Void(ctx, intent.ProviderRef.String); err != nil {
+		log.Println("Cannot release payment of trip", trip.BookingID, ":", err)
+		return
+	}
+
+	_, err = server.store.UpdatePaymentIntentStatus(ctx, db.UpdatePaymentIntentStatusParams{
+		Status:        db.PaymentStatusVoided,
+		ID:            intent.ID,
+		CurrentStatus: db.PaymentStatusAuthorized,
+	})
+	if err != nil && err != sql.ErrNoRows {
+		log.Println("Cannot release payment of trip", trip.BookingID, ":", err)
+	}
+}
+
+// refundTripPayment lets the platform pay part or all of the fare of a
+// completed trip back. It is an admin route, since card refunds come out of
+// the platform's money. Card payments are refunded through their provider first,
+// after the refund is reserved on the payment so it cannot get lost; either
+// way the refund is posted to the ledger.
+func (server *Server) refundTripPayment(ctx *gin.Context) {
+	var req RefundTripPaymentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	trip, err := server.store.GetTripByBookingID(ctx, req.BookingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Trip not found"}))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	if trip.TripStatus != db.TripStatusCompleted {
+		ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+			Status:  false,
+			Message: db.ErrTripNotCompleted.Error()}))
+		return
+	}
+
+	intent, err := server.store.GetTripPaymentIntent(ctx, sql.NullInt64{Int64: trip.ID, Valid: true})
+	if err != nil && err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	var entry db.JournalEntry
+	if err == nil && intent.Status == db.PaymentStatusCaptured {
+		provider, err := server.payments.Get(intent.Provider)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+				Status:  false,
+				Message: err.Error()}))
+			return
+		}
+
+		intent, err = server.store.ReservePaymentRefund(ctx, db.ReservePaymentRefundParams{
+			Amount: req.Amount,
+			ID:     intent.ID,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				err = db.ErrRefundExceedsPayment
+			}
+			server.refundError(ctx, err)
+			return
+		}
+
+		if err := provider.Refund(ctx, intent.ProviderRef.String, req.Amount); err != nil {
+			releaseErr := server.store.ReleasePaymentRefund(ctx, db.ReleasePaymentRefundParams{
+				Amount: req.Amount,
+				ID:     intent.ID,
+			})
+			if releaseErr != nil {
+				log.Println("Cannot release refund of trip", trip.BookingID, ":", releaseErr)
+			}
+
+			ctx.JSON(http.StatusBadGateway, finalResponse(FinalResponse{
+				Status:  false,
+				Message: err.Error()}))
+			return
+		}
+
+		var result db.RefundPaymentTxResult
+		result, err = server.store.RefundPaymentTx(ctx, db.RefundPaymentTxParams{
+			PaymentIntentID: intent.ID,
+			Amount:          req.Amount,
+			Reserved:        true,
+			Reason:          req.Reason,
+			CollectedBy:     paymentCollector(intent),
+		})
+		entry = result.Entry
+		if err != nil {
+			// the provider has paid the money back and the refund stays
+			// pending on the payment until its webhook records it
+			log.Println("Cannot record refund of trip", trip.BookingID, ":", err)
+			ctx.JSON(http.StatusAccepted, finalResponse(FinalResponse{
+				Status:  true,
+				Message: "Refund issued, it is recorded once the payment provider confirms it"}))
+			return
+		}
+
+		// the provider's webhook got to record the refund first
+		if result.Entry.ID == 0 {
+			ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+				Status:  true,
+				Message: "Refund issued successfully"}))
+			return
+		}
+	} else {
+		// paid in cash, or nothing was captured: credit the passenger's wallet
+		var result db.RefundTripTxResult
+		result, err = server.store.RefundTripTx(ctx, db.RefundTripTxParams{
+			BookingID: trip.BookingID,
+			Amount:    req.Amount,
+			Reason:    req.Reason,
+		})
+		entry = result.Entry
+		if err != nil {
+			server.refundError(ctx, err)
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Refund issued successfully",
+		Data: WalletPostingResponse{
+			ID:          entry.ID,
+			Kind:        entry.Kind,
+			BookingID:   &trip.BookingID,
+			Description: entry.Description,
+			Amount:      entry.Amount,
+			CreatedAt:   entry.CreatedAt,
+		},
+	}))
+}
+
+func (server *Server) refundError(ctx *gin.Context, err error) {
+	if errors.Is(err, db.ErrRefundExceedsFare) || errors.Is(err, db.ErrRefundExceedsPayment) || errors.Is(err, db.ErrTripNotCompleted) {
+		ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+		Status:  false,
+		Message: err.Error()}))
+}
+
+// paymentWebhook applies changes a provider reports about a charge. Events
+// carry running totals, so a provider retrying an event changes nothing.
+func (server *Server) paymentWebhook(ctx *gin.Context) {
+	provider, err := server.payments.Get(ctx.Param("provider"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	payload, err := ctx.GetRawData()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	event, err := provider.VerifyWebhook(payload, ctx.GetHeader(paymentSignatureHeader))
+	if err != nil {
+		if errors.Is(err, payments.ErrInvalidSignature) {
+			ctx.JSON(http.StatusUnauthorized, finalResponse(FinalResponse{
+				Status:  false,
+				Message: err.Error()}))
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	intent, err := server.store.GetPaymentIntentByProviderRef(ctx, db.GetPaymentIntentByProviderRefParams{
+		Provider:    provider.Name(),
+		ProviderRef: sql.NullString{String: event.ProviderRef, Valid: true},
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Payment not found"}))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	switch event.Type {
+	case payments.EventCaptured, payments.EventFailed:
+		if intent.Status != db.PaymentStatusAuthorized {
+			break
+		}
+
+		if event.Type == payments.EventCaptured {
+			_, err = server.store.CapturePaymentTx(ctx, db.CapturePaymentTxParams{
+				PaymentIntentID: intent.ID,
+				Amount:          event.Amount,
+				CollectedBy:     paymentCollector(intent),
+			})
+		} else {
+			_, err = server.store.UpdatePaymentIntentStatus(ctx, db.UpdatePaymentIntentStatusParams{
+				Status:        db.PaymentStatusFailed,
+				FailureReason: sql.NullString{String: event.FailureReason, Valid: event.FailureReason != ""},
+				ID:            intent.ID,
+				CurrentStatus: db.PaymentStatusAuthorized,
+			})
+		}
+
+		// another request moving the payment on first is not an error
+		if err == sql.ErrNoRows {
+			err = nil
+		}
+	case payments.EventRefunded:
+		// the store records what the total adds under the payment's lock, so
+		// a refund recorded meanwhile by refundTripPayment is not paid twice
+		_, err = server.store.RefundPaymentTx(ctx, db.RefundPaymentTxParams{
+			PaymentIntentID: intent.ID,
+			RefundedTotal:   event.Amount,
+			Reason:          "Refunded by the payment provider",
+			CollectedBy:     paymentCollector(intent),
+		})
+	}
+	if err != nil {
+		server.refundError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Webhook processed successfully",
+	}))
+}
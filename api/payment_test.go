@@ -0,0 +1,1391 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	mockdb "github.com/emonoid/toribook.git/db/mock"
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/payments"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/emonoid/toribook.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGateway returns the fake card gateway the test server charges cards with.
+func fakeGateway(t *testing.T, server *Server) *payments.FakeGateway {
+	provider, err := server.payments.Get(payments.ProviderFakeCard)
+	require.NoError(t, err)
+	return provider.(*payments.FakeGateway)
+}
+
+// cardCharge authorizes amount on the fake gateway and captures captured of
+// it, returning the charge reference.
+func cardCharge(t *testing.T, gateway *payments.FakeGateway, amount, captured int64) string {
+	auth, err := gateway.Authorize(context.Background(), payments.AuthorizeRequest{
+		Amount:        amount,
+		PaymentMethod: payments.FakeCardOK,
+	})
+	require.NoError(t, err)
+
+	if captured > 0 {
+		require.NoError(t, gateway.Capture(context.Background(), auth.ProviderRef, captured))
+	}
+	return auth.ProviderRef
+}
+
+func TestNewServerPaymentProviders(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+
+	config := utils.Config{
+		TokenSymmetricKey:    utils.RandomString(32),
+		RedisAddress:         redisServer.Addr(),
+		PaymentWebhookSecret: utils.RandomString(32),
+	}
+
+	server, err := NewServer(config, nil)
+	require.NoError(t, err)
+
+	_, err = server.payments.Get(payments.ProviderCash)
+	require.NoError(t, err)
+	// the fake gateway takes any test card, so it is off unless asked for
+	_, err = server.payments.Get(payments.ProviderFakeCard)
+	require.ErrorIs(t, err, payments.ErrUnknownProvider)
+
+	config.PaymentFakeGateway = true
+	server, err = NewServer(config, nil)
+	require.NoError(t, err)
+
+	_, err = server.payments.Get(payments.ProviderFakeCard)
+	require.NoError(t, err)
+
+	config.PaymentWebhookSecret = ""
+	server, err = NewServer(config, nil)
+	require.Error(t, err)
+	require.Nil(t, server)
+}
+
+func tripPaymentIntent(trip db.Trip, provider, status string) db.PaymentIntent {
+	return db.PaymentIntent{
+		ID:          trip.ID + 100,
+		Provider:    provider,
+		ProviderRef: sql.NullString{String: "ch_" + trip.BookingID, Valid: true},
+		TripID:      sql.NullInt64{Int64: trip.ID, Valid: true},
+		PayerType:   db.AccountOwnerPassenger,
+		PayerID:     trip.PassengerID.Int64,
+		Amount:      trip.Fare.Int64,
+		Currency:    "BDT",
+		Status:      status,
+		CreatedAt:   time.Now(),
+	}
+}
+
+func TestPayTripAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+
+	trip := randomTrip(passenger.ID, db.TripStatusAccepted)
+	trip.Fare = sql.NullInt64{Int64: 350, Valid: true}
+	tripID := sql.NullInt64{Int64: trip.ID, Valid: true}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		userID        int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Card",
+			body: gin.H{
+				"booking_id":     trip.BookingID,
+				"provider":       payments.ProviderFakeCard,
+				"payment_method": payments.FakeCardOK,
+			},
+			userID: passenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(db.PaymentIntent{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreatePaymentIntent(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreatePaymentIntentParams) (db.PaymentIntent, error) {
+						require.Equal(t, payments.ProviderFakeCard, arg.Provider)
+						require.True(t, arg.ProviderRef.Valid)
+						require.Equal(t, tripID, arg.TripID)
+						require.False(t, arg.SubscriptionID.Valid)
+						require.Equal(t, db.AccountOwnerPassenger, arg.PayerType)
+						require.Equal(t, passenger.ID, arg.PayerID)
+						require.Equal(t, int64(350), arg.Amount)
+						require.Equal(t, db.PaymentStatusAuthorized, arg.Status)
+
+						intent := tripPaymentIntent(trip, arg.Provider, arg.Status)
+						intent.ProviderRef = arg.ProviderRef
+						return intent, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got PaymentIntentResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.Equal(t, payments.ProviderFakeCard, got.Provider)
+				require.Equal(t, db.PaymentStatusAuthorized, got.Status)
+				require.Equal(t, int64(350), got.Amount)
+			},
+		},
+		{
+			name: "Cash",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"provider":   payments.ProviderCash,
+			},
+			userID: passenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(db.PaymentIntent{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreatePaymentIntent(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreatePaymentIntentParams) (db.PaymentIntent, error) {
+						require.Equal(t, payments.ProviderCash, arg.Provider)
+						require.Equal(t, db.PaymentStatusAuthorized, arg.Status)
+						return tripPaymentIntent(trip, arg.Provider, arg.Status), nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Declined",
+			body: gin.H{
+				"booking_id":     trip.BookingID,
+				"provider":       payments.ProviderFakeCard,
+				"payment_method": payments.FakeCardDeclined,
+			},
+			userID: passenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(db.PaymentIntent{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreatePaymentIntent(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreatePaymentIntentParams) (db.PaymentIntent, error) {
+						require.Equal(t, db.PaymentStatusFailed, arg.Status)
+						require.False(t, arg.ProviderRef.Valid)
+						require.Equal(t, payments.ErrDeclined.Error(), arg.FailureReason.String)
+
+						intent := tripPaymentIntent(trip, arg.Provider, arg.Status)
+						intent.ProviderRef = arg.ProviderRef
+						intent.FailureReason = arg.FailureReason
+						return intent, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPaymentRequired, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyPaid",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"provider":   payments.ProviderCash,
+			},
+			userID: passenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(tripPaymentIntent(trip, payments.ProviderFakeCard, db.PaymentStatusAuthorized), nil)
+				store.EXPECT().
+					CreatePaymentIntent(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "NoFareYet",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"provider":   payments.ProviderCash,
+			},
+			userID: passenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				requested := trip
+				requested.TripStatus = db.TripStatusRequested
+				requested.Fare = sql.NullInt64{}
+
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(requested, nil)
+				store.EXPECT().
+					CreatePaymentIntent(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "CompletedTrip",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"provider":   payments.ProviderCash,
+			},
+			userID: passenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				completed := trip
+				completed.TripStatus = db.TripStatusCompleted
+
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(completed, nil)
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "OtherPassengersTrip",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"provider":   payments.ProviderCash,
+			},
+			userID: passenger.ID + 1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownProvider",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"provider":   "paypal",
+			},
+			userID: passenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "TripNotFound",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"provider":   payments.ProviderCash,
+			},
+			userID: passenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(db.Trip{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/trip/pay", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, tc.userID, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestPaySubscriptionAPI(t *testing.T) {
+	driver, _ := randomDriver(t)
+
+	subscription := db.Subscription{
+		ID:                   3,
+		SubscriptionPackage:  "Monthly",
+		SubscriptionAmount:   "499.00",
+		SubscriptionValidity: 30,
+		Status:               true,
+	}
+	subscriptionID := sql.NullInt64{Int64: subscription.ID, Valid: true}
+
+	authorized := db.PaymentIntent{
+		ID:             9,
+		Provider:       payments.ProviderFakeCard,
+		ProviderRef:    sql.NullString{String: "ch_sub", Valid: true},
+		SubscriptionID: subscriptionID,
+		PayerType:      db.AccountOwnerDriver,
+		PayerID:        driver.ID,
+		Amount:         499,
+		Currency:       "BDT",
+		Status:         db.PaymentStatusAuthorized,
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"subscription_id": subscription.ID,
+				"provider":        payments.ProviderFakeCard,
+				"payment_method":  payments.FakeCardOK,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSubscription(gomock.Any(), gomock.Eq(subscription.ID)).
+					Times(1).
+					Return(subscription, nil)
+
+				var ref sql.NullString
+				store.EXPECT().
+					CreatePaymentIntent(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreatePaymentIntentParams) (db.PaymentIntent, error) {
+						require.Equal(t, subscriptionID, arg.SubscriptionID)
+						require.False(t, arg.TripID.Valid)
+						require.Equal(t, db.AccountOwnerDriver, arg.PayerType)
+						require.Equal(t, driver.ID, arg.PayerID)
+						require.Equal(t, int64(499), arg.Amount)
+						require.Equal(t, db.PaymentStatusAuthorized, arg.Status)
+
+						ref = arg.ProviderRef
+						intent := authorized
+						intent.ProviderRef = ref
+						return intent, nil
+					})
+				store.EXPECT().
+					CapturePaymentTx(gomock.Any(), gomock.Eq(db.CapturePaymentTxParams{
+						PaymentIntentID: authorized.ID,
+						Amount:          499,
+						CollectedBy:     db.AccountOwnerPlatform,
+					})).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CapturePaymentTxParams) (db.CapturePaymentTxResult, error) {
+						intent := authorized
+						intent.ProviderRef = ref
+						intent.Status = db.PaymentStatusCaptured
+						intent.CapturedAmount = arg.Amount
+						return db.CapturePaymentTxResult{PaymentIntent: intent}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got PaymentIntentResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.Equal(t, db.PaymentStatusCaptured, got.Status)
+				require.Equal(t, int64(499), got.CapturedAmount)
+			},
+		},
+		{
+			name: "Declined",
+			body: gin.H{
+				"subscription_id": subscription.ID,
+				"provider":        payments.ProviderFakeCard,
+				"payment_method":  payments.FakeCardDeclined,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSubscription(gomock.Any(), gomock.Eq(subscription.ID)).
+					Times(1).
+					Return(subscription, nil)
+				store.EXPECT().
+					CreatePaymentIntent(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreatePaymentIntentParams) (db.PaymentIntent, error) {
+						require.Equal(t, db.PaymentStatusFailed, arg.Status)
+
+						intent := authorized
+						intent.Status = arg.Status
+						return intent, nil
+					})
+				store.EXPECT().
+					UpdatePaymentIntentStatus(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPaymentRequired, recorder.Code)
+			},
+		},
+		{
+			name: "InactiveSubscription",
+			body: gin.H{
+				"subscription_id": subscription.ID,
+				"provider":        payments.ProviderCash,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				inactive := subscription
+				inactive.Status = false
+
+				store.EXPECT().
+					GetSubscription(gomock.Any(), gomock.Eq(subscription.ID)).
+					Times(1).
+					Return(inactive, nil)
+				store.EXPECT().
+					CreatePaymentIntent(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "SubscriptionNotFound",
+			body: gin.H{
+				"subscription_id": subscription.ID,
+				"provider":        payments.ProviderCash,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSubscription(gomock.Any(), gomock.Eq(subscription.ID)).
+					Times(1).
+					Return(db.Subscription{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/subscription/pay", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestSubscriptionAmount(t *testing.T) {
+	testCases := []struct {
+		amount string
+		want   int64
+		ok     bool
+	}{
+		{amount: "499", want: 499, ok: true},
+		{amount: " 499.50 ", want: 500, ok: true},
+		{amount: "0", ok: false},
+		{amount: "free", ok: false},
+	}
+
+	for _, tc := range testCases {
+		got, err := subscriptionAmount(db.Subscription{SubscriptionAmount: tc.amount})
+		if !tc.ok {
+			require.Error(t, err, tc.amount)
+			continue
+		}
+		require.NoError(t, err, tc.amount)
+		require.Equal(t, tc.want, got)
+	}
+}
+
+func TestReleaseTripPayment(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	driver, _ := randomDriver(t)
+
+	trip := randomTrip(passenger.ID, db.TripStatusCancelled)
+	trip.DriverID = sql.NullInt64{Int64: driver.ID, Valid: true}
+	trip.Fare = sql.NullInt64{Int64: 350, Valid: true}
+	tripID := sql.NullInt64{Int64: trip.ID, Valid: true}
+
+	testCases := []struct {
+		name       string
+		fee        sql.NullInt64
+		buildStubs func(store *mockdb.MockStore, gateway *payments.FakeGateway)
+	}{
+		{
+			name: "VoidWithoutFee",
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				intent := tripPaymentIntent(trip, payments.ProviderFakeCard, db.PaymentStatusAuthorized)
+				intent.ProviderRef.String = cardCharge(t, gateway, 350, 0)
+
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(intent, nil)
+				store.EXPECT().
+					UpdatePaymentIntentStatus(gomock.Any(), gomock.Eq(db.UpdatePaymentIntentStatusParams{
+						Status:        db.PaymentStatusVoided,
+						ID:            intent.ID,
+						CurrentStatus: db.PaymentStatusAuthorized,
+					})).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.UpdatePaymentIntentStatusParams) (db.PaymentIntent, error) {
+						// the gateway no longer holds the money
+						err := gateway.Capture(context.Background(), intent.ProviderRef.String, 350)
+						require.ErrorIs(t, err, payments.ErrInvalidAmount)
+						return intent, nil
+					})
+			},
+		},
+		{
+			name: "CaptureFee",
+			fee:  sql.NullInt64{Int64: 50, Valid: true},
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				intent := tripPaymentIntent(trip, payments.ProviderFakeCard, db.PaymentStatusAuthorized)
+				intent.ProviderRef.String = cardCharge(t, gateway, 350, 0)
+
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(intent, nil)
+				store.EXPECT().
+					CapturePaymentTx(gomock.Any(), gomock.Eq(db.CapturePaymentTxParams{
+						PaymentIntentID: intent.ID,
+						Amount:          50,
+						CollectedBy:     db.AccountOwnerPlatform,
+					})).
+					Times(1).
+					Return(db.CapturePaymentTxResult{PaymentIntent: intent}, nil)
+				store.EXPECT().
+					UpdatePaymentIntentStatus(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+		},
+		{
+			name: "VoidRefused",
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				// the gateway does not know this charge
+				intent := tripPaymentIntent(trip, payments.ProviderFakeCard, db.PaymentStatusAuthorized)
+
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(intent, nil)
+				store.EXPECT().
+					UpdatePaymentIntentStatus(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+		},
+		{
+			name: "NotAuthorized",
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(tripPaymentIntent(trip, payments.ProviderFakeCard, db.PaymentStatusFailed), nil)
+				store.EXPECT().
+					UpdatePaymentIntentStatus(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store, fakeGateway(t, server))
+
+			trip := trip
+			trip.CancellationFee = tc.fee
+			server.releaseTripPayment(context.Background(), trip)
+		})
+	}
+}
+
+func TestRefundTripPaymentAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	driver, _ := randomDriver(t)
+
+	trip := randomTrip(passenger.ID, db.TripStatusCompleted)
+	trip.DriverID = sql.NullInt64{Int64: driver.ID, Valid: true}
+	trip.Fare = sql.NullInt64{Int64: 350, Valid: true}
+	tripID := sql.NullInt64{Int64: trip.ID, Valid: true}
+
+	entry := db.JournalEntry{
+		ID:          21,
+		Kind:        db.EntryKindRefund,
+		TripID:      tripID,
+		Amount:      100,
+		Description: "Refund for trip " + trip.BookingID + ": Overcharged",
+		CreatedAt:   time.Now(),
+	}
+
+	adminAuth := func(t *testing.T, request *http.Request, server *Server) {
+		request.Header.Set(adminKeyHeaderKey, server.config.AdminAPIKey)
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mockdb.MockStore, gateway *payments.FakeGateway)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "Card",
+			body:      gin.H{"booking_id": trip.BookingID, "amount": 100, "reason": "Overcharged"},
+			setupAuth: adminAuth,
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				intent := tripPaymentIntent(trip, payments.ProviderFakeCard, db.PaymentStatusCaptured)
+				intent.ProviderRef.String = cardCharge(t, gateway, 350, 350)
+				intent.CapturedAmount = 350
+
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(intent, nil)
+				reserved := intent
+				reserved.RefundPendingAmount = 100
+				store.EXPECT().
+					ReservePaymentRefund(gomock.Any(), gomock.Eq(db.ReservePaymentRefundParams{
+						Amount: 100,
+						ID:     intent.ID,
+					})).
+					Times(1).
+					Return(reserved, nil)
+				store.EXPECT().
+					RefundPaymentTx(gomock.Any(), gomock.Eq(db.RefundPaymentTxParams{
+						PaymentIntentID: intent.ID,
+						Amount:          100,
+						Reserved:        true,
+						Reason:          "Overcharged",
+						CollectedBy:     db.AccountOwnerPlatform,
+					})).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.RefundPaymentTxParams) (db.RefundPaymentTxResult, error) {
+						// the gateway has paid the money back by now
+						err := gateway.Refund(context.Background(), intent.ProviderRef.String, 251)
+						require.ErrorIs(t, err, payments.ErrInvalidAmount)
+
+						return db.RefundPaymentTxResult{PaymentIntent: intent, Entry: entry}, nil
+					})
+				store.EXPECT().
+					RefundTripTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got WalletPostingResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.Equal(t, db.EntryKindRefund, got.Kind)
+				require.Equal(t, int64(100), got.Amount)
+				require.Equal(t, trip.BookingID, *got.BookingID)
+			},
+		},
+		{
+			name:      "Cash",
+			body:      gin.H{"booking_id": trip.BookingID, "amount": 100, "reason": "Overcharged"},
+			setupAuth: adminAuth,
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(db.PaymentIntent{}, sql.ErrNoRows)
+				store.EXPECT().
+					RefundTripTx(gomock.Any(), gomock.Eq(db.RefundTripTxParams{
+						BookingID: trip.BookingID,
+						Amount:    100,
+						Reason:    "Overcharged",
+					})).
+					Times(1).
+					Return(db.RefundTripTxResult{Entry: entry}, nil)
+				store.EXPECT().
+					RefundPaymentTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "ExceedsPayment",
+			body:      gin.H{"booking_id": trip.BookingID, "amount": 100},
+			setupAuth: adminAuth,
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				intent := tripPaymentIntent(trip, payments.ProviderFakeCard, db.PaymentStatusCaptured)
+				intent.ProviderRef.String = cardCharge(t, gateway, 350, 350)
+				intent.CapturedAmount = 350
+				intent.RefundedAmount = 300
+
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(intent, nil)
+				store.EXPECT().
+					ReservePaymentRefund(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PaymentIntent{}, sql.ErrNoRows)
+				store.EXPECT().
+					RefundPaymentTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:      "ExceedsFare",
+			body:      gin.H{"booking_id": trip.BookingID, "amount": 400},
+			setupAuth: adminAuth,
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(db.PaymentIntent{}, sql.ErrNoRows)
+				store.EXPECT().
+					RefundTripTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RefundTripTxResult{}, db.ErrRefundExceedsFare)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:      "ProviderRefuses",
+			body:      gin.H{"booking_id": trip.BookingID, "amount": 100},
+			setupAuth: adminAuth,
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				// the gateway does not know this charge
+				intent := tripPaymentIntent(trip, payments.ProviderFakeCard, db.PaymentStatusCaptured)
+				intent.CapturedAmount = 350
+
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(intent, nil)
+				store.EXPECT().
+					ReservePaymentRefund(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(intent, nil)
+				// nothing was refunded, so the reservation is given back
+				store.EXPECT().
+					ReleasePaymentRefund(gomock.Any(), gomock.Eq(db.ReleasePaymentRefundParams{
+						Amount: 100,
+						ID:     intent.ID,
+					})).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					RefundPaymentTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadGateway, recorder.Code)
+			},
+		},
+		{
+			name:      "RecordingFails",
+			body:      gin.H{"booking_id": trip.BookingID, "amount": 100},
+			setupAuth: adminAuth,
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				intent := tripPaymentIntent(trip, payments.ProviderFakeCard, db.PaymentStatusCaptured)
+				intent.ProviderRef.String = cardCharge(t, gateway, 350, 350)
+				intent.CapturedAmount = 350
+
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(intent, nil)
+				store.EXPECT().
+					ReservePaymentRefund(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(intent, nil)
+				store.EXPECT().
+					RefundPaymentTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RefundPaymentTxResult{}, sql.ErrConnDone)
+				// the refund stays pending for the webhook, not given back
+				store.EXPECT().
+					ReleasePaymentRefund(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+			},
+		},
+		{
+			name:      "RecordedByWebhook",
+			body:      gin.H{"booking_id": trip.BookingID, "amount": 100},
+			setupAuth: adminAuth,
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				intent := tripPaymentIntent(trip, payments.ProviderFakeCard, db.PaymentStatusCaptured)
+				intent.ProviderRef.String = cardCharge(t, gateway, 350, 350)
+				intent.CapturedAmount = 350
+
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(intent, nil)
+				store.EXPECT().
+					ReservePaymentRefund(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(intent, nil)
+				store.EXPECT().
+					RefundPaymentTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RefundPaymentTxResult{PaymentIntent: intent}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "TripNotCompleted",
+			body:      gin.H{"booking_id": trip.BookingID, "amount": 100},
+			setupAuth: adminAuth,
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				inProgress := trip
+				inProgress.TripStatus = db.TripStatusInProgress
+
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(inProgress, nil)
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "DriverToken",
+			body: gin.H{"booking_id": trip.BookingID, "amount": 100},
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				// even the trip's own driver cannot refund a card payment
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					ReservePaymentRefund(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "WrongAdminKey",
+			body: gin.H{"booking_id": trip.BookingID, "amount": 100},
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				request.Header.Set(adminKeyHeaderKey, utils.RandomString(32))
+			},
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidAmount",
+			body:      gin.H{"booking_id": trip.BookingID, "amount": 0},
+			setupAuth: adminAuth,
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store, fakeGateway(t, server))
+
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/admin/trip/refund", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestPaymentWebhookAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+
+	trip := randomTrip(passenger.ID, db.TripStatusCompleted)
+	trip.Fare = sql.NullInt64{Int64: 350, Valid: true}
+
+	authorized := tripPaymentIntent(trip, payments.ProviderFakeCard, db.PaymentStatusAuthorized)
+	captured := authorized
+	captured.Status = db.PaymentStatusCaptured
+	captured.CapturedAmount = 350
+	captured.RefundedAmount = 50
+
+	byRef := db.GetPaymentIntentByProviderRefParams{
+		Provider:    payments.ProviderFakeCard,
+		ProviderRef: authorized.ProviderRef,
+	}
+
+	testCases := []struct {
+		name          string
+		provider      string
+		event         payments.Event
+		tamper        bool
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Captured",
+			provider: payments.ProviderFakeCard,
+			event:    payments.Event{Type: payments.EventCaptured, ProviderRef: authorized.ProviderRef.String, Amount: 350},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPaymentIntentByProviderRef(gomock.Any(), gomock.Eq(byRef)).
+					Times(1).
+					Return(authorized, nil)
+				store.EXPECT().
+					CapturePaymentTx(gomock.Any(), gomock.Eq(db.CapturePaymentTxParams{
+						PaymentIntentID: authorized.ID,
+						Amount:          350,
+						CollectedBy:     db.AccountOwnerPlatform,
+					})).
+					Times(1).
+					Return(db.CapturePaymentTxResult{PaymentIntent: captured}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Failed",
+			provider: payments.ProviderFakeCard,
+			event:    payments.Event{Type: payments.EventFailed, ProviderRef: authorized.ProviderRef.String, FailureReason: "card expired"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPaymentIntentByProviderRef(gomock.Any(), gomock.Eq(byRef)).
+					Times(1).
+					Return(authorized, nil)
+				store.EXPECT().
+					UpdatePaymentIntentStatus(gomock.Any(), gomock.Eq(db.UpdatePaymentIntentStatusParams{
+						Status:        db.PaymentStatusFailed,
+						FailureReason: sql.NullString{String: "card expired", Valid: true},
+						ID:            authorized.ID,
+						CurrentStatus: db.PaymentStatusAuthorized,
+					})).
+					Times(1).
+					Return(db.PaymentIntent{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Refunded",
+			provider: payments.ProviderFakeCard,
+			event:    payments.Event{Type: payments.EventRefunded, ProviderRef: authorized.ProviderRef.String, Amount: 150},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPaymentIntentByProviderRef(gomock.Any(), gomock.Eq(byRef)).
+					Times(1).
+					Return(captured, nil)
+				store.EXPECT().
+					RefundPaymentTx(gomock.Any(), gomock.Eq(db.RefundPaymentTxParams{
+						PaymentIntentID: captured.ID,
+						RefundedTotal:   150,
+						Reason:          "Refunded by the payment provider",
+						CollectedBy:     db.AccountOwnerPlatform,
+					})).
+					Times(1).
+					Return(db.RefundPaymentTxResult{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "RefundReplayed",
+			provider: payments.ProviderFakeCard,
+			event:    payments.Event{Type: payments.EventRefunded, ProviderRef: authorized.ProviderRef.String, Amount: 50},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPaymentIntentByProviderRef(gomock.Any(), gomock.Eq(byRef)).
+					Times(1).
+					Return(captured, nil)
+				// a total already recorded is a no-op inside the transaction
+				store.EXPECT().
+					RefundPaymentTx(gomock.Any(), gomock.Eq(db.RefundPaymentTxParams{
+						PaymentIntentID: captured.ID,
+						RefundedTotal:   50,
+						Reason:          "Refunded by the payment provider",
+						CollectedBy:     db.AccountOwnerPlatform,
+					})).
+					Times(1).
+					Return(db.RefundPaymentTxResult{PaymentIntent: captured}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidSignature",
+			provider: payments.ProviderFakeCard,
+			event:    payments.Event{Type: payments.EventCaptured, ProviderRef: authorized.ProviderRef.String, Amount: 350},
+			tamper:   true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPaymentIntentByProviderRef(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "CashHasNoWebhooks",
+			provider: payments.ProviderCash,
+			event:    payments.Event{Type: payments.EventCaptured, ProviderRef: "cash_1", Amount: 350},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPaymentIntentByProviderRef(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "UnknownProvider",
+			provider: "paypal",
+			event:    payments.Event{Type: payments.EventCaptured, ProviderRef: "pp_1", Amount: 350},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPaymentIntentByProviderRef(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "PaymentNotFound",
+			provider: payments.ProviderFakeCard,
+			event:    payments.Event{Type: payments.EventCaptured, ProviderRef: authorized.ProviderRef.String, Amount: 350},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPaymentIntentByProviderRef(gomock.Any(), gomock.Eq(byRef)).
+					Times(1).
+					Return(db.PaymentIntent{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			payload, signature, err := fakeGateway(t, server).Webhook(tc.event)
+			require.NoError(t, err)
+			if tc.tamper {
+				payload = append(payload, ' ')
+			}
+
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/payments/webhook/"+tc.provider, bytes.NewReader(payload))
+			require.NoError(t, err)
+			request.Header.Set(paymentSignatureHeader, signature)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCaptureTripPayment(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+
+	trip := randomTrip(passenger.ID, db.TripStatusCompleted)
+	tripID := sql.NullInt64{Int64: trip.ID, Valid: true}
+
+	testCases := []struct {
+		name       string
+		fare       int64
+		buildStubs func(store *mockdb.MockStore, gateway *payments.FakeGateway)
+	}{
+		{
+			name: "LowerFinalFare",
+			fare: 300,
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				intent := tripPaymentIntent(trip, payments.ProviderFakeCard, db.PaymentStatusAuthorized)
+				intent.Amount = 350
+				intent.ProviderRef.String = cardCharge(t, gateway, 350, 0)
+
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(intent, nil)
+				store.EXPECT().
+					CapturePaymentTx(gomock.Any(), gomock.Eq(db.CapturePaymentTxParams{
+						PaymentIntentID: intent.ID,
+						Amount:          300,
+						CollectedBy:     db.AccountOwnerPlatform,
+					})).
+					Times(1).
+					Return(db.CapturePaymentTxResult{PaymentIntent: intent}, nil)
+			},
+		},
+		{
+			name: "FareAboveAuthorized",
+			fare: 420,
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				intent := tripPaymentIntent(trip, payments.ProviderFakeCard, db.PaymentStatusAuthorized)
+				intent.Amount = 350
+				intent.ProviderRef.String = cardCharge(t, gateway, 350, 0)
+
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(intent, nil)
+				// only the authorization is taken, the other 70 stay owed on
+				// the passenger's wallet
+				store.EXPECT().
+					CapturePaymentTx(gomock.Any(), gomock.Eq(db.CapturePaymentTxParams{
+						PaymentIntentID: intent.ID,
+						Amount:          350,
+						CollectedBy:     db.AccountOwnerPlatform,
+					})).
+					Times(1).
+					Return(db.CapturePaymentTxResult{PaymentIntent: intent}, nil)
+			},
+		},
+		{
+			name: "CashCollectedByDriver",
+			fare: 300,
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				intent := tripPaymentIntent(trip, payments.ProviderCash, db.PaymentStatusAuthorized)
+				intent.Amount = 300
+
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(intent, nil)
+				store.EXPECT().
+					CapturePaymentTx(gomock.Any(), gomock.Eq(db.CapturePaymentTxParams{
+						PaymentIntentID: intent.ID,
+						Amount:          300,
+						CollectedBy:     db.AccountOwnerDriver,
+					})).
+					Times(1).
+					Return(db.CapturePaymentTxResult{PaymentIntent: intent}, nil)
+			},
+		},
+		{
+			name: "CaptureRefused",
+			fare: 300,
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				// the gateway does not know this charge
+				intent := tripPaymentIntent(trip, payments.ProviderFakeCard, db.PaymentStatusAuthorized)
+
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(intent, nil)
+				store.EXPECT().
+					UpdatePaymentIntentStatus(gomock.Any(), gomock.Eq(db.UpdatePaymentIntentStatusParams{
+						Status:        db.PaymentStatusFailed,
+						FailureReason: sql.NullString{String: payments.ErrChargeNotFound.Error(), Valid: true},
+						ID:            intent.ID,
+						CurrentStatus: db.PaymentStatusAuthorized,
+					})).
+					Times(1).
+					Return(intent, nil)
+				store.EXPECT().
+					CapturePaymentTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+		},
+		{
+			name: "AlreadyCaptured",
+			fare: 300,
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(tripPaymentIntent(trip, payments.ProviderCash, db.PaymentStatusCaptured), nil)
+				store.EXPECT().
+					UpdatePaymentIntentStatus(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+		},
+		{
+			name: "NotPaidUpFront",
+			fare: 300,
+			buildStubs: func(store *mockdb.MockStore, gateway *payments.FakeGateway) {
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(tripID)).
+					Times(1).
+					Return(db.PaymentIntent{}, sql.ErrNoRows)
+				store.EXPECT().
+					UpdatePaymentIntentStatus(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(store, fakeGateway(t, server))
+
+			trip := trip
+			trip.Fare = sql.NullInt64{Int64: tc.fare, Valid: true}
+			server.captureTripPayment(context.Background(), trip)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/helpers"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/gin-gonic/gin"
+)
+
+type RateTripRequest struct {
+	BookingID string  `json:"booking_id" binding:"required"`
+	Score     int32   `json:"score" binding:"required,min=1,max=5"`
+	Comment   *string `json:"comment" binding:"omitempty,max=500"`
+}
+
+type TripRatingResponse struct {
+	BookingID string  `json:"booking_id"`
+	RatedBy   string  `json:"rated_by"`
+	Score     int32   `json:"score"`
+	Comment   *string `json:"comment"`
+	// RateeRating is the new overall rating of the driver or passenger rated.
+	RateeRating float64   `json:"ratee_rating"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (server *Server) passengerRateTrip(ctx *gin.Context) {
+	server.rateTrip(ctx, db.RatedByPassenger)
+}
+
+func (server *Server) driverRateTrip(ctx *gin.Context) {
+	server.rateTrip(ctx, db.RatedByDriver)
+}
+
+// rateTrip lets the passenger or driver in the token rate the other side of
+// a completed trip once.
+func (server *Server) rateTrip(ctx *gin.Context, ratedBy string) {
+	var req RateTripRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+
+	result, err := server.store.RateTripTx(ctx, db.RateTripTxParams{
+		BookingID: req.BookingID,
+		RatedBy:   ratedBy,
+		RaterID:   authPayload.UserID,
+		Score:     req.Score,
+		Comment:   helpers.MakeNullString(req.Comment),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows || errors.Is(err, db.ErrTripNotOwned) || errors.Is(err, db.ErrTripNotAssigned) {
+			ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Trip not found"}))
+			return
+		}
+
+		if errors.Is(err, db.ErrTripNotCompleted) || errors.Is(err, db.ErrTripAlreadyRated) {
+			ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+				Status:  false,
+				Message: err.Error()}))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Trip rated successfully",
+		Data: TripRatingResponse{
+			BookingID:   req.BookingID,
+			RatedBy:     result.Rating.RatedBy,
+			Score:       result.Rating.Score,
+			Comment:     helpers.NullStringToPtr(result.Rating.Comment),
+			RateeRating: result.RateeRating,
+			CreatedAt:   result.Rating.CreatedAt,
+		},
+	}))
+}
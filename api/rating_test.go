@@ -0,0 +1,259 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/emonoid/toribook.git/db/mock"
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateTripAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	driver, _ := randomDriver(t)
+
+	trip := randomTrip(passenger.ID, db.TripStatusCompleted)
+	trip.DriverID = sql.NullInt64{Int64: driver.ID, Valid: true}
+
+	comment := "Smooth ride"
+
+	passengerAuth := func(t *testing.T, request *http.Request, server *Server) {
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+	}
+	driverAuth := func(t *testing.T, request *http.Request, server *Server) {
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+	}
+
+	testCases := []struct {
+		name          string
+		url           string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "PassengerRatesDriver",
+			url:  "/api/v1/trip/rate",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"score":      5,
+				"comment":    comment,
+			},
+			setupAuth: passengerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RateTripTx(gomock.Any(), gomock.Eq(db.RateTripTxParams{
+						BookingID: trip.BookingID,
+						RatedBy:   db.RatedByPassenger,
+						RaterID:   passenger.ID,
+						Score:     5,
+						Comment:   sql.NullString{String: comment, Valid: true},
+					})).
+					Times(1).
+					Return(db.RateTripTxResult{
+						Rating: db.TripRating{
+							TripID:      trip.ID,
+							RatedBy:     db.RatedByPassenger,
+							PassengerID: passenger.ID,
+							DriverID:    driver.ID,
+							Score:       5,
+							Comment:     sql.NullString{String: comment, Valid: true},
+							CreatedAt:   time.Now(),
+						},
+						RateeRating: db.SmoothedRating(1, 5),
+					}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got TripRatingResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.Equal(t, trip.BookingID, got.BookingID)
+				require.Equal(t, db.RatedByPassenger, got.RatedBy)
+				require.Equal(t, int32(5), got.Score)
+				require.Equal(t, comment, *got.Comment)
+				require.Equal(t, 4.58, got.RateeRating)
+			},
+		},
+		{
+			name: "DriverRatesPassengerWithoutComment",
+			url:  "/api/v1/trip/driver-rate",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"score":      2,
+			},
+			setupAuth: driverAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RateTripTx(gomock.Any(), gomock.Eq(db.RateTripTxParams{
+						BookingID: trip.BookingID,
+						RatedBy:   db.RatedByDriver,
+						RaterID:   driver.ID,
+						Score:     2,
+					})).
+					Times(1).
+					Return(db.RateTripTxResult{
+						Rating: db.TripRating{
+							TripID:      trip.ID,
+							RatedBy:     db.RatedByDriver,
+							PassengerID: passenger.ID,
+							DriverID:    driver.ID,
+							Score:       2,
+							CreatedAt:   time.Now(),
+						},
+						RateeRating: db.SmoothedRating(1, 2),
+					}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got TripRatingResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.Equal(t, db.RatedByDriver, got.RatedBy)
+				require.Nil(t, got.Comment)
+				require.Equal(t, 4.08, got.RateeRating)
+			},
+		},
+		{
+			name: "ScoreOutOfRange",
+			url:  "/api/v1/trip/rate",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"score":      6,
+			},
+			setupAuth: passengerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RateTripTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotOwnTrip",
+			url:  "/api/v1/trip/rate",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"score":      4,
+			},
+			setupAuth: passengerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RateTripTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RateTripTxResult{}, db.ErrTripNotOwned)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "NotCompleted",
+			url:  "/api/v1/trip/driver-rate",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"score":      4,
+			},
+			setupAuth: driverAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RateTripTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RateTripTxResult{}, db.ErrTripNotCompleted)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyRated",
+			url:  "/api/v1/trip/rate",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"score":      4,
+			},
+			setupAuth: passengerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RateTripTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RateTripTxResult{}, db.ErrTripAlreadyRated)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "DriverOnPassengerEndpoint",
+			url:  "/api/v1/trip/rate",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"score":      4,
+			},
+			setupAuth: driverAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RateTripTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			url:  "/api/v1/trip/rate",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"score":      4,
+			},
+			setupAuth: passengerAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RateTripTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RateTripTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, tc.url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
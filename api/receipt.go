@@ -0,0 +1,138 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/receipt"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/gin-gonic/gin"
+)
+
+func (server *Server) getTripReceipt(ctx *gin.Context) {
+	stored, ok := server.tripReceipt(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Receipt retrieved successfully",
+		Data:    json.RawMessage(stored.Document),
+	}))
+}
+
+func (server *Server) getTripReceiptPDF(ctx *gin.Context) {
+	stored, ok := server.tripReceipt(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", stored.ReceiptNumber+".pdf"))
+	ctx.Data(http.StatusOK, "application/pdf", stored.PDF)
+}
+
+// tripReceipt loads the receipt of a completed trip of the passenger in the
+// token, issuing it on the first download. It writes the error response and
+// returns false when there is no receipt to send.
+func (server *Server) tripReceipt(ctx *gin.Context) (db.TripReceipt, bool) {
+	var req GetTripRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return db.TripReceipt{}, false
+	}
+
+	trip, err := server.store.GetTripByBookingID(ctx, req.BookingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Trip not found"}))
+			return db.TripReceipt{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return db.TripReceipt{}, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+
+	if !trip.PassengerID.Valid || trip.PassengerID.Int64 != authPayload.UserID {
+		ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Trip not found"}))
+		return db.TripReceipt{}, false
+	}
+
+	if trip.TripStatus != db.TripStatusCompleted {
+		ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Receipts are issued once the trip is completed"}))
+		return db.TripReceipt{}, false
+	}
+
+	stored, err := server.store.GetTripReceipt(ctx, trip.ID)
+	if err == sql.ErrNoRows {
+		stored, err = server.issueReceipt(ctx, trip)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return db.TripReceipt{}, false
+	}
+
+	return stored, true
+}
+
+// issueReceipt builds and stores the receipt of a completed trip. When two
+// downloads race, the store keeps the first receipt and returns it to both.
+func (server *Server) issueReceipt(ctx *gin.Context, trip db.Trip) (db.TripReceipt, error) {
+	stops, err := server.store.ListTripStops(ctx, trip.ID)
+	if err != nil {
+		return db.TripReceipt{}, err
+	}
+
+	arg := receipt.BuildParams{
+		Trip:     trip,
+		Stops:    stops,
+		IssuedAt: time.Now().UTC(),
+	}
+
+	// the car and its fare rule may have been removed since the trip
+	if trip.CarType.Valid {
+		rule, err := server.store.GetFareRule(ctx, trip.CarType.String)
+		if err != nil && err != sql.ErrNoRows {
+			return db.TripReceipt{}, err
+		}
+		arg.Rule = db.NewFareRule(rule)
+	}
+
+	if trip.CarID.Valid {
+		arg.Car, err = server.store.GetCar(ctx, trip.CarID.Int64)
+		if err != nil && err != sql.ErrNoRows {
+			return db.TripReceipt{}, err
+		}
+	}
+
+	r := receipt.Build(arg)
+
+	document, err := json.Marshal(r)
+	if err != nil {
+		return db.TripReceipt{}, err
+	}
+
+	return server.store.CreateTripReceipt(ctx, db.CreateTripReceiptParams{
+		TripID:        trip.ID,
+		ReceiptNumber: r.Number,
+		Document:      document,
+		PDF:           receipt.RenderPDF(r),
+	})
+}
@@ -0,0 +1,275 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/emonoid/toribook.git/db/mock"
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/receipt"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTripReceiptAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	otherPassenger, _ := randomPassenger(t)
+	otherPassenger.ID = passenger.ID + 1
+	driver, _ := randomDriver(t)
+
+	trip := randomTrip(passenger.ID, db.TripStatusCompleted)
+	trip.DriverID = sql.NullInt64{Int64: driver.ID, Valid: true}
+	trip.DriverName = sql.NullString{String: driver.FullName, Valid: true}
+	trip.CarID = sql.NullInt64{Int64: driver.CarID, Valid: true}
+	trip.CarType = sql.NullString{String: driver.CarType, Valid: true}
+	trip.Fare = sql.NullInt64{Int64: 350, Valid: true}
+
+	rule := randomFareRule(driver.CarType)
+	car := db.Car{ID: driver.CarID, CarType: driver.CarType, CarModel: "Toyota Axio"}
+
+	issued := receipt.Build(receipt.BuildParams{Trip: trip, Rule: db.NewFareRule(rule), Car: car})
+	document, err := json.Marshal(issued)
+	require.NoError(t, err)
+	stored := db.TripReceipt{
+		ID:            1,
+		TripID:        trip.ID,
+		ReceiptNumber: issued.Number,
+		Document:      document,
+		PDF:           receipt.RenderPDF(issued),
+		CreatedAt:     time.Now(),
+	}
+
+	testCases := []struct {
+		name          string
+		url           string
+		user          db.Passenger
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "FirstDownload",
+			url:  "/api/v1/trip/%s/receipt",
+			user: passenger,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					GetTripReceipt(gomock.Any(), gomock.Eq(trip.ID)).
+					Times(1).
+					Return(db.TripReceipt{}, sql.ErrNoRows)
+				store.EXPECT().
+					ListTripStops(gomock.Any(), gomock.Eq(trip.ID)).
+					Times(1).
+					Return([]db.TripStop{}, nil)
+				store.EXPECT().
+					GetFareRule(gomock.Any(), gomock.Eq(driver.CarType)).
+					Times(1).
+					Return(rule, nil)
+				store.EXPECT().
+					GetCar(gomock.Any(), gomock.Eq(driver.CarID)).
+					Times(1).
+					Return(car, nil)
+				store.EXPECT().
+					CreateTripReceipt(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateTripReceiptParams) (db.TripReceipt, error) {
+						require.Equal(t, trip.ID, arg.TripID)
+						require.Equal(t, receipt.Number(trip.BookingID), arg.ReceiptNumber)
+						require.True(t, bytes.HasPrefix(arg.PDF, []byte("%PDF-")))
+
+						return db.TripReceipt{
+							ID:            1,
+							TripID:        arg.TripID,
+							ReceiptNumber: arg.ReceiptNumber,
+							Document:      arg.Document,
+							PDF:           arg.PDF,
+							CreatedAt:     time.Now(),
+						}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got receipt.Receipt
+				requireBodyData(t, recorder.Body, &got)
+				require.Equal(t, receipt.Number(trip.BookingID), got.Number)
+				require.Equal(t, driver.FullName, got.Driver.Name)
+				require.Equal(t, "Toyota Axio", got.Car.Model)
+				require.Equal(t, int64(350), got.Fare.Total)
+				require.Equal(t, rule.BaseFare, got.Fare.BaseFare)
+			},
+		},
+		{
+			name: "StoredReceipt",
+			url:  "/api/v1/trip/%s/receipt",
+			user: passenger,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					GetTripReceipt(gomock.Any(), gomock.Eq(trip.ID)).
+					Times(1).
+					Return(stored, nil)
+				store.EXPECT().
+					CreateTripReceipt(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp testResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.JSONEq(t, string(document), string(rsp.Data))
+			},
+		},
+		{
+			name: "PDF",
+			url:  "/api/v1/trip/%s/receipt/pdf",
+			user: passenger,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					GetTripReceipt(gomock.Any(), gomock.Eq(trip.ID)).
+					Times(1).
+					Return(stored, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "application/pdf", recorder.Header().Get("Content-Type"))
+				require.Contains(t, recorder.Header().Get("Content-Disposition"), stored.ReceiptNumber+".pdf")
+				require.Equal(t, stored.PDF, recorder.Body.Bytes())
+			},
+		},
+		{
+			name: "TripNotCompleted",
+			url:  "/api/v1/trip/%s/receipt",
+			user: passenger,
+			buildStubs: func(store *mockdb.MockStore) {
+				inProgress := trip
+				inProgress.TripStatus = db.TripStatusInProgress
+
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(inProgress, nil)
+				store.EXPECT().
+					GetTripReceipt(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "OtherPassengersTrip",
+			url:  "/api/v1/trip/%s/receipt/pdf",
+			user: otherPassenger,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					GetTripReceipt(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "TripNotFound",
+			url:  "/api/v1/trip/%s/receipt",
+			user: passenger,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(db.Trip{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			url:  "/api/v1/trip/%s/receipt",
+			user: passenger,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					GetTripReceipt(gomock.Any(), gomock.Eq(trip.ID)).
+					Times(1).
+					Return(db.TripReceipt{}, sql.ErrConnDone)
+				store.EXPECT().
+					CreateTripReceipt(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf(tc.url, trip.BookingID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user.Email, token.RolePassenger, tc.user.ID, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetTripReceiptDriverForbidden(t *testing.T) {
+	driver, _ := randomDriver(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetTripByBookingID(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/trip/TB-ABC234/receipt", nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
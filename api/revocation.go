@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/emonoid/toribook.git/token"
+	"github.com/go-redis/redis/v8"
+)
+
+var ErrRevokedToken = errors.New("token has been revoked")
+
+// revocationList remembers tokens that were logged out before they expired.
+// Entries only live as long as the tokens they reject, since an expired token
+// could no longer be used anyway.
+type revocationList interface {
+	// Revoke rejects the token described by payload until it expires.
+	Revoke(ctx context.Context, payload *token.Payload) error
+	// RevokeAllBefore rejects every token of the user issued before cutoff.
+	// ttl must cover the longest token lifetime so no older token outlives
+	// the entry.
+	RevokeAllBefore(ctx context.Context, role string, userID int64, cutoff time.Time, ttl time.Duration) error
+	// IsRevoked reports whether the token described by payload was revoked.
+	IsRevoked(ctx context.Context, payload *token.Payload) (bool, error)
+}
+
+// redisRevocationList stores revocations in redis with a TTL matching the
+// token lifetime, so they are shared by every server instance.
+type redisRevocationList struct {
+	client *redis.Client
+}
+
+func newRedisRevocationList(client *redis.Client) revocationList {
+	return &redisRevocationList{client: client}
+}
+
+func revokedTokenKey(tokenID string) string {
+	return "revoked_token:" + tokenID
+}
+
+func revokedBeforeKey(role string, userID int64) string {
+	return fmt.Sprintf("revoked_before:%s:%d", role, userID)
+}
+
+// Revoke implements revocationList.
+func (list *redisRevocationList) Revoke(ctx context.Context, payload *token.Payload) error {
+	ttl := time.Until(payload.ExpireAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return list.client.Set(ctx, revokedTokenKey(payload.ID.String()), 1, ttl).Err()
+}
+
+// RevokeAllBefore implements revocationList.
+func (list *redisRevocationList) RevokeAllBefore(ctx context.Context, role string, userID int64, cutoff time.Time, ttl time.Duration) error {
+	return list.client.Set(ctx, revokedBeforeKey(role, userID), cutoff.UnixNano(), ttl).Err()
+}
+
+// IsRevoked implements revocationList.
+func (list *redisRevocationList) IsRevoked(ctx context.Context, payload *token.Payload) (bool, error) {
+	values, err := list.client.MGet(ctx,
+		revokedTokenKey(payload.ID.String()),
+		revokedBeforeKey(payload.Role, payload.UserID),
+	).Result()
+	if err != nil {
+		return false, err
+	}
+
+	if values[0] != nil {
+		return true, nil
+	}
+
+	if cutoff, ok := values[1].(string); ok {
+		cutoffNano, err := strconv.ParseInt(cutoff, 10, 64)
+		if err != nil {
+			return false, err
+		}
+		return payload.IssuedAt.UnixNano() < cutoffNano, nil
+	}
+
+	return false, nil
+}
+
+// revokedBefore is a RevokeAllBefore cutoff kept in memory until it expires.
+type revokedBefore struct {
+	cutoff   time.Time
+	expireAt time.Time
+}
+
+// memoryRevocationList keeps revocations in process memory. It is used when
+// redis is unavailable, so logging out keeps working on a single server
+// instance instead of every authenticated request failing. Expired entries
+// are pruned whenever a new one is added.
+type memoryRevocationList struct {
+	tokens  map[string]time.Time
+	cutoffs map[string]revokedBefore
+	lock    sync.RWMutex
+}
+
+func newMemoryRevocationList() revocationList {
+	return &memoryRevocationList{
+		tokens:  make(map[string]time.Time),
+		cutoffs: make(map[string]revokedBefore),
+	}
+}
+
+// Revoke implements revocationList.
+func (list *memoryRevocationList) Revoke(ctx context.Context, payload *token.Payload) error {
+	if !time.Now().Before(payload.ExpireAt) {
+		return nil
+	}
+
+	list.lock.Lock()
+	defer list.lock.Unlock()
+
+	list.prune()
+	list.tokens[revokedTokenKey(payload.ID.String())] = payload.ExpireAt
+	return nil
+}
+
+// RevokeAllBefore implements revocationList.
+func (list *memoryRevocationList) RevokeAllBefore(ctx context.Context, role string, userID int64, cutoff time.Time, ttl time.Duration) error {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+
+	list.prune()
+	list.cutoffs[revokedBeforeKey(role, userID)] = revokedBefore{
+		cutoff:   cutoff,
+		expireAt: time.Now().Add(ttl),
+	}
+	return nil
+}
+
+// IsRevoked implements revocationList.
+func (list *memoryRevocationList) IsRevoked(ctx context.Context, payload *token.Payload) (bool, error) {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
+
+	now := time.Now()
+
+	if expireAt, ok := list.tokens[revokedTokenKey(payload.ID.String())]; ok && now.Before(expireAt) {
+		return true, nil
+	}
+
+	if entry, ok := list.cutoffs[revokedBeforeKey(payload.Role, payload.UserID)]; ok && now.Before(entry.expireAt) {
+		return payload.IssuedAt.Before(entry.cutoff), nil
+	}
+
+	return false, nil
+}
+
+// prune drops the entries whose tokens have expired. The caller must hold
+// the write lock.
+func (list *memoryRevocationList) prune() {
+	now := time.Now()
+	for key, expireAt := range list.tokens {
+		if !now.Before(expireAt) {
+			delete(list.tokens, key)
+		}
+	}
+	for key, entry := range list.cutoffs {
+		if !now.Before(entry.expireAt) {
+			delete(list.cutoffs, key)
+		}
+	}
+}
+
+// verifyUnrevokedToken checks the token signature, expiry and type, then makes
+// sure it was not revoked.
+func verifyUnrevokedToken(ctx context.Context, tokenMaker token.Maker, revocations revocationList, tokenString string, tokenType string) (*token.Payload, error) {
+	payload, err := tokenMaker.VerifyToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if payload.TokenType != tokenType {
+		return nil, token.ErrInvalidToken
+	}
+
+	revoked, err := revocations.IsRevoked(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrRevokedToken
+	}
+
+	return payload, nil
+}
+
+// verifyToken is used by handlers, such as the websocket ones, that receive
+// the access token outside of the authorization header.
+func (server *Server) verifyToken(ctx context.Context, tokenString string) (*token.Payload, error) {
+	return verifyUnrevokedToken(ctx, server.tokenMaker, server.revocations, tokenString, token.TokenTypeAccess)
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/emonoid/toribook.git/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRevocationLists(t *testing.T) map[string]revocationList {
+	redisServer := miniredis.RunT(t)
+
+	return map[string]revocationList{
+		"Redis":  newRedisRevocationList(utils.NewRedisClient(redisServer.Addr())),
+		"Memory": newMemoryRevocationList(),
+	}
+}
+
+func randomAccessPayload(t *testing.T, userID int64, duration time.Duration) *token.Payload {
+	payload, err := token.NewPayload(utils.RandomEmail(), token.RolePassenger, userID, token.TokenTypeAccess, uuid.Nil, duration)
+	require.NoError(t, err)
+	return payload
+}
+
+func TestRevokeToken(t *testing.T) {
+	for name, revocations := range newTestRevocationLists(t) {
+		t.Run(name, func(t *testing.T) {
+			userID := utils.RandomInt(1, 1000)
+			revoked := randomAccessPayload(t, userID, time.Minute)
+			other := randomAccessPayload(t, userID, time.Minute)
+
+			require.NoError(t, revocations.Revoke(context.Background(), revoked))
+
+			isRevoked, err := revocations.IsRevoked(context.Background(), revoked)
+			require.NoError(t, err)
+			require.True(t, isRevoked)
+
+			isRevoked, err = revocations.IsRevoked(context.Background(), other)
+			require.NoError(t, err)
+			require.False(t, isRevoked)
+		})
+	}
+}
+
+func TestRevokeAllBefore(t *testing.T) {
+	for name, revocations := range newTestRevocationLists(t) {
+		t.Run(name, func(t *testing.T) {
+			userID := utils.RandomInt(1, 1000)
+			older := randomAccessPayload(t, userID, time.Minute)
+			older.IssuedAt = time.Now().Add(-time.Second)
+			otherUser := randomAccessPayload(t, userID+1, time.Minute)
+			otherUser.IssuedAt = older.IssuedAt
+
+			err := revocations.RevokeAllBefore(context.Background(), token.RolePassenger, userID, time.Now(), time.Minute)
+			require.NoError(t, err)
+
+			newer := randomAccessPayload(t, userID, time.Minute)
+			newer.IssuedAt = time.Now().Add(time.Second)
+
+			isRevoked, err := revocations.IsRevoked(context.Background(), older)
+			require.NoError(t, err)
+			require.True(t, isRevoked)
+
+			isRevoked, err = revocations.IsRevoked(context.Background(), newer)
+			require.NoError(t, err)
+			require.False(t, isRevoked)
+
+			isRevoked, err = revocations.IsRevoked(context.Background(), otherUser)
+			require.NoError(t, err)
+			require.False(t, isRevoked)
+		})
+	}
+}
+
+func TestMemoryRevocationListPrunesExpired(t *testing.T) {
+	revocations := newMemoryRevocationList().(*memoryRevocationList)
+
+	expiring := randomAccessPayload(t, utils.RandomInt(1, 1000), time.Minute)
+	require.NoError(t, revocations.Revoke(context.Background(), expiring))
+	require.NoError(t, revocations.RevokeAllBefore(context.Background(), token.RolePassenger, expiring.UserID, time.Now(), time.Minute))
+
+	// Pretend both entries have outlived the token.
+	revocations.tokens[revokedTokenKey(expiring.ID.String())] = time.Now().Add(-time.Second)
+	entry := revocations.cutoffs[revokedBeforeKey(token.RolePassenger, expiring.UserID)]
+	entry.expireAt = time.Now().Add(-time.Second)
+	revocations.cutoffs[revokedBeforeKey(token.RolePassenger, expiring.UserID)] = entry
+
+	isRevoked, err := revocations.IsRevoked(context.Background(), expiring)
+	require.NoError(t, err)
+	require.False(t, isRevoked)
+
+	fresh := randomAccessPayload(t, utils.RandomInt(1001, 2000), time.Minute)
+	require.NoError(t, revocations.Revoke(context.Background(), fresh))
+	require.Len(t, revocations.tokens, 1)
+	require.Empty(t, revocations.cutoffs)
+
+	// Tokens that already expired are not worth remembering.
+	expired := randomAccessPayload(t, utils.RandomInt(1, 1000), -time.Minute)
+	require.NoError(t, revocations.Revoke(context.Background(), expired))
+	require.Len(t, revocations.tokens, 1)
+}
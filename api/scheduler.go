@@ -0,0 +1,185 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	db "github.com/emonoid/toribook.git/db/sqlc"
+)
+
+const (
+	defaultScheduleOpenBefore     = 20 * time.Minute
+	defaultScheduleReminderBefore = time.Hour
+	defaultScheduleExpireAfter    = 15 * time.Minute
+	defaultSchedulerInterval      = 30 * time.Second
+	// maxScheduleAhead is how far in advance a trip can be booked.
+	maxScheduleAhead = 7 * 24 * time.Hour
+)
+
+type scheduleSettings struct {
+	openBefore     time.Duration
+	reminderBefore time.Duration
+	expireAfter    time.Duration
+	interval       time.Duration
+}
+
+// scheduleSettings reads the scheduled trip options from the config and falls
+// back to the defaults for anything left unset.
+func (server *Server) scheduleSettings() scheduleSettings {
+	settings := scheduleSettings{
+		openBefore:     server.config.ScheduleOpenBefore,
+		reminderBefore: server.config.ScheduleReminderBefore,
+		expireAfter:    server.config.ScheduleExpireAfter,
+		interval:       server.config.SchedulerInterval,
+	}
+
+	if settings.openBefore <= 0 {
+		settings.openBefore = defaultScheduleOpenBefore
+	}
+	if settings.reminderBefore <= 0 {
+		settings.reminderBefore = defaultScheduleReminderBefore
+	}
+	if settings.expireAfter <= 0 {
+		settings.expireAfter = defaultScheduleExpireAfter
+	}
+	if settings.interval <= 0 {
+		settings.interval = defaultSchedulerInterval
+	}
+
+	return settings
+}
+
+// runScheduler moves scheduled trips along until ctx is done. Every tick it
+// reminds passengers of upcoming pickups, opens bidding on trips whose pickup
+// is close and expires trips nobody accepted in time.
+func (server *Server) runScheduler(ctx context.Context) {
+	ticker := time.NewTicker(server.scheduleSettings().interval)
+	defer ticker.Stop()
+
+	for {
+		server.runScheduledJobs(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (server *Server) runScheduledJobs(ctx context.Context, now time.Time) {
+	settings := server.scheduleSettings()
+
+	server.remindScheduledTrips(ctx, now.Add(settings.reminderBefore))
+	server.openScheduledTrips(ctx, now.Add(settings.openBefore))
+	server.expireScheduledTrips(ctx, now.Add(-settings.expireAfter))
+}
+
+// remindScheduledTrips tells passengers about scheduled trips picking up
+// before the given time, once per trip.
+func (server *Server) remindScheduledTrips(ctx context.Context, before time.Time) {
+	trips, err := server.store.ListScheduledTripsToRemind(ctx, sql.NullTime{Time: before, Valid: true})
+	if err != nil {
+		log.Println("Cannot list scheduled trips to remind:", err)
+		return
+	}
+
+	for _, trip := range trips {
+		// nothing changes when another server already sent this reminder
+		sent, err := server.store.MarkTripReminderSent(ctx, trip.ID)
+		if err != nil {
+			log.Println("Cannot mark reminder sent for trip", trip.BookingID, ":", err)
+			continue
+		}
+		if sent == 0 {
+			continue
+		}
+
+		server.webSocketManager.Broadcast("trip_status: "+trip.BookingID, finalResponse(FinalResponse{
+			Status:  true,
+			Message: "Scheduled trip reminder",
+			Data:    newTripResponse(trip),
+		}))
+	}
+}
+
+// openScheduledTrips moves scheduled trips picking up before the given time
+// to requested and offers them to nearby drivers.
+func (server *Server) openScheduledTrips(ctx context.Context, before time.Time) {
+	trips, err := server.store.ListDueScheduledTrips(ctx, sql.NullTime{Time: before, Valid: true})
+	if err != nil {
+		log.Println("Cannot list due scheduled trips:", err)
+		return
+	}
+
+	for _, trip := range trips {
+		result, err := server.store.UpdateTripStatusTx(ctx, db.UpdateTripStatusTxParams{
+			UpdateTripStatusParams: db.UpdateTripStatusParams{
+				TripStatus:    db.TripStatusRequested,
+				BookingID:     trip.BookingID,
+				CurrentStatus: db.TripStatusScheduled,
+			},
+			Actor: systemActor,
+		})
+		if err != nil {
+			// sql.ErrNoRows means it was cancelled or opened meanwhile
+			if err != sql.ErrNoRows {
+				log.Println("Cannot open scheduled trip", trip.BookingID, ":", err)
+			}
+			continue
+		}
+
+		opened := result.Trip
+		response := newTripResponse(opened)
+
+		server.webSocketManager.Broadcast("trip_status: "+opened.BookingID, finalResponse(FinalResponse{
+			Status:  true,
+			Message: "Scheduled trip open for bidding",
+			Data:    response,
+		}))
+
+		go server.dispatchTrip(ctx, opened, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Scheduled trip open for bidding",
+			Data:    response}))
+	}
+}
+
+// expireScheduledTrips expires scheduled trips that are still waiting for a
+// driver although their pickup time was before the given time, and releases
+// any payment held for them.
+func (server *Server) expireScheduledTrips(ctx context.Context, before time.Time) {
+	trips, err := server.store.ListExpiredScheduledTrips(ctx, sql.NullTime{Time: before, Valid: true})
+	if err != nil {
+		log.Println("Cannot list expired scheduled trips:", err)
+		return
+	}
+
+	for _, trip := range trips {
+		result, err := server.store.UpdateTripStatusTx(ctx, db.UpdateTripStatusTxParams{
+			UpdateTripStatusParams: db.UpdateTripStatusParams{
+				TripStatus:    db.TripStatusExpired,
+				BookingID:     trip.BookingID,
+				CurrentStatus: trip.TripStatus,
+			},
+			Actor: systemActor,
+		})
+		if err != nil {
+			// sql.ErrNoRows means a driver was found or it was cancelled meanwhile
+			if err != sql.ErrNoRows {
+				log.Println("Cannot expire scheduled trip", trip.BookingID, ":", err)
+			}
+			continue
+		}
+
+		server.releaseTripPayment(ctx, result.Trip)
+
+		server.webSocketManager.Broadcast("trip_status: "+trip.BookingID, finalResponse(FinalResponse{
+			Status:  true,
+			Message: "Trip expired",
+			Data:    newTripResponse(result.Trip),
+		}))
+	}
+}
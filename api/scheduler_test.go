@@ -0,0 +1,223 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/emonoid/toribook.git/db/mock"
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/payments"
+	"github.com/golang/mock/gomock"
+)
+
+func scheduledTrip(passengerID int64, scheduledAt time.Time) db.Trip {
+	trip := randomTrip(passengerID, db.TripStatusScheduled)
+	trip.ScheduledAt = sql.NullTime{Time: scheduledAt, Valid: true}
+	return trip
+}
+
+func TestRunScheduledJobs(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	now := time.Now().UTC()
+
+	remindBefore := sql.NullTime{Time: now.Add(defaultScheduleReminderBefore), Valid: true}
+	openBefore := sql.NullTime{Time: now.Add(defaultScheduleOpenBefore), Valid: true}
+	expireBefore := sql.NullTime{Time: now.Add(-defaultScheduleExpireAfter), Valid: true}
+
+	// expectNothingDue stubs the jobs a test case does not care about.
+	expectNothingDue := func(store *mockdb.MockStore, remind, open, expire bool) {
+		if remind {
+			store.EXPECT().
+				ListScheduledTripsToRemind(gomock.Any(), gomock.Eq(remindBefore)).
+				Times(1).
+				Return([]db.Trip{}, nil)
+		}
+		if open {
+			store.EXPECT().
+				ListDueScheduledTrips(gomock.Any(), gomock.Eq(openBefore)).
+				Times(1).
+				Return([]db.Trip{}, nil)
+		}
+		if expire {
+			store.EXPECT().
+				ListExpiredScheduledTrips(gomock.Any(), gomock.Eq(expireBefore)).
+				Times(1).
+				Return([]db.Trip{}, nil)
+		}
+	}
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+	}{
+		{
+			name: "Remind",
+			buildStubs: func(store *mockdb.MockStore) {
+				trip := scheduledTrip(passenger.ID, now.Add(45*time.Minute))
+
+				store.EXPECT().
+					ListScheduledTripsToRemind(gomock.Any(), gomock.Eq(remindBefore)).
+					Times(1).
+					Return([]db.Trip{trip}, nil)
+				store.EXPECT().
+					MarkTripReminderSent(gomock.Any(), gomock.Eq(trip.ID)).
+					Times(1).
+					Return(int64(1), nil)
+				expectNothingDue(store, false, true, true)
+			},
+		},
+		{
+			name: "ReminderAlreadySent",
+			buildStubs: func(store *mockdb.MockStore) {
+				trip := scheduledTrip(passenger.ID, now.Add(45*time.Minute))
+
+				store.EXPECT().
+					ListScheduledTripsToRemind(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Trip{trip}, nil)
+				store.EXPECT().
+					MarkTripReminderSent(gomock.Any(), gomock.Eq(trip.ID)).
+					Times(1).
+					Return(int64(0), nil)
+				expectNothingDue(store, false, true, true)
+			},
+		},
+		{
+			name: "OpenDueTrip",
+			buildStubs: func(store *mockdb.MockStore) {
+				trip := scheduledTrip(passenger.ID, now.Add(10*time.Minute))
+				opened := trip
+				opened.TripStatus = db.TripStatusRequested
+
+				expectNothingDue(store, true, false, true)
+				store.EXPECT().
+					ListDueScheduledTrips(gomock.Any(), gomock.Eq(openBefore)).
+					Times(1).
+					Return([]db.Trip{trip}, nil)
+				store.EXPECT().
+					UpdateTripStatusTx(gomock.Any(), gomock.Eq(db.UpdateTripStatusTxParams{
+						UpdateTripStatusParams: db.UpdateTripStatusParams{
+							TripStatus:    db.TripStatusRequested,
+							BookingID:     trip.BookingID,
+							CurrentStatus: db.TripStatusScheduled,
+						},
+						Actor: systemActor,
+					})).
+					Times(1).
+					Return(db.UpdateTripStatusTxResult{Trip: opened}, nil)
+			},
+		},
+		{
+			name: "DueTripCancelledMeanwhile",
+			buildStubs: func(store *mockdb.MockStore) {
+				trip := scheduledTrip(passenger.ID, now.Add(10*time.Minute))
+
+				expectNothingDue(store, true, false, true)
+				store.EXPECT().
+					ListDueScheduledTrips(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Trip{trip}, nil)
+				store.EXPECT().
+					UpdateTripStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateTripStatusTxResult{}, sql.ErrNoRows)
+			},
+		},
+		{
+			name: "Expire",
+			buildStubs: func(store *mockdb.MockStore) {
+				trip := scheduledTrip(passenger.ID, now.Add(-time.Hour))
+				trip.TripStatus = db.TripStatusBidding
+				expired := trip
+				expired.TripStatus = db.TripStatusExpired
+
+				expectNothingDue(store, true, true, false)
+				store.EXPECT().
+					ListExpiredScheduledTrips(gomock.Any(), gomock.Eq(expireBefore)).
+					Times(1).
+					Return([]db.Trip{trip}, nil)
+				store.EXPECT().
+					UpdateTripStatusTx(gomock.Any(), gomock.Eq(db.UpdateTripStatusTxParams{
+						UpdateTripStatusParams: db.UpdateTripStatusParams{
+							TripStatus:    db.TripStatusExpired,
+							BookingID:     trip.BookingID,
+							CurrentStatus: db.TripStatusBidding,
+						},
+						Actor: systemActor,
+					})).
+					Times(1).
+					Return(db.UpdateTripStatusTxResult{Trip: expired}, nil)
+
+				// the hold on the passenger's funds is released
+				intent := tripPaymentIntent(expired, payments.ProviderCash, db.PaymentStatusAuthorized)
+				intent.Amount = 300
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(sql.NullInt64{Int64: trip.ID, Valid: true})).
+					Times(1).
+					Return(intent, nil)
+				store.EXPECT().
+					UpdatePaymentIntentStatus(gomock.Any(), gomock.Eq(db.UpdatePaymentIntentStatusParams{
+						Status:        db.PaymentStatusVoided,
+						ID:            intent.ID,
+						CurrentStatus: db.PaymentStatusAuthorized,
+					})).
+					Times(1).
+					Return(intent, nil)
+			},
+		},
+		{
+			name: "ExpiringTripAcceptedMeanwhile",
+			buildStubs: func(store *mockdb.MockStore) {
+				trip := scheduledTrip(passenger.ID, now.Add(-time.Hour))
+				trip.TripStatus = db.TripStatusRequested
+
+				expectNothingDue(store, true, true, false)
+				store.EXPECT().
+					ListExpiredScheduledTrips(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Trip{trip}, nil)
+				store.EXPECT().
+					UpdateTripStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateTripStatusTxResult{}, sql.ErrNoRows)
+			},
+		},
+		{
+			name: "ListErrorDoesNotStopOtherJobs",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListScheduledTripsToRemind(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+				store.EXPECT().
+					MarkTripReminderSent(gomock.Any(), gomock.Any()).
+					Times(0)
+				expectNothingDue(store, false, true, true)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			// search a single radius so dispatching opened trips needs no store calls
+			server.config.DispatchRadiusKm = 1
+			server.config.DispatchMaxRadiusKm = 1
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			server.runScheduledJobs(ctx, now)
+		})
+	}
+}
@@ -1,11 +1,15 @@
 package api
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"sync"
 
 	db "github.com/emonoid/toribook.git/db/sqlc"
 	"github.com/emonoid/toribook.git/helpers"
+	"github.com/emonoid/toribook.git/location"
+	"github.com/emonoid/toribook.git/payments"
 	"github.com/emonoid/toribook.git/token"
 	"github.com/emonoid/toribook.git/utils"
 	"github.com/gin-gonic/gin"
@@ -14,16 +18,19 @@ import (
 )
 
 type Server struct {
-	store            *db.Store
+	store            db.Store
 	router           *gin.Engine
 	tokenMaker       token.Maker
 	config           utils.Config
 	webSocketManager *helpers.WebSocketManager
 	redisSubscribers map[string]bool
 	redisLock        sync.Mutex
+	revocations      revocationList
+	locations        location.Store
+	payments         *payments.Registry
 }
 
-func NewServer(config utils.Config, store *db.Store) (*Server, error) {
+func NewServer(config utils.Config, store db.Store) (*Server, error) {
 
 	tokenMaker, err := token.NewPasetoMaker([]byte(config.TokenSymmetricKey))
 	// tokenMaker, err := token.NewJWTMaker(config.TokenSymmetricKey)
@@ -32,8 +39,19 @@ func NewServer(config utils.Config, store *db.Store) (*Server, error) {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
 
+	// webhooks signed with an empty secret could be forged by anyone
+	if config.PaymentWebhookSecret == "" {
+		return nil, fmt.Errorf("cannot create payment providers: PAYMENT_WEBHOOK_SECRET is not set")
+	}
+
 	server := &Server{store: store, tokenMaker: tokenMaker, config: config, webSocketManager: helpers.NewWebSocketManager(), redisSubscribers: make(map[string]bool), redisLock: sync.Mutex{}}
 
+	providers := []payments.Provider{payments.NewCashProvider()}
+	if config.PaymentFakeGateway {
+		providers = append(providers, payments.NewFakeGateway(config.PaymentWebhookSecret))
+	}
+	server.payments = payments.NewRegistry(providers...)
+
 	// Register custom validation if needed
 	// if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 	// 	v.RegisterValidation("currency", validateCurrency)
@@ -46,8 +64,19 @@ func NewServer(config utils.Config, store *db.Store) (*Server, error) {
 
 func (server *Server) setupRouters() {
 	router := gin.Default()
-	protectedRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
-	redisClient := utils.NewRedisClient()
+	redisClient := utils.NewRedisClient(server.config.RedisAddress)
+	if err := redisClient.Ping(context.Background()).Err(); err != nil {
+		log.Println("Redis unavailable, keeping driver locations and token revocations in memory:", err)
+		server.locations = location.NewMemoryStore()
+		server.revocations = newMemoryRevocationList()
+	} else {
+		server.locations = location.NewRedisStore(redisClient)
+		server.revocations = newRedisRevocationList(redisClient)
+	}
+	protectedRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.revocations))
+	passengerRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.revocations), requireRole(token.RolePassenger))
+	driverRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.revocations), requireRole(token.RoleDriver))
+	adminRoutes := router.Group("/").Use(requireAdminKey(server.config.AdminAPIKey))
 
 	apiVersion := "/api/v1/"
 	router.GET("/", func(ctx *gin.Context) {
@@ -57,34 +86,68 @@ func (server *Server) setupRouters() {
 	// passenger routes
 	router.POST(apiVersion+"passenger/registration", server.createPassenger)
 	router.POST(apiVersion+"passenger/login", server.loginPassenger)
+	passengerRoutes.GET(apiVersion+"passenger/trips", server.passengerTripHistory)
+	passengerRoutes.GET(apiVersion+"passenger/wallet", server.getWallet)
+	passengerRoutes.GET(apiVersion+"passenger/wallet/statement", server.getWalletStatement)
 	protectedRoutes.GET(apiVersion+"passenger/:id", server.getPassenger)
 
 	// driver routes
 	router.POST(apiVersion+"driver/registration", server.createDriver)
 	router.POST(apiVersion+"driver/login", server.loginDriver)
+	protectedRoutes.GET(apiVersion+"driver/nearby", server.getNearbyDrivers)
+	driverRoutes.GET(apiVersion+"driver/trips", server.driverTripHistory)
+	driverRoutes.GET(apiVersion+"driver/wallet", server.getWallet)
+	driverRoutes.GET(apiVersion+"driver/wallet/statement", server.getWalletStatement)
 	protectedRoutes.GET(apiVersion+"driver/:id", server.getDriver)
+	driverRoutes.POST(apiVersion+"driver/location", server.updateDriverLocation)
+	router.GET(apiVersion+"ws/driver/location", server.driverLocationWebSocket)
+
+	// token routes
+	router.POST(apiVersion+"token/renew", server.renewAccessToken)
+	protectedRoutes.POST(apiVersion+"logout", server.logout)
+	protectedRoutes.POST(apiVersion+"logout/all", server.logoutAll)
 
 	// cars routes
 	protectedRoutes.GET(apiVersion+"car/all", server.getAllCars)
 
 	// trip routes
-	protectedRoutes.POST(apiVersion+"trip/create", server.createTrip)
+	passengerRoutes.POST(apiVersion+"trip/create", server.createTrip)
+	protectedRoutes.POST(apiVersion+"trip/estimate", server.estimateFare)
 	protectedRoutes.GET(apiVersion+"trip/:id", server.getTrip)
+	protectedRoutes.GET(apiVersion+"trip/:id/timeline", server.getTripTimeline)
 	router.GET(apiVersion+"ws/trips", server.tripWebSocket)
 	protectedRoutes.GET(apiVersion+"trip/all", server.getAllTrips)
-	protectedRoutes.POST(apiVersion+"trip/update-status", server.updateTripStatus)
+	driverRoutes.POST(apiVersion+"trip/update-status", server.updateTripStatus)
 	router.GET(apiVersion+"ws/trip/listen-update-status", server.tripStatusUpdateWebSocket)
-	protectedRoutes.POST(apiVersion+"trip/accept", server.tripAccept)
+	router.GET(apiVersion+"ws/trip/location", server.tripLocationWebSocket)
+	driverRoutes.POST(apiVersion+"trip/accept", server.tripAccept)
+	passengerRoutes.POST(apiVersion+"trip/cancel", server.passengerCancelTrip)
+	driverRoutes.POST(apiVersion+"trip/driver-cancel", server.driverCancelTrip)
+	driverRoutes.POST(apiVersion+"trip/stop-status", server.updateTripStopStatus)
+	passengerRoutes.POST(apiVersion+"trip/rate", server.passengerRateTrip)
+	driverRoutes.POST(apiVersion+"trip/driver-rate", server.driverRateTrip)
+	passengerRoutes.GET(apiVersion+"trip/:id/receipt", server.getTripReceipt)
+	passengerRoutes.GET(apiVersion+"trip/:id/receipt/pdf", server.getTripReceiptPDF)
+	passengerRoutes.POST(apiVersion+"trip/pay", server.payTrip)
 
 	// bid routes
-	protectedRoutes.POST(apiVersion+"bid/submit", server.bidSubmitHandler(redisClient))
-	protectedRoutes.GET(apiVersion+"bids/:booking_id", server.getBidListHandler(redisClient))
+	driverRoutes.POST(apiVersion+"bid/submit", server.bidSubmitHandler(redisClient))
+	passengerRoutes.GET(apiVersion+"bids/:booking_id", server.getBidListHandler(redisClient))
+	passengerRoutes.POST(apiVersion+"bid/accept", server.bidAcceptHandler(redisClient))
 	router.GET(apiVersion+"ws/bids/:booking_id", server.BidWebSocketHandler(redisClient))
 
+	// payment routes
+	driverRoutes.POST(apiVersion+"subscription/pay", server.paySubscription)
+	router.POST(apiVersion+"payments/webhook/:provider", server.paymentWebhook)
+
+	// admin routes
+	adminRoutes.POST(apiVersion+"admin/trip/refund", server.refundTripPayment)
+
 	server.router = router
 }
 
 func (server *Server) Start(address string) error {
+	go server.runScheduler(context.Background())
 	return server.router.Run(address)
 }
 
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/fare"
+)
+
+const (
+	defaultSurgeMaxMultiplier = 2
+	defaultSurgeWindow        = 15 * time.Minute
+)
+
+// surgeMultiplier compares the trips requested in the grid cell around the
+// point during the surge window with the drivers available in that cell.
+// SURGE_MAX_MULTIPLIER caps the result; setting it to 1 turns surge off.
+func (server *Server) surgeMultiplier(ctx context.Context, lat, long float64) (float64, error) {
+	maxMultiplier := server.config.SurgeMaxMultiplier
+	if maxMultiplier <= 0 {
+		maxMultiplier = defaultSurgeMaxMultiplier
+	}
+	window := server.config.SurgeWindow
+	if window <= 0 {
+		window = defaultSurgeWindow
+	}
+
+	cell := fare.CellOf(lat, long)
+
+	openRequests, err := server.store.CountOpenTripsInArea(ctx, db.CountOpenTripsInAreaParams{
+		Since:   time.Now().Add(-window),
+		MinLat:  cell.MinLat,
+		MaxLat:  cell.MaxLat,
+		MinLong: cell.MinLong,
+		MaxLong: cell.MaxLong,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	centerLat, centerLong := cell.Center()
+	drivers, err := server.findNearbyDrivers(ctx, centerLat, centerLong, cell.RadiusKm(), "", nearbyCandidates)
+	if err != nil {
+		return 0, err
+	}
+
+	// the radius search reaches past the edges of the cell
+	var availableDrivers int64
+	for _, driver := range drivers {
+		if cell.Contains(driver.Lat, driver.Long) {
+			availableDrivers++
+		}
+	}
+
+	return fare.SurgeMultiplier(openRequests, availableDrivers, maxMultiplier), nil
+}
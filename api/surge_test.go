@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/emonoid/toribook.git/db/mock"
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/location"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSurgeMultiplier(t *testing.T) {
+	insideDriver, _ := randomDriver(t)
+	outsideDriver, _ := randomDriver(t)
+
+	// the pickup point 23.7937, 90.4066 lies in the cell 23.78-23.80, 90.40-90.42;
+	// the second driver is close by but across the northern edge
+	fixes := []location.Fix{
+		{DriverID: insideDriver.ID, Lat: 23.7950, Long: 90.4100},
+		{DriverID: outsideDriver.ID, Lat: 23.8010, Long: 90.4100},
+	}
+
+	testCases := []struct {
+		name          string
+		maxMultiplier float64
+		buildStubs    func(store *mockdb.MockStore)
+		check         func(t *testing.T, multiplier float64, err error)
+	}{
+		{
+			name: "CountsDriversInCell",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CountOpenTripsInArea(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CountOpenTripsInAreaParams) (int64, error) {
+						require.InDelta(t, 23.78, arg.MinLat, 1e-9)
+						require.InDelta(t, 23.80, arg.MaxLat, 1e-9)
+						require.InDelta(t, 90.40, arg.MinLong, 1e-9)
+						require.InDelta(t, 90.42, arg.MaxLong, 1e-9)
+						require.WithinDuration(t, time.Now().Add(-defaultSurgeWindow), arg.Since, time.Second)
+						return 3, nil
+					})
+				store.EXPECT().
+					ListAvailableDrivers(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Driver{insideDriver, outsideDriver}, nil)
+			},
+			check: func(t *testing.T, multiplier float64, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 2.0, multiplier)
+			},
+		},
+		{
+			name:          "Capped",
+			maxMultiplier: 1.3,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CountOpenTripsInArea(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(3), nil)
+				store.EXPECT().
+					ListAvailableDrivers(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Driver{insideDriver, outsideDriver}, nil)
+			},
+			check: func(t *testing.T, multiplier float64, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 1.3, multiplier)
+			},
+		},
+		{
+			name: "EnoughDrivers",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CountOpenTripsInArea(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(1), nil)
+				store.EXPECT().
+					ListAvailableDrivers(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Driver{insideDriver}, nil)
+			},
+			check: func(t *testing.T, multiplier float64, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 1.0, multiplier)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CountOpenTripsInArea(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), sql.ErrConnDone)
+				store.EXPECT().
+					ListAvailableDrivers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			check: func(t *testing.T, multiplier float64, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.config.SurgeMaxMultiplier = tc.maxMultiplier
+
+			for _, fix := range fixes {
+				fix.RecordedAt = time.Now().UTC()
+				require.NoError(t, server.locations.SaveLocation(context.Background(), fix))
+			}
+
+			multiplier, err := server.surgeMultiplier(context.Background(), 23.7937, 90.4066)
+			tc.check(t, multiplier, err)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/helpers"
+	"github.com/emonoid/toribook.git/location"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/gin-gonic/gin"
+)
+
+// systemActor is recorded for status changes the server makes on its own.
+var systemActor = db.TripActor{Type: db.ActorTypeSystem}
+
+func passengerActor(passengerID int64) db.TripActor {
+	return db.TripActor{
+		Type: db.ActorTypePassenger,
+		ID:   sql.NullInt64{Int64: passengerID, Valid: true},
+	}
+}
+
+// driverActor returns the driver together with their last reported position.
+// The position is best effort: a missing or failed lookup only leaves it out.
+func (server *Server) driverActor(ctx context.Context, driverID int64) db.TripActor {
+	actor := db.TripActor{
+		Type: db.ActorTypeDriver,
+		ID:   sql.NullInt64{Int64: driverID, Valid: true},
+	}
+
+	fix, err := server.locations.GetLocation(ctx, driverID)
+	if err != nil {
+		if !errors.Is(err, location.ErrLocationNotFound) {
+			log.Println("Failed to load driver location:", err)
+		}
+		return actor
+	}
+
+	actor.Lat = sql.NullFloat64{Float64: fix.Lat, Valid: true}
+	actor.Long = sql.NullFloat64{Float64: fix.Long, Valid: true}
+	return actor
+}
+
+type TripEventResponse struct {
+	// FromStatus is null for the event creating the trip.
+	FromStatus *string   `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ActorType  string    `json:"actor_type"`
+	ActorID    *int64    `json:"actor_id"`
+	Lat        *float64  `json:"lat"`
+	Long       *float64  `json:"long"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type TripTimelineResponse struct {
+	BookingID   string              `json:"booking_id"`
+	TripStatus  string              `json:"trip_status"`
+	CreatedAt   time.Time           `json:"created_at"`
+	AcceptedAt  *time.Time          `json:"accepted_at"`
+	ArrivedAt   *time.Time          `json:"arrived_at"`
+	StartedAt   *time.Time          `json:"started_at"`
+	CompletedAt *time.Time          `json:"completed_at"`
+	CancelledAt *time.Time          `json:"cancelled_at"`
+	Events      []TripEventResponse `json:"events"`
+}
+
+func newTripEventResponse(event db.TripEvent) TripEventResponse {
+	return TripEventResponse{
+		FromStatus: helpers.NullStringToPtr(event.FromStatus),
+		ToStatus:   event.ToStatus,
+		ActorType:  event.ActorType,
+		ActorID:    helpers.NullInt64ToPtr(event.ActorID),
+		Lat:        helpers.NullFloat64ToPtr(event.Lat),
+		Long:       helpers.NullFloat64ToPtr(event.Long),
+		CreatedAt:  event.CreatedAt,
+	}
+}
+
+// getTripTimeline returns every status change of a trip oldest first, along
+// with the timestamps of its milestones.
+func (server *Server) getTripTimeline(ctx *gin.Context) {
+	var req GetTripRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	trip, err := server.store.GetTripByBookingID(ctx, req.BookingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Trip not found"}))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+
+	// passengers may only look at their own bookings
+	if authPayload.Role == token.RolePassenger && trip.PassengerID.Int64 != authPayload.UserID {
+		ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Trip not found"}))
+		return
+	}
+
+	events, err := server.store.ListTripEvents(ctx, trip.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	response := TripTimelineResponse{
+		BookingID:   trip.BookingID,
+		TripStatus:  trip.TripStatus,
+		CreatedAt:   trip.CreatedAt,
+		AcceptedAt:  helpers.NullTimeToPtr(trip.AcceptedAt),
+		ArrivedAt:   helpers.NullTimeToPtr(trip.ArrivedAt),
+		StartedAt:   helpers.NullTimeToPtr(trip.StartedAt),
+		CompletedAt: helpers.NullTimeToPtr(trip.CompletedAt),
+		CancelledAt: helpers.NullTimeToPtr(trip.CancelledAt),
+		Events:      make([]TripEventResponse, 0, len(events)),
+	}
+	for _, event := range events {
+		response.Events = append(response.Events, newTripEventResponse(event))
+	}
+
+	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Trip timeline retrieved successfully",
+		Data:    response,
+	}))
+}
@@ -0,0 +1,252 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/emonoid/toribook.git/db/mock"
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/location"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// unlocatedDriverActor is the actor recorded for a driver who has not
+// reported a position yet.
+func unlocatedDriverActor(driverID int64) db.TripActor {
+	return db.TripActor{
+		Type: db.ActorTypeDriver,
+		ID:   sql.NullInt64{Int64: driverID, Valid: true},
+	}
+}
+
+func TestDriverActor(t *testing.T) {
+	driver, _ := randomDriver(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+	require.Equal(t, unlocatedDriverActor(driver.ID), server.driverActor(context.Background(), driver.ID))
+
+	err := server.locations.SaveLocation(context.Background(), location.Fix{
+		DriverID:   driver.ID,
+		Lat:        23.78,
+		Long:       90.41,
+		RecordedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	actor := server.driverActor(context.Background(), driver.ID)
+	require.Equal(t, db.ActorTypeDriver, actor.Type)
+	require.Equal(t, sql.NullInt64{Int64: driver.ID, Valid: true}, actor.ID)
+	require.True(t, actor.Lat.Valid)
+	require.InDelta(t, 23.78, actor.Lat.Float64, 1e-4)
+	require.True(t, actor.Long.Valid)
+	require.InDelta(t, 90.41, actor.Long.Float64, 1e-4)
+}
+
+func TestGetTripTimelineAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	otherPassenger, _ := randomPassenger(t)
+	otherPassenger.ID = passenger.ID + 1
+	driver, _ := randomDriver(t)
+
+	trip := randomTrip(passenger.ID, db.TripStatusDriverArriving)
+	trip.DriverID = sql.NullInt64{Int64: driver.ID, Valid: true}
+	trip.AcceptedAt = sql.NullTime{Time: trip.CreatedAt.Add(time.Minute), Valid: true}
+
+	events := []db.TripEvent{
+		{
+			ID:        1,
+			TripID:    trip.ID,
+			ToStatus:  db.TripStatusRequested,
+			ActorType: db.ActorTypePassenger,
+			ActorID:   trip.PassengerID,
+			CreatedAt: trip.CreatedAt,
+		},
+		{
+			ID:         2,
+			TripID:     trip.ID,
+			FromStatus: sql.NullString{String: db.TripStatusRequested, Valid: true},
+			ToStatus:   db.TripStatusAccepted,
+			ActorType:  db.ActorTypeDriver,
+			ActorID:    trip.DriverID,
+			CreatedAt:  trip.AcceptedAt.Time,
+		},
+		{
+			ID:         3,
+			TripID:     trip.ID,
+			FromStatus: sql.NullString{String: db.TripStatusAccepted, Valid: true},
+			ToStatus:   db.TripStatusDriverArriving,
+			ActorType:  db.ActorTypeDriver,
+			ActorID:    trip.DriverID,
+			Lat:        sql.NullFloat64{Float64: 23.78, Valid: true},
+			Long:       sql.NullFloat64{Float64: 90.41, Valid: true},
+			CreatedAt:  trip.AcceptedAt.Time.Add(time.Minute),
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OwnTrip",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					ListTripEvents(gomock.Any(), gomock.Eq(trip.ID)).
+					Times(1).
+					Return(events, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got TripTimelineResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.Equal(t, trip.BookingID, got.BookingID)
+				require.Equal(t, db.TripStatusDriverArriving, got.TripStatus)
+				require.NotNil(t, got.AcceptedAt)
+				require.True(t, trip.AcceptedAt.Time.Equal(*got.AcceptedAt))
+				require.Nil(t, got.ArrivedAt)
+				require.Nil(t, got.CompletedAt)
+
+				require.Len(t, got.Events, 3)
+				require.Nil(t, got.Events[0].FromStatus)
+				require.Equal(t, db.TripStatusRequested, got.Events[0].ToStatus)
+				require.Equal(t, passenger.ID, *got.Events[0].ActorID)
+				require.Equal(t, db.TripStatusAccepted, *got.Events[2].FromStatus)
+				require.Equal(t, db.ActorTypeDriver, got.Events[2].ActorType)
+				require.Equal(t, 23.78, *got.Events[2].Lat)
+				require.Equal(t, 90.41, *got.Events[2].Long)
+				require.Nil(t, got.Events[1].Lat)
+			},
+		},
+		{
+			name: "Driver",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					ListTripEvents(gomock.Any(), gomock.Eq(trip.ID)).
+					Times(1).
+					Return([]db.TripEvent{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got TripTimelineResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.NotNil(t, got.Events)
+				require.Empty(t, got.Events)
+			},
+		},
+		{
+			name: "OtherPassengersTrip",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, otherPassenger.Email, token.RolePassenger, otherPassenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					ListTripEvents(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(db.Trip{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					ListTripEvents(gomock.Any(), gomock.Eq(trip.ID)).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/v1/trip/%s/timeline", trip.BookingID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// authTokens is the access/refresh token pair handed out on login.
+type authTokens struct {
+	SessionID             uuid.UUID
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+}
+
+// createSession issues an access token and a refresh token for the user and
+// records the refresh token in a new session row. The access token carries
+// the id of the session so logging out with it ends the session too.
+func (server *Server) createSession(ctx *gin.Context, username string, role string, userID int64) (authTokens, error) {
+	var tokens authTokens
+
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(username, role, userID, token.TokenTypeRefresh, uuid.Nil, server.config.RefreshTokenDuration)
+	if err != nil {
+		return tokens, err
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(username, role, userID, token.TokenTypeAccess, refreshPayload.ID, server.config.AccessTokenDuration)
+	if err != nil {
+		return tokens, err
+	}
+
+	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		Username:     username,
+		Role:         role,
+		UserID:       userID,
+		RefreshToken: refreshToken,
+		UserAgent:    ctx.Request.UserAgent(),
+		ClientIP:     ctx.ClientIP(),
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpireAt,
+	})
+	if err != nil {
+		return tokens, err
+	}
+
+	tokens = authTokens{
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpireAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpireAt,
+	}
+	return tokens, nil
+}
+
+type RenewAccessTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type RenewAccessTokenResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+}
+
+func (server *Server) renewAccessToken(ctx *gin.Context) {
+	var req RenewAccessTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	refreshPayload, err := verifyUnrevokedToken(ctx, server.tokenMaker, server.revocations, req.RefreshToken, token.TokenTypeRefresh)
+	if err != nil {
+		status := http.StatusUnauthorized
+		if !isTokenError(err) {
+			status = http.StatusInternalServerError
+		}
+		ctx.JSON(status, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	session, err := server.store.GetSession(ctx, refreshPayload.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Session not found"}))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	if session.IsBlocked {
+		ctx.JSON(http.StatusUnauthorized, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Session is blocked"}))
+		return
+	}
+
+	if session.Username != refreshPayload.Username || session.Role != refreshPayload.Role || session.UserID != refreshPayload.UserID {
+		ctx.JSON(http.StatusUnauthorized, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Incorrect session user"}))
+		return
+	}
+
+	if session.RefreshToken != req.RefreshToken {
+		ctx.JSON(http.StatusUnauthorized, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Mismatched session token"}))
+		return
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		ctx.JSON(http.StatusUnauthorized, finalResponse(FinalResponse{
+			Status:  false,
+			Message: fmt.Sprintf("Session expired at %s", session.ExpiresAt.Format(time.RFC3339))}))
+		return
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
+		refreshPayload.Username,
+		refreshPayload.Role,
+		refreshPayload.UserID,
+		token.TokenTypeAccess,
+		session.ID,
+		server.config.AccessTokenDuration,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	rsp := RenewAccessTokenResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: accessPayload.ExpireAt,
+	}
+
+	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Access token renewed",
+		Data:    rsp}))
+}
+
+type LogoutRequest struct {
+	// SessionID is only read for access tokens issued before they carried
+	// the id of their session.
+	SessionID string `json:"session_id" binding:"omitempty,uuid"`
+}
+
+// logout revokes the access token used for this request, blocks the session
+// it was issued in and revokes the session's refresh token, so a stolen
+// device cannot get new access tokens either.
+func (server *Server) logout(ctx *gin.Context) {
+	var req LogoutRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+
+	sessionID := authPayload.SessionID
+	if sessionID == uuid.Nil && req.SessionID != "" {
+		sessionID = uuid.MustParse(req.SessionID)
+	}
+
+	if sessionID != uuid.Nil {
+		rows, err := server.store.BlockSession(ctx, db.BlockSessionParams{
+			ID:     sessionID,
+			Role:   authPayload.Role,
+			UserID: authPayload.UserID,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+				Status:  false,
+				Message: err.Error()}))
+			return
+		}
+
+		if rows == 0 {
+			ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Session not found"}))
+			return
+		}
+
+		// a session is keyed by the id of its refresh token, which expires
+		// no later than a refresh token issued now
+		refreshPayload := &token.Payload{
+			ID:       sessionID,
+			ExpireAt: time.Now().Add(server.config.RefreshTokenDuration),
+		}
+		if err := server.revocations.Revoke(ctx, refreshPayload); err != nil {
+			ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+				Status:  false,
+				Message: err.Error()}))
+			return
+		}
+	}
+
+	if err := server.revocations.Revoke(ctx, authPayload); err != nil {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Logged out successfully"}))
+}
+
+// logoutAll revokes every token issued to the user so far and blocks all of
+// their sessions, logging them out of every device.
+func (server *Server) logoutAll(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+
+	err := server.store.BlockUserSessions(ctx, db.BlockUserSessionsParams{
+		Role:   authPayload.Role,
+		UserID: authPayload.UserID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	// refresh tokens live longest, so the cutoff has to outlast them
+	ttl := server.config.RefreshTokenDuration
+	if server.config.AccessTokenDuration > ttl {
+		ttl = server.config.AccessTokenDuration
+	}
+
+	err = server.revocations.RevokeAllBefore(ctx, authPayload.Role, authPayload.UserID, time.Now(), ttl)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Logged out of all devices"}))
+}
@@ -0,0 +1,367 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/emonoid/toribook.git/db/mock"
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenewAccessTokenAPI(t *testing.T) {
+	driver, _ := randomDriver(t)
+
+	testCases := []struct {
+		name          string
+		setupToken    func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload)
+		buildStubs    func(store *mockdb.MockStore, refreshToken string, payload *token.Payload)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(newTestSession(refreshToken, payload), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp RenewAccessTokenResponse
+				requireBodyData(t, recorder.Body, &rsp)
+				require.NotEmpty(t, rsp.AccessToken)
+				require.WithinDuration(t, time.Now().Add(time.Minute), rsp.AccessTokenExpiresAt, time.Second)
+			},
+		},
+		{
+			name: "SessionNotFound",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(db.Session{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "BlockedSession",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				session := newTestSession(refreshToken, payload)
+				session.IsBlocked = true
+
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(session, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "MismatchedToken",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(newTestSession("another-token", payload), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredToken",
+			setupToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				refreshToken, payload, err := tokenMaker.CreateToken(driver.Mobile, token.RoleDriver, driver.ID, token.TokenTypeRefresh, uuid.Nil, -time.Minute)
+				require.NoError(t, err)
+				return refreshToken, payload
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "AccessToken",
+			setupToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				accessToken, payload, err := tokenMaker.CreateToken(driver.Mobile, token.RoleDriver, driver.ID, token.TokenTypeAccess, uuid.Nil, time.Hour)
+				require.NoError(t, err)
+				return accessToken, payload
+			},
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			var refreshToken string
+			var payload *token.Payload
+			if tc.setupToken != nil {
+				refreshToken, payload = tc.setupToken(t, server.tokenMaker)
+			} else {
+				var err error
+				refreshToken, payload, err = server.tokenMaker.CreateToken(driver.Mobile, token.RoleDriver, driver.ID, token.TokenTypeRefresh, uuid.Nil, time.Hour)
+				require.NoError(t, err)
+			}
+			tc.buildStubs(store, refreshToken, payload)
+
+			data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, "/api/v1/token/renew", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func newTestSession(refreshToken string, payload *token.Payload) db.Session {
+	return db.Session{
+		ID:           payload.ID,
+		Username:     payload.Username,
+		Role:         payload.Role,
+		UserID:       payload.UserID,
+		RefreshToken: refreshToken,
+		ExpiresAt:    payload.ExpireAt,
+	}
+}
+
+func TestLogoutAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	sessionID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, replay *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, replay *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, http.StatusUnauthorized, replay.Code)
+			},
+		},
+		{
+			name: "BlockSession",
+			body: gin.H{"session_id": sessionID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Eq(db.BlockSessionParams{
+						ID:     sessionID,
+						Role:   token.RolePassenger,
+						UserID: passenger.ID,
+					})).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, replay *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, http.StatusUnauthorized, replay.Code)
+			},
+		},
+		{
+			name: "OtherUsersSession",
+			body: gin.H{"session_id": sessionID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return(int64(0), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, replay *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.Equal(t, http.StatusNotFound, replay.Code)
+			},
+		},
+		{
+			name: "InvalidSessionID",
+			body: gin.H{"session_id": "not-a-uuid"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, replay *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, http.StatusBadRequest, replay.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+
+			accessToken, _, err := server.tokenMaker.CreateToken(passenger.Email, token.RolePassenger, passenger.ID, token.TokenTypeAccess, uuid.Nil, time.Minute)
+			require.NoError(t, err)
+
+			// logging out twice with the same token shows whether it was revoked
+			send := func() *httptest.ResponseRecorder {
+				data, err := json.Marshal(tc.body)
+				require.NoError(t, err)
+
+				recorder := httptest.NewRecorder()
+				request, err := http.NewRequest(http.MethodPost, "/api/v1/logout", bytes.NewReader(data))
+				require.NoError(t, err)
+
+				request.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" "+accessToken)
+				server.router.ServeHTTP(recorder, request)
+				return recorder
+			}
+
+			recorder := send()
+			replay := send()
+			tc.checkResponse(t, recorder, replay)
+		})
+	}
+}
+
+func TestLogoutRevokesRefreshToken(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(passenger.Email, token.RolePassenger, passenger.ID, token.TokenTypeRefresh, uuid.Nil, time.Hour)
+	require.NoError(t, err)
+
+	store.EXPECT().
+		BlockSession(gomock.Any(), gomock.Eq(db.BlockSessionParams{
+			ID:     refreshPayload.ID,
+			Role:   token.RolePassenger,
+			UserID: passenger.ID,
+		})).
+		Times(1).
+		Return(int64(1), nil)
+	// the revoked refresh token is turned away before its session is looked up
+	store.EXPECT().
+		GetSession(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	// a plain logout, the access token knows its session
+	accessToken, _, err := server.tokenMaker.CreateToken(passenger.Email, token.RolePassenger, passenger.ID, token.TokenTypeAccess, refreshPayload.ID, time.Minute)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/logout", bytes.NewReader(nil))
+	require.NoError(t, err)
+
+	request.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" "+accessToken)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+	require.NoError(t, err)
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodPost, "/api/v1/token/renew", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestLogoutAllAPI(t *testing.T) {
+	driver, _ := randomDriver(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		BlockUserSessions(gomock.Any(), gomock.Eq(db.BlockUserSessionsParams{
+			Role:   token.RoleDriver,
+			UserID: driver.ID,
+		})).
+		Times(1).
+		Return(nil)
+
+	server := newTestServer(t, store)
+
+	logoutAll := func(accessToken string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/logout/all", nil)
+		require.NoError(t, err)
+
+		request.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" "+accessToken)
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	phoneToken, _, err := server.tokenMaker.CreateToken(driver.Mobile, token.RoleDriver, driver.ID, token.TokenTypeAccess, uuid.Nil, time.Minute)
+	require.NoError(t, err)
+	tabletToken, _, err := server.tokenMaker.CreateToken(driver.Mobile, token.RoleDriver, driver.ID, token.TokenTypeAccess, uuid.Nil, time.Minute)
+	require.NoError(t, err)
+
+	recorder := logoutAll(phoneToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// every token issued before the logout is rejected, not just the one used
+	payload, err := server.verifyToken(context.Background(), tabletToken)
+	require.ErrorIs(t, err, ErrRevokedToken)
+	require.Nil(t, payload)
+
+	// logging in again afterwards works
+	newToken, _, err := server.tokenMaker.CreateToken(driver.Mobile, token.RoleDriver, driver.ID, token.TokenTypeAccess, uuid.Nil, time.Minute)
+	require.NoError(t, err)
+
+	payload, err = server.verifyToken(context.Background(), newToken)
+	require.NoError(t, err)
+	require.Equal(t, driver.ID, payload.UserID)
+}
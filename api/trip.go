@@ -1,49 +1,99 @@
 package api
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	db "github.com/emonoid/toribook.git/db/sqlc"
 	"github.com/emonoid/toribook.git/helpers"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/emonoid/toribook.git/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/lib/pq"
 )
 
+// maxBookingIDAttempts bounds how often createTrip regenerates a booking id
+// that collided with an existing trip.
+const maxBookingIDAttempts = 5
+
 type CreateTripRequest struct {
-	BookingID       string  `json:"booking_id" binding:"required"`
-	TripStatus      string  `json:"trip_status" binding:"required"`
-	PickupLocation  string  `json:"pickup_location" binding:"required"`
-	PickupLat       string  `json:"pickup_lat" binding:"required"`
-	PickupLong      string  `json:"pickup_long" binding:"required"`
-	DropoffLocation string  `json:"dropoff_location" binding:"required"`
-	DropoffLat      string  `json:"dropoff_lat" binding:"required"`
-	DropoffLong     string  `json:"dropoff_long" binding:"required"`
-	DriverID        *int64  `json:"driver_id"`
-	DriverName      *string `json:"driver_name"`
-	DriverMobile    *string `json:"driver_mobile"`
-	CarID           *int64  `json:"car_id"`
-	CarType         *string `json:"car_type"`
-	CarImage        *string `json:"car_image"`
-	Fare            *int64  `json:"fare"`
+	PickupLocation  string   `json:"pickup_location" binding:"required"`
+	PickupLat       *float64 `json:"pickup_lat" binding:"required,min=-90,max=90"`
+	PickupLong      *float64 `json:"pickup_long" binding:"required,min=-180,max=180"`
+	DropoffLocation string   `json:"dropoff_location" binding:"required"`
+	DropoffLat      *float64 `json:"dropoff_lat" binding:"required,min=-90,max=90"`
+	DropoffLong     *float64 `json:"dropoff_long" binding:"required,min=-180,max=180"`
+	// ScheduledAt books the trip for a later pickup instead of right away.
+	ScheduledAt *time.Time `json:"scheduled_at"`
+	// Stops are up to five waypoints visited in order between pickup and dropoff.
+	Stops []TripStopRequest `json:"stops" binding:"max=5,dive"`
 }
 
 type TripResponse struct {
-	BookingID       string  `json:"booking_id"`
-	TripStatus      string  `json:"trip_status"`
-	PickupLocation  string  `json:"pickup_location"`
-	PickupLat       string  `json:"pickup_lat"`
-	PickupLong      string  `json:"pickup_long"`
-	DropoffLocation string  `json:"dropoff_location"`
-	DropoffLat      string  `json:"dropoff_lat"`
-	DropoffLong     string  `json:"dropoff_long"`
-	DriverID        *int64  `json:"driver_id"`
-	DriverName      *string `json:"driver_name"`
-	DriverMobile    *string `json:"driver_mobile"`
-	CarID           *int64  `json:"car_id"`
-	CarType         *string `json:"car_type"`
-	CarImage        *string `json:"car_image"`
-	Fare            *int64  `json:"fare"`
+	BookingID       string     `json:"booking_id"`
+	PassengerID     *int64     `json:"passenger_id"`
+	TripStatus      string     `json:"trip_status"`
+	PickupLocation  string     `json:"pickup_location"`
+	PickupLat       float64    `json:"pickup_lat"`
+	PickupLong      float64    `json:"pickup_long"`
+	DropoffLocation string     `json:"dropoff_location"`
+	DropoffLat      float64    `json:"dropoff_lat"`
+	DropoffLong     float64    `json:"dropoff_long"`
+	DriverID        *int64     `json:"driver_id"`
+	DriverName      *string    `json:"driver_name"`
+	DriverMobile    *string    `json:"driver_mobile"`
+	CarID           *int64     `json:"car_id"`
+	CarType         *string    `json:"car_type"`
+	CarImage        *string    `json:"car_image"`
+	Fare            *int64     `json:"fare"`
+	SurgeMultiplier float64    `json:"surge_multiplier"`
+	CancelledBy     *string    `json:"cancelled_by"`
+	CancelReason    *string    `json:"cancel_reason"`
+	CancellationFee *int64     `json:"cancellation_fee"`
+	CancelledAt     *time.Time `json:"cancelled_at"`
+	ScheduledAt     *time.Time `json:"scheduled_at"`
+	AcceptedAt      *time.Time `json:"accepted_at"`
+	ArrivedAt       *time.Time `json:"arrived_at"`
+	StartedAt       *time.Time `json:"started_at"`
+	CompletedAt     *time.Time `json:"completed_at"`
+	// Stops is only filled in by endpoints returning a single trip.
+	Stops []TripStopResponse `json:"stops,omitempty"`
+}
+
+func newTripResponse(trip db.Trip) TripResponse {
+	return TripResponse{
+		BookingID:       trip.BookingID,
+		PassengerID:     helpers.NullInt64ToPtr(trip.PassengerID),
+		TripStatus:      trip.TripStatus,
+		PickupLocation:  trip.PickupLocation,
+		PickupLat:       trip.PickupLat,
+		PickupLong:      trip.PickupLong,
+		DropoffLocation: trip.DropoffLocation,
+		DropoffLat:      trip.DropoffLat,
+		DropoffLong:     trip.DropoffLong,
+		DriverID:        helpers.NullInt64ToPtr(trip.DriverID),
+		DriverName:      helpers.NullStringToPtr(trip.DriverName),
+		DriverMobile:    helpers.NullStringToPtr(trip.DriverMobile),
+		CarID:           helpers.NullInt64ToPtr(trip.CarID),
+		CarType:         helpers.NullStringToPtr(trip.CarType),
+		CarImage:        helpers.NullStringToPtr(trip.CarImage),
+		Fare:            helpers.NullInt64ToPtr(trip.Fare),
+		SurgeMultiplier: trip.SurgeMultiplier,
+		CancelledBy:     helpers.NullStringToPtr(trip.CancelledBy),
+		CancelReason:    helpers.NullStringToPtr(trip.CancelReason),
+		CancellationFee: helpers.NullInt64ToPtr(trip.CancellationFee),
+		CancelledAt:     helpers.NullTimeToPtr(trip.CancelledAt),
+		ScheduledAt:     helpers.NullTimeToPtr(trip.ScheduledAt),
+		AcceptedAt:      helpers.NullTimeToPtr(trip.AcceptedAt),
+		ArrivedAt:       helpers.NullTimeToPtr(trip.ArrivedAt),
+		StartedAt:       helpers.NullTimeToPtr(trip.StartedAt),
+		CompletedAt:     helpers.NullTimeToPtr(trip.CompletedAt),
+	}
 }
 
 func (server *Server) createTrip(ctx *gin.Context) {
@@ -55,25 +105,78 @@ func (server *Server) createTrip(ctx *gin.Context) {
 		return
 	}
 
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+
+	status := db.TripStatusRequested
+	var scheduledAt sql.NullTime
+	if req.ScheduledAt != nil {
+		// bidding opens openBefore the pickup, so anything sooner is an immediate ride
+		openBefore := server.scheduleSettings().openBefore
+		lead := time.Until(*req.ScheduledAt)
+		if lead < openBefore || lead > maxScheduleAhead {
+			ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+				Status: false,
+				Message: fmt.Sprintf("Scheduled trips must be booked between %d minutes and %d days ahead",
+					int(openBefore.Minutes()), int(maxScheduleAhead.Hours()/24))}))
+			return
+		}
+
+		status = db.TripStatusScheduled
+		scheduledAt = sql.NullTime{Time: req.ScheduledAt.UTC(), Valid: true}
+	}
+
+	// scheduled trips are booked at the standard rate since demand at pickup
+	// time is not known yet
+	surge := 1.0
+	if status == db.TripStatusRequested {
+		var err error
+		surge, err = server.surgeMultiplier(ctx, *req.PickupLat, *req.PickupLong)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+				Status:  false,
+				Message: err.Error()}))
+			return
+		}
+	}
+
 	arg := db.CreateTripParams{
-		BookingID:       req.BookingID,
-		TripStatus:      req.TripStatus,
+		TripStatus:      status,
 		PickupLocation:  req.PickupLocation,
-		PickupLat:       req.PickupLat,
-		PickupLong:      req.PickupLong,
+		PickupLat:       *req.PickupLat,
+		PickupLong:      *req.PickupLong,
 		DropoffLocation: req.DropoffLocation,
-		DropoffLat:      req.DropoffLat,
-		DropoffLong:     req.DropoffLong,
-		DriverID:        helpers.MakeNullInt64(req.DriverID),
-		DriverName:      helpers.MakeNullString(req.DriverName),
-		DriverMobile:    helpers.MakeNullString(req.DriverMobile),
-		CarID:           helpers.MakeNullInt64(req.CarID),
-		CarType:         helpers.MakeNullString(req.CarType),
-		CarImage:        helpers.MakeNullString(req.CarImage),
-		Fare:            helpers.MakeNullInt64(req.Fare),
+		DropoffLat:      *req.DropoffLat,
+		DropoffLong:     *req.DropoffLong,
+		PassengerID:     sql.NullInt64{Int64: authPayload.UserID, Valid: true},
+		SurgeMultiplier: surge,
+		ScheduledAt:     scheduledAt,
+	}
+
+	stops := make([]db.TripStopParams, 0, len(req.Stops))
+	for _, stop := range req.Stops {
+		stops = append(stops, db.TripStopParams{
+			Location: stop.Location,
+			Lat:      *stop.Lat,
+			Long:     *stop.Long,
+		})
 	}
 
-	trip, err := server.store.CreateTrip(ctx, arg)
+	var result db.CreateTripTxResult
+	var err error
+	for attempt := 0; attempt < maxBookingIDAttempts; attempt++ {
+		arg.BookingID, err = utils.RandomBookingID()
+		if err != nil {
+			break
+		}
+
+		result, err = server.store.CreateTripTx(ctx, db.CreateTripTxParams{
+			CreateTripParams: arg,
+			Stops:            stops,
+		})
+		if !isBookingIDConflict(err) {
+			break
+		}
+	}
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
@@ -82,22 +185,17 @@ func (server *Server) createTrip(ctx *gin.Context) {
 		return
 	}
 
-	response := TripResponse{
-		BookingID:       trip.BookingID,
-		TripStatus:      trip.TripStatus,
-		PickupLocation:  trip.PickupLocation,
-		PickupLat:       trip.PickupLat,
-		PickupLong:      trip.PickupLong,
-		DropoffLocation: trip.DropoffLocation,
-		DropoffLat:      trip.DropoffLat,
-		DropoffLong:     trip.DropoffLong,
-		DriverID:        helpers.NullInt64ToPtr(trip.DriverID),
-		DriverName:      helpers.NullStringToPtr(trip.DriverName),
-		DriverMobile:    helpers.NullStringToPtr(trip.DriverMobile),
-		CarID:           helpers.NullInt64ToPtr(trip.CarID),
-		CarType:         helpers.NullStringToPtr(trip.CarType),
-		CarImage:        helpers.NullStringToPtr(trip.CarImage),
-		Fare:            helpers.NullInt64ToPtr(trip.Fare),
+	trip := result.Trip
+	response := newTripResponse(trip)
+	response.Stops = newTripStopResponses(result.Stops)
+
+	if trip.TripStatus == db.TripStatusScheduled {
+		// the scheduler opens bidding shortly before pickup
+		ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Trip scheduled successfully",
+			Data:    response}))
+		return
 	}
 
 	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
@@ -105,14 +203,22 @@ func (server *Server) createTrip(ctx *gin.Context) {
 		Message: "Trip created successfully",
 		Data:    response}))
 
-	server.webSocketManager.Broadcast("trips", finalResponse(FinalResponse{
+	go server.dispatchTrip(context.Background(), trip, finalResponse(FinalResponse{
 		Status:  false,
 		Message: "Trip created successfully",
 		Data:    response}))
 }
 
+// isBookingIDConflict reports whether err is a duplicate booking_id insert.
+func isBookingIDConflict(err error) bool {
+	if pqErr, ok := err.(*pq.Error); ok {
+		return pqErr.Code.Name() == "unique_violation" && pqErr.Constraint == "trips_booking_id_key"
+	}
+	return false
+}
+
 type GetTripRequest struct {
-	BookingID string `uri:"booking_id" binding:"required"`
+	BookingID string `uri:"id" binding:"required"`
 }
 
 func (server *Server) getTrip(ctx *gin.Context) {
@@ -138,24 +244,26 @@ func (server *Server) getTrip(ctx *gin.Context) {
 		return
 	}
 
-	response := TripResponse{
-		BookingID:       trip.BookingID,
-		TripStatus:      trip.TripStatus,
-		PickupLocation:  trip.PickupLocation,
-		PickupLat:       trip.PickupLat,
-		PickupLong:      trip.PickupLong,
-		DropoffLocation: trip.DropoffLocation,
-		DropoffLat:      trip.DropoffLat,
-		DropoffLong:     trip.DropoffLong,
-		DriverID:        helpers.NullInt64ToPtr(trip.DriverID),
-		DriverName:      helpers.NullStringToPtr(trip.DriverName),
-		DriverMobile:    helpers.NullStringToPtr(trip.DriverMobile),
-		CarID:           helpers.NullInt64ToPtr(trip.CarID),
-		CarType:         helpers.NullStringToPtr(trip.CarType),
-		CarImage:        helpers.NullStringToPtr(trip.CarImage),
-		Fare:            helpers.NullInt64ToPtr(trip.Fare),
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+
+	if !canViewTrip(trip, authPayload) {
+		ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Trip not found"}))
+		return
+	}
+
+	stops, err := server.store.ListTripStops(ctx, trip.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
 	}
 
+	response := newTripResponse(trip)
+	response.Stops = newTripStopResponses(stops)
+
 	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
 		Status:  false,
 		Message: "Success",
@@ -178,12 +286,23 @@ func (server *Server) getAllTrips(ctx *gin.Context) {
 		return
 	}
 
-	arg := db.ListTripsParams{
-		Limit:  req.PerPage,
-		Offset: (req.PageNumber - 1) * req.PerPage,
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+
+	var trips []db.Trip
+	var err error
+	if authPayload.Role == token.RolePassenger {
+		trips, err = server.store.ListPassengerTrips(ctx, db.ListPassengerTripsParams{
+			PassengerID: sql.NullInt64{Int64: authPayload.UserID, Valid: true},
+			Limit:       req.PerPage,
+			Offset:      (req.PageNumber - 1) * req.PerPage,
+		})
+	} else {
+		trips, err = server.store.ListDriverTrips(ctx, db.ListDriverTripsParams{
+			DriverID: sql.NullInt64{Int64: authPayload.UserID, Valid: true},
+			Limit:    req.PerPage,
+			Offset:   (req.PageNumber - 1) * req.PerPage,
+		})
 	}
-
-	trips, err := server.store.ListTrips(ctx, arg)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
@@ -211,23 +330,7 @@ func (server *Server) getAllTrips(ctx *gin.Context) {
 
 	var responseTrips []TripResponse
 	for _, trip := range trips {
-		response := TripResponse{
-			BookingID:       trip.BookingID,
-			TripStatus:      trip.TripStatus,
-			PickupLocation:  trip.PickupLocation,
-			PickupLat:       trip.PickupLat,
-			PickupLong:      trip.PickupLong,
-			DropoffLocation: trip.DropoffLocation,
-			DropoffLat:      trip.DropoffLat,
-			DropoffLong:     trip.DropoffLong,
-			DriverID:        helpers.NullInt64ToPtr(trip.DriverID),
-			DriverName:      helpers.NullStringToPtr(trip.DriverName),
-			DriverMobile:    helpers.NullStringToPtr(trip.DriverMobile),
-			CarID:           helpers.NullInt64ToPtr(trip.CarID),
-			CarType:         helpers.NullStringToPtr(trip.CarType),
-			CarImage:        helpers.NullStringToPtr(trip.CarImage),
-			Fare:            helpers.NullInt64ToPtr(trip.Fare),
-		}
+		response := newTripResponse(trip)
 		responseTrips = append(responseTrips, response)
 	}
 
@@ -253,11 +356,30 @@ func (server *Server) updateTripStatus(ctx *gin.Context) {
 		return
 	}
 
-	trip, err := server.store.UpdateTripStatus(ctx, db.UpdateTripStatusParams{
-		BookingID:  req.BookingID,
-		TripStatus: req.TripStatus,
-	})
+	if !db.IsValidTripStatus(req.TripStatus) {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Invalid trip status"}))
+		return
+	}
+
+	// cancelling records who cancelled and why, which only the cancel endpoints do
+	if req.TripStatus == db.TripStatusCancelled {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Use the trip cancel endpoint to cancel a trip"}))
+		return
+	}
 
+	// only the scheduler expires trips nobody accepted
+	if req.TripStatus == db.TripStatusExpired {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Trips expire automatically"}))
+		return
+	}
+
+	trip, err := server.store.GetTripByBookingID(ctx, req.BookingID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
@@ -273,24 +395,74 @@ func (server *Server) updateTripStatus(ctx *gin.Context) {
 		return
 	}
 
-	finalTrip := TripResponse{
-		BookingID:       trip.BookingID,
-		TripStatus:      trip.TripStatus,
-		PickupLocation:  trip.PickupLocation,
-		PickupLat:       trip.PickupLat,
-		PickupLong:      trip.PickupLong,
-		DropoffLocation: trip.DropoffLocation,
-		DropoffLat:      trip.DropoffLat,
-		DropoffLong:     trip.DropoffLong,
-		DriverID:        helpers.NullInt64ToPtr(trip.DriverID),
-		DriverName:      helpers.NullStringToPtr(trip.DriverName),
-		DriverMobile:    helpers.NullStringToPtr(trip.DriverMobile),
-		CarID:           helpers.NullInt64ToPtr(trip.CarID),
-		CarType:         helpers.NullStringToPtr(trip.CarType),
-		CarImage:        helpers.NullStringToPtr(trip.CarImage),
-		Fare:            helpers.NullInt64ToPtr(trip.Fare),
+	if err := db.ValidateTripStatusTransition(trip.TripStatus, req.TripStatus); err != nil {
+		ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
 	}
 
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+	actor := server.driverActor(ctx, authPayload.UserID)
+
+	if req.TripStatus == db.TripStatusCompleted {
+		// completing also frees the driver, so it has to go through the store transaction
+		var result db.CompleteTripTxResult
+		result, err = server.store.CompleteTripTx(ctx, db.CompleteTripTxParams{
+			BookingID:      req.BookingID,
+			Actor:          actor,
+			CommissionRate: server.commissionRate(),
+		})
+		trip = result.Trip
+	} else {
+		var result db.UpdateTripStatusTxResult
+		result, err = server.store.UpdateTripStatusTx(ctx, db.UpdateTripStatusTxParams{
+			UpdateTripStatusParams: db.UpdateTripStatusParams{
+				TripStatus:    req.TripStatus,
+				BookingID:     req.BookingID,
+				CurrentStatus: trip.TripStatus,
+			},
+			Actor: actor,
+		})
+		trip = result.Trip
+	}
+
+	if err != nil {
+		if errors.Is(err, db.ErrTripNotAssigned) {
+			ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Trip not found",
+				Data:    nil}))
+			return
+		}
+
+		if errors.Is(err, db.ErrInvalidTripStatusTransition) {
+			ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+				Status:  false,
+				Message: err.Error()}))
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Trip status was changed by another request",
+				Data:    nil}))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	if trip.TripStatus == db.TripStatusCompleted {
+		server.captureTripPayment(ctx, trip)
+	}
+
+	finalTrip := newTripResponse(trip)
+
 	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
 		Status:  true,
 		Message: "Trip status updated successfully",
@@ -305,12 +477,7 @@ func (server *Server) updateTripStatus(ctx *gin.Context) {
 }
 
 type TripAcceptRequest struct {
-	BookingID    string  `json:"booking_id" binding:"required"`
-	TripStatus   string  `json:"trip_status" binding:"required"`
-	DriverID     *int64  `json:"driver_id" binding:"required"`
-	DriverName   *string `json:"driver_name" binding:"required"`
-	DriverMobile *string `json:"driver_mobile" binding:"required"`
-	Fare         *int64  `json:"fare" binding:"required"`
+	BookingID string `json:"booking_id" binding:"required"`
 }
 
 func (server *Server) tripAccept(ctx *gin.Context) {
@@ -322,20 +489,28 @@ func (server *Server) tripAccept(ctx *gin.Context) {
 		return
 	}
 
-	trip, err := server.store.TripAccept(ctx, db.TripAcceptParams{
-		BookingID:    req.BookingID,
-		TripStatus:   req.TripStatus,
-		DriverID:     helpers.MakeNullInt64(req.DriverID),
-		DriverName:   helpers.MakeNullString(req.DriverName),
-		DriverMobile: helpers.MakeNullString(req.DriverMobile),
-		Fare:         helpers.MakeNullInt64(req.Fare),
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+
+	result, err := server.store.AssignDriverTx(ctx, db.AssignDriverTxParams{
+		BookingID: req.BookingID,
+		DriverID:  authPayload.UserID,
+		Actor:     server.driverActor(ctx, authPayload.UserID),
 	})
 
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
 				Status:  false,
-				Message: "Trip not found",
+				Message: "Trip or driver not found",
+				Data:    nil}))
+			return
+		}
+
+		if errors.Is(err, db.ErrInvalidTripStatusTransition) || errors.Is(err, db.ErrDriverBusy) ||
+			errors.Is(err, db.ErrTripOpenForBids) || errors.Is(err, db.ErrNoFareRule) {
+			ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+				Status:  false,
+				Message: err.Error(),
 				Data:    nil}))
 			return
 		}
@@ -346,23 +521,8 @@ func (server *Server) tripAccept(ctx *gin.Context) {
 		return
 	}
 
-	finalTrip := TripResponse{
-		BookingID:       trip.BookingID,
-		TripStatus:      trip.TripStatus,
-		PickupLocation:  trip.PickupLocation,
-		PickupLat:       trip.PickupLat,
-		PickupLong:      trip.PickupLong,
-		DropoffLocation: trip.DropoffLocation,
-		DropoffLat:      trip.DropoffLat,
-		DropoffLong:     trip.DropoffLong,
-		DriverID:        helpers.NullInt64ToPtr(trip.DriverID),
-		DriverName:      helpers.NullStringToPtr(trip.DriverName),
-		DriverMobile:    helpers.NullStringToPtr(trip.DriverMobile),
-		CarID:           helpers.NullInt64ToPtr(trip.CarID),
-		CarType:         helpers.NullStringToPtr(trip.CarType),
-		CarImage:        helpers.NullStringToPtr(trip.CarImage),
-		Fare:            helpers.NullInt64ToPtr(trip.Fare),
-	}
+	trip := result.Trip
+	finalTrip := newTripResponse(trip)
 
 	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
 		Status:  true,
@@ -392,7 +552,7 @@ func (server *Server) tripWebSocket(ctx *gin.Context) {
 		return
 	}
 
-	_, err := server.tokenMaker.VerifyToken(tokenString)
+	payload, err := server.verifyToken(ctx, tokenString)
 	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, finalResponse(FinalResponse{
 			Status:  false,
@@ -400,14 +560,23 @@ func (server *Server) tripWebSocket(ctx *gin.Context) {
 		return
 	}
 
+	// trip requests and bid results are addressed to individual drivers
+	if payload.Role != token.RoleDriver {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Only drivers can listen for trips"}))
+		return
+	}
+	channel := driverChannel(payload.UserID)
+
 	conn, err := tripUpgrader.Upgrade(ctx.Writer, ctx.Request, nil)
 	if err != nil {
 		return
 	}
 
-	server.webSocketManager.AddClient("trips", conn)
+	server.webSocketManager.AddClient(channel, conn)
 	defer func() {
-		server.webSocketManager.RemoveClient("trips", conn)
+		server.webSocketManager.RemoveClient(channel, conn)
 		conn.Close()
 	}()
 
@@ -435,7 +604,7 @@ func (server *Server) tripStatusUpdateWebSocket(ctx *gin.Context) {
 		return
 	}
 
-	_, err := server.tokenMaker.VerifyToken(tokenString)
+	payload, err := server.verifyToken(ctx, tokenString)
 	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, finalResponse(FinalResponse{
 			Status:  false,
@@ -444,6 +613,30 @@ func (server *Server) tripStatusUpdateWebSocket(ctx *gin.Context) {
 		return
 	}
 
+	trip, err := server.store.GetTripByBookingID(ctx, bookingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Trip not found",
+			}))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error(),
+		}))
+		return
+	}
+
+	if !isTripParticipant(trip, payload) {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Trip not found",
+		}))
+		return
+	}
+
 	conn, err := tripStatusUpgrader.Upgrade(ctx.Writer, ctx.Request, nil)
 	if err != nil {
 		return
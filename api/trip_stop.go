@@ -0,0 +1,177 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/fare"
+	"github.com/emonoid/toribook.git/helpers"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/gin-gonic/gin"
+)
+
+type TripStopRequest struct {
+	Location string   `json:"location" binding:"required"`
+	Lat      *float64 `json:"lat" binding:"required,min=-90,max=90"`
+	Long     *float64 `json:"long" binding:"required,min=-180,max=180"`
+}
+
+type TripStopResponse struct {
+	StopOrder  int32      `json:"stop_order"`
+	Location   string     `json:"location"`
+	Lat        float64    `json:"lat"`
+	Long       float64    `json:"long"`
+	Status     string     `json:"status"`
+	ArrivedAt  *time.Time `json:"arrived_at"`
+	DepartedAt *time.Time `json:"departed_at"`
+}
+
+func newTripStopResponse(stop db.TripStop) TripStopResponse {
+	return TripStopResponse{
+		StopOrder:  stop.StopOrder,
+		Location:   stop.Location,
+		Lat:        stop.Lat,
+		Long:       stop.Long,
+		Status:     stop.Status,
+		ArrivedAt:  helpers.NullTimeToPtr(stop.ArrivedAt),
+		DepartedAt: helpers.NullTimeToPtr(stop.DepartedAt),
+	}
+}
+
+func newTripStopResponses(stops []db.TripStop) []TripStopResponse {
+	responses := make([]TripStopResponse, 0, len(stops))
+	for _, stop := range stops {
+		responses = append(responses, newTripStopResponse(stop))
+	}
+	return responses
+}
+
+// routePoints lists the pickup, every stop and the dropoff in driving order.
+func routePoints(pickupLat, pickupLong float64, stops []TripStopRequest, dropoffLat, dropoffLong float64) []fare.Point {
+	points := []fare.Point{{Lat: pickupLat, Long: pickupLong}}
+	for _, stop := range stops {
+		points = append(points, fare.Point{Lat: *stop.Lat, Long: *stop.Long})
+	}
+	return append(points, fare.Point{Lat: dropoffLat, Long: dropoffLong})
+}
+
+type UpdateTripStopStatusRequest struct {
+	BookingID string `json:"booking_id" binding:"required"`
+	StopOrder int32  `json:"stop_order" binding:"required,min=1"`
+	Status    string `json:"status" binding:"required,oneof=arrived departed"`
+}
+
+// updateTripStopStatus lets the assigned driver mark a stop of an in progress
+// trip arrived or departed. Stops are visited in order, so arriving at a stop
+// needs every earlier stop departed.
+func (server *Server) updateTripStopStatus(ctx *gin.Context) {
+	var req UpdateTripStopStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	trip, err := server.store.GetTripByBookingID(ctx, req.BookingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Trip not found"}))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+
+	if !trip.DriverID.Valid || trip.DriverID.Int64 != authPayload.UserID {
+		ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Trip not found"}))
+		return
+	}
+
+	if trip.TripStatus != db.TripStatusInProgress {
+		ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Stops can only be updated while the trip is in progress"}))
+		return
+	}
+
+	stops, err := server.store.ListTripStops(ctx, trip.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	var stop *db.TripStop
+	for i := range stops {
+		if stops[i].StopOrder == req.StopOrder {
+			stop = &stops[i]
+			break
+		}
+
+		if req.Status == db.TripStopStatusArrived && stops[i].Status != db.TripStopStatusDeparted {
+			ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Depart the previous stops first"}))
+			return
+		}
+	}
+
+	if stop == nil {
+		ctx.JSON(http.StatusNotFound, finalResponse(FinalResponse{
+			Status:  false,
+			Message: "Stop not found"}))
+		return
+	}
+
+	if err := db.ValidateTripStopTransition(stop.Status, req.Status); err != nil {
+		ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	var updated db.TripStop
+	if req.Status == db.TripStopStatusArrived {
+		updated, err = server.store.ArriveTripStop(ctx, stop.ID)
+	} else {
+		updated, err = server.store.DepartTripStop(ctx, stop.ID)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, finalResponse(FinalResponse{
+				Status:  false,
+				Message: "Stop status was changed by another request"}))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	response := newTripStopResponse(updated)
+
+	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Stop status updated successfully",
+		Data:    response,
+	}))
+
+	server.webSocketManager.Broadcast("trip_status: "+trip.BookingID, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Trip stop updated",
+		Data:    response,
+	}))
+}
@@ -0,0 +1,308 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/emonoid/toribook.git/db/mock"
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/emonoid/toribook.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomTripStops(tripID int64, n int) []db.TripStop {
+	stops := make([]db.TripStop, 0, n)
+	for i := 0; i < n; i++ {
+		stops = append(stops, db.TripStop{
+			ID:        utils.RandomInt(1, 1000),
+			TripID:    tripID,
+			StopOrder: int32(i + 1),
+			Location:  utils.RandomString(8),
+			Lat:       23.78,
+			Long:      90.41,
+			Status:    db.TripStopStatusPending,
+			CreatedAt: time.Now(),
+		})
+	}
+	return stops
+}
+
+func withStopStatus(stop db.TripStop, status string) db.TripStop {
+	stop.Status = status
+	switch status {
+	case db.TripStopStatusArrived:
+		stop.ArrivedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	case db.TripStopStatusDeparted:
+		stop.ArrivedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		stop.DepartedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+	return stop
+}
+
+func TestUpdateTripStopStatusAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	driver, _ := randomDriver(t)
+
+	trip := randomTrip(passenger.ID, db.TripStatusInProgress)
+	trip.DriverID = sql.NullInt64{Int64: driver.ID, Valid: true}
+	stops := randomTripStops(trip.ID, 2)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Arrive",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"stop_order": 1,
+				"status":     db.TripStopStatusArrived,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					ListTripStops(gomock.Any(), gomock.Eq(trip.ID)).
+					Times(1).
+					Return(stops, nil)
+				store.EXPECT().
+					ArriveTripStop(gomock.Any(), gomock.Eq(stops[0].ID)).
+					Times(1).
+					Return(withStopStatus(stops[0], db.TripStopStatusArrived), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got TripStopResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.Equal(t, db.TripStopStatusArrived, got.Status)
+				require.NotNil(t, got.ArrivedAt)
+				require.Nil(t, got.DepartedAt)
+			},
+		},
+		{
+			name: "Depart",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"stop_order": 1,
+				"status":     db.TripStopStatusDeparted,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					ListTripStops(gomock.Any(), gomock.Eq(trip.ID)).
+					Times(1).
+					Return([]db.TripStop{withStopStatus(stops[0], db.TripStopStatusArrived), stops[1]}, nil)
+				store.EXPECT().
+					DepartTripStop(gomock.Any(), gomock.Eq(stops[0].ID)).
+					Times(1).
+					Return(withStopStatus(stops[0], db.TripStopStatusDeparted), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got TripStopResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.Equal(t, db.TripStopStatusDeparted, got.Status)
+				require.NotNil(t, got.DepartedAt)
+			},
+		},
+		{
+			name: "ArriveOutOfOrder",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"stop_order": 2,
+				"status":     db.TripStopStatusArrived,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					ListTripStops(gomock.Any(), gomock.Eq(trip.ID)).
+					Times(1).
+					Return([]db.TripStop{withStopStatus(stops[0], db.TripStopStatusArrived), stops[1]}, nil)
+				store.EXPECT().
+					ArriveTripStop(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "DepartBeforeArriving",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"stop_order": 1,
+				"status":     db.TripStopStatusDeparted,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					ListTripStops(gomock.Any(), gomock.Eq(trip.ID)).
+					Times(1).
+					Return(stops, nil)
+				store.EXPECT().
+					DepartTripStop(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "ConcurrentUpdate",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"stop_order": 1,
+				"status":     db.TripStopStatusArrived,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					ListTripStops(gomock.Any(), gomock.Eq(trip.ID)).
+					Times(1).
+					Return(stops, nil)
+				store.EXPECT().
+					ArriveTripStop(gomock.Any(), gomock.Eq(stops[0].ID)).
+					Times(1).
+					Return(db.TripStop{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "StopNotFound",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"stop_order": 3,
+				"status":     db.TripStopStatusDeparted,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					ListTripStops(gomock.Any(), gomock.Eq(trip.ID)).
+					Times(1).
+					Return(stops, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "NotAssignedDriver",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"stop_order": 1,
+				"status":     db.TripStopStatusArrived,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				other := trip
+				other.DriverID = sql.NullInt64{Int64: driver.ID + 1, Valid: true}
+
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(other, nil)
+				store.EXPECT().
+					ListTripStops(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "TripNotInProgress",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"stop_order": 1,
+				"status":     db.TripStopStatusArrived,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				accepted := trip
+				accepted.TripStatus = db.TripStatusAccepted
+
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(accepted, nil)
+				store.EXPECT().
+					ListTripStops(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidStatus",
+			body: gin.H{
+				"booking_id": trip.BookingID,
+				"stop_order": 1,
+				"status":     db.TripStopStatusPending,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/v1/trip/stop-status"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
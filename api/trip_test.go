@@ -0,0 +1,1197 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/emonoid/toribook.git/db/mock"
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/emonoid/toribook.git/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func randomTrip(passengerID int64, status string) db.Trip {
+	return db.Trip{
+		ID:              utils.RandomInt(1, 1000),
+		BookingID:       "TB-" + strings.ToUpper(utils.RandomString(6)),
+		TripStatus:      status,
+		PickupLocation:  "Banani",
+		PickupLat:       23.7937,
+		PickupLong:      90.4066,
+		DropoffLocation: "Motijheel",
+		DropoffLat:      23.7330,
+		DropoffLong:     90.4172,
+		PassengerID:     sql.NullInt64{Int64: passengerID, Valid: true},
+		CreatedAt:       time.Now(),
+		SurgeMultiplier: 1,
+	}
+}
+
+func requireBodyMatchTrip(t *testing.T, recorder *httptest.ResponseRecorder, trip db.Trip) {
+	var gotTrip TripResponse
+	requireBodyData(t, recorder.Body, &gotTrip)
+
+	require.Equal(t, newTripResponse(trip), gotTrip)
+}
+
+func createTripBody() gin.H {
+	return gin.H{
+		"pickup_location":  "Banani",
+		"pickup_lat":       23.7937,
+		"pickup_long":      90.4066,
+		"dropoff_location": "Motijheel",
+		"dropoff_lat":      23.7330,
+		"dropoff_long":     90.4172,
+	}
+}
+
+func TestCreateTripAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	driver, _ := randomDriver(t)
+	trip := randomTrip(passenger.ID, db.TripStatusRequested)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: createTripBody(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CountOpenTripsInArea(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+				store.EXPECT().
+					CreateTripTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateTripTxParams) (db.CreateTripTxResult, error) {
+						require.Regexp(t, `^TB-[2-9A-Z]{6}$`, arg.BookingID)
+						require.Equal(t, db.TripStatusRequested, arg.TripStatus)
+						require.Equal(t, passenger.ID, arg.PassengerID.Int64)
+						require.Equal(t, 23.7937, arg.PickupLat)
+						require.Equal(t, 90.4172, arg.DropoffLong)
+						require.Equal(t, float64(1), arg.SurgeMultiplier)
+						return db.CreateTripTxResult{Trip: trip}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchTrip(t, recorder, trip)
+			},
+		},
+		{
+			name: "ClientPriceAndDriverIgnored",
+			body: func() gin.H {
+				body := createTripBody()
+				body["fare"] = 1
+				body["driver_id"] = driver.ID
+				body["driver_name"] = driver.FullName
+				body["car_type"] = "sedan"
+				return body
+			}(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CountOpenTripsInArea(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+				store.EXPECT().
+					CreateTripTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateTripTxParams) (db.CreateTripTxResult, error) {
+						// only the fare rule or an accepted bid price a trip
+						require.False(t, arg.Fare.Valid)
+						require.False(t, arg.DriverID.Valid)
+						require.False(t, arg.DriverName.Valid)
+						require.False(t, arg.CarType.Valid)
+						return db.CreateTripTxResult{Trip: trip}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "RetryOnBookingIDConflict",
+			body: createTripBody(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CountOpenTripsInArea(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+				gomock.InOrder(
+					store.EXPECT().
+						CreateTripTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.CreateTripTxResult{}, &pq.Error{Code: "23505", Constraint: "trips_booking_id_key"}),
+					store.EXPECT().
+						CreateTripTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.CreateTripTxResult{Trip: trip}, nil),
+				)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchTrip(t, recorder, trip)
+			},
+		},
+		{
+			name: "Scheduled",
+			body: func() gin.H {
+				body := createTripBody()
+				body["scheduled_at"] = time.Now().Add(2 * time.Hour)
+				return body
+			}(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CountOpenTripsInArea(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CreateTripTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateTripTxParams) (db.CreateTripTxResult, error) {
+						require.Equal(t, db.TripStatusScheduled, arg.TripStatus)
+						require.True(t, arg.ScheduledAt.Valid)
+						require.WithinDuration(t, time.Now().Add(2*time.Hour), arg.ScheduledAt.Time, time.Minute)
+						require.Equal(t, float64(1), arg.SurgeMultiplier)
+
+						scheduled := trip
+						scheduled.TripStatus = arg.TripStatus
+						scheduled.ScheduledAt = arg.ScheduledAt
+						return db.CreateTripTxResult{Trip: scheduled}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got TripResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.Equal(t, db.TripStatusScheduled, got.TripStatus)
+				require.NotNil(t, got.ScheduledAt)
+			},
+		},
+		{
+			name: "WithStops",
+			body: func() gin.H {
+				body := createTripBody()
+				body["stops"] = []gin.H{
+					{"location": "Gulshan 1", "lat": 23.7806, "long": 90.4167},
+					{"location": "Tejgaon", "lat": 23.7639, "long": 90.3889},
+				}
+				return body
+			}(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				stops := randomTripStops(trip.ID, 2)
+
+				store.EXPECT().
+					CountOpenTripsInArea(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+				store.EXPECT().
+					CreateTripTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateTripTxParams) (db.CreateTripTxResult, error) {
+						require.Equal(t, []db.TripStopParams{
+							{Location: "Gulshan 1", Lat: 23.7806, Long: 90.4167},
+							{Location: "Tejgaon", Lat: 23.7639, Long: 90.3889},
+						}, arg.Stops)
+						return db.CreateTripTxResult{Trip: trip, Stops: stops}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got TripResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.Len(t, got.Stops, 2)
+				require.Equal(t, int32(1), got.Stops[0].StopOrder)
+				require.Equal(t, db.TripStopStatusPending, got.Stops[1].Status)
+			},
+		},
+		{
+			name: "StopMissingCoordinates",
+			body: func() gin.H {
+				body := createTripBody()
+				body["stops"] = []gin.H{{"location": "Gulshan 1"}}
+				return body
+			}(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateTripTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ScheduledTooSoon",
+			body: func() gin.H {
+				body := createTripBody()
+				body["scheduled_at"] = time.Now().Add(5 * time.Minute)
+				return body
+			}(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateTripTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ScheduledTooFarAhead",
+			body: func() gin.H {
+				body := createTripBody()
+				body["scheduled_at"] = time.Now().Add(8 * 24 * time.Hour)
+				return body
+			}(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateTripTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotPassenger",
+			body: createTripBody(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateTripTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "MissingPickup",
+			body: gin.H{
+				"dropoff_location": "Motijheel",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateTripTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NonNumericCoordinates",
+			body: func() gin.H {
+				body := createTripBody()
+				body["pickup_lat"] = "abc"
+				return body
+			}(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateTripTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "DropoffLongitudeOutOfRange",
+			body: func() gin.H {
+				body := createTripBody()
+				body["dropoff_long"] = 190.5
+				return body
+			}(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateTripTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			body: createTripBody(),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateTripTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/v1/trip/create"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetTripAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	otherPassenger, _ := randomPassenger(t)
+	otherPassenger.ID = passenger.ID + 1
+	driver, _ := randomDriver(t)
+	otherDriver, _ := randomDriver(t)
+	trip := randomTrip(passenger.ID, db.TripStatusRequested)
+	stops := randomTripStops(trip.ID, 2)
+
+	assignedTrip := trip
+	assignedTrip.TripStatus = db.TripStatusAccepted
+	assignedTrip.DriverID = sql.NullInt64{Int64: driver.ID, Valid: true}
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OwnTrip",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					ListTripStops(gomock.Any(), gomock.Eq(trip.ID)).
+					Times(1).
+					Return(stops, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				want := newTripResponse(trip)
+				want.Stops = newTripStopResponses(stops)
+
+				var got TripResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.Equal(t, want, got)
+			},
+		},
+		{
+			name: "OtherPassengersTrip",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, otherPassenger.Email, token.RolePassenger, otherPassenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "DriverOpenTrip",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					ListTripStops(gomock.Any(), gomock.Eq(trip.ID)).
+					Times(1).
+					Return([]db.TripStop{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchTrip(t, recorder, trip)
+			},
+		},
+		{
+			name: "AssignedDriver",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(assignedTrip, nil)
+				store.EXPECT().
+					ListTripStops(gomock.Any(), gomock.Eq(trip.ID)).
+					Times(1).
+					Return([]db.TripStop{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchTrip(t, recorder, assignedTrip)
+			},
+		},
+		{
+			name: "OtherDriversTrip",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, otherDriver.Mobile, token.RoleDriver, otherDriver.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(assignedTrip, nil)
+				store.EXPECT().
+					ListTripStops(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(db.Trip{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/v1/trip/%s", trip.BookingID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetAllTripsAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	driver, _ := randomDriver(t)
+
+	n := 3
+	trips := make([]db.Trip, n)
+	for i := 0; i < n; i++ {
+		trips[i] = randomTrip(passenger.ID, db.TripStatusRequested)
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "Passenger",
+			query: "page_number=1&per_page=3",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPassengerTrips(gomock.Any(), gomock.Eq(db.ListPassengerTripsParams{
+						PassengerID: sql.NullInt64{Int64: passenger.ID, Valid: true},
+						Limit:       3,
+						Offset:      0,
+					})).
+					Times(1).
+					Return(trips, nil)
+				store.EXPECT().
+					ListDriverTrips(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotTrips []TripResponse
+				requireBodyData(t, recorder.Body, &gotTrips)
+				require.Len(t, gotTrips, n)
+			},
+		},
+		{
+			name:  "Driver",
+			query: "page_number=2&per_page=3",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// drivers only see the trips assigned to them
+				store.EXPECT().
+					ListDriverTrips(gomock.Any(), gomock.Eq(db.ListDriverTripsParams{
+						DriverID: sql.NullInt64{Int64: driver.ID, Valid: true},
+						Limit:    3,
+						Offset:   3,
+					})).
+					Times(1).
+					Return(trips, nil)
+				store.EXPECT().
+					ListTrips(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidPageNumber",
+			query: "page_number=0&per_page=3",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, passenger.Email, token.RolePassenger, passenger.ID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPassengerTrips(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := "/api/v1/trip/all?" + tc.query
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdateTripStatusAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	driver, _ := randomDriver(t)
+	trip := randomTrip(passenger.ID, db.TripStatusAccepted)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"booking_id":  trip.BookingID,
+				"trip_status": db.TripStatusDriverArriving,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				updatedTrip := trip
+				updatedTrip.TripStatus = db.TripStatusDriverArriving
+
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					UpdateTripStatusTx(gomock.Any(), gomock.Eq(db.UpdateTripStatusTxParams{
+						UpdateTripStatusParams: db.UpdateTripStatusParams{
+							TripStatus:    db.TripStatusDriverArriving,
+							BookingID:     trip.BookingID,
+							CurrentStatus: db.TripStatusAccepted,
+						},
+						Actor: unlocatedDriverActor(driver.ID),
+					})).
+					Times(1).
+					Return(db.UpdateTripStatusTxResult{Trip: updatedTrip}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NotAssignedDriver",
+			body: gin.H{
+				"booking_id":  trip.BookingID,
+				"trip_status": db.TripStatusDriverArriving,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					UpdateTripStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateTripStatusTxResult{}, db.ErrTripNotAssigned)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Arrived",
+			body: gin.H{
+				"booking_id":  trip.BookingID,
+				"trip_status": db.TripStatusDriverArrived,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arrivingTrip := trip
+				arrivingTrip.TripStatus = db.TripStatusDriverArriving
+				arrivedTrip := trip
+				arrivedTrip.TripStatus = db.TripStatusDriverArrived
+
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(arrivingTrip, nil)
+				store.EXPECT().
+					UpdateTripStatusTx(gomock.Any(), gomock.Eq(db.UpdateTripStatusTxParams{
+						UpdateTripStatusParams: db.UpdateTripStatusParams{
+							TripStatus:    db.TripStatusDriverArrived,
+							BookingID:     trip.BookingID,
+							CurrentStatus: db.TripStatusDriverArriving,
+						},
+						Actor: unlocatedDriverActor(driver.ID),
+					})).
+					Times(1).
+					Return(db.UpdateTripStatusTxResult{Trip: arrivedTrip}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "StartBeforeArrival",
+			body: gin.H{
+				"booking_id":  trip.BookingID,
+				"trip_status": db.TripStatusInProgress,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arrivingTrip := trip
+				arrivingTrip.TripStatus = db.TripStatusDriverArriving
+
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(arrivingTrip, nil)
+				store.EXPECT().
+					UpdateTripStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "CompleteNotAssignedDriver",
+			body: gin.H{
+				"booking_id":  trip.BookingID,
+				"trip_status": db.TripStatusCompleted,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				inProgressTrip := trip
+				inProgressTrip.TripStatus = db.TripStatusInProgress
+
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(inProgressTrip, nil)
+				store.EXPECT().
+					CompleteTripTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CompleteTripTxResult{}, db.ErrTripNotAssigned)
+				// nothing is charged for a trip the driver does not own
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Complete",
+			body: gin.H{
+				"booking_id":  trip.BookingID,
+				"trip_status": db.TripStatusCompleted,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				inProgressTrip := trip
+				inProgressTrip.TripStatus = db.TripStatusInProgress
+				completedTrip := trip
+				completedTrip.TripStatus = db.TripStatusCompleted
+
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(inProgressTrip, nil)
+				store.EXPECT().
+					CompleteTripTx(gomock.Any(), gomock.Eq(db.CompleteTripTxParams{
+						BookingID:      trip.BookingID,
+						Actor:          unlocatedDriverActor(driver.ID),
+						CommissionRate: defaultCommissionRate,
+					})).
+					Times(1).
+					Return(db.CompleteTripTxResult{Trip: completedTrip}, nil)
+				store.EXPECT().
+					GetTripPaymentIntent(gomock.Any(), gomock.Eq(sql.NullInt64{Int64: trip.ID, Valid: true})).
+					Times(1).
+					Return(db.PaymentIntent{}, sql.ErrNoRows)
+				store.EXPECT().
+					UpdateTripStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "IllegalTransition",
+			body: gin.H{
+				"booking_id":  trip.BookingID,
+				"trip_status": db.TripStatusRequested,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					UpdateTripStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "ConcurrentUpdate",
+			body: gin.H{
+				"booking_id":  trip.BookingID,
+				"trip_status": db.TripStatusDriverArriving,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(trip, nil)
+				store.EXPECT().
+					UpdateTripStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateTripStatusTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownStatus",
+			body: gin.H{
+				"booking_id":  trip.BookingID,
+				"trip_status": "searching",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "CancelNeedsCancelEndpoint",
+			body: gin.H{
+				"booking_id":  trip.BookingID,
+				"trip_status": db.TripStatusCancelled,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredIsAutomatic",
+			body: gin.H{
+				"booking_id":  trip.BookingID,
+				"trip_status": db.TripStatusExpired,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			body: gin.H{
+				"booking_id":  trip.BookingID,
+				"trip_status": db.TripStatusDriverArriving,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+					Times(1).
+					Return(db.Trip{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/v1/trip/update-status"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestTripAcceptAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	driver, _ := randomDriver(t)
+	trip := randomTrip(passenger.ID, db.TripStatusRequested)
+
+	body := gin.H{
+		"booking_id": trip.BookingID,
+		// the fare comes from the trip, never from the driver
+		"fare": 1,
+	}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				acceptedTrip := trip
+				acceptedTrip.TripStatus = db.TripStatusAccepted
+				acceptedTrip.DriverID = sql.NullInt64{Int64: driver.ID, Valid: true}
+				acceptedTrip.Fare = sql.NullInt64{Int64: 350, Valid: true}
+
+				store.EXPECT().
+					AssignDriverTx(gomock.Any(), gomock.Eq(db.AssignDriverTxParams{
+						BookingID: trip.BookingID,
+						DriverID:  driver.ID,
+						Actor:     unlocatedDriverActor(driver.ID),
+					})).
+					Times(1).
+					Return(db.AssignDriverTxResult{Trip: acceptedTrip, Driver: driver}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got TripResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.Equal(t, int64(350), *got.Fare)
+			},
+		},
+		{
+			name: "OpenForBids",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AssignDriverTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AssignDriverTxResult{}, db.ErrTripOpenForBids)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "NoFareRule",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AssignDriverTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AssignDriverTxResult{}, db.ErrNoFareRule)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "DriverBusy",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AssignDriverTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AssignDriverTxResult{}, db.ErrDriverBusy)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyAccepted",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AssignDriverTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AssignDriverTxResult{}, db.ValidateTripStatusTransition(db.TripStatusAccepted, db.TripStatusAccepted))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AssignDriverTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AssignDriverTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+
+			url := "/api/v1/trip/accept"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestTripStatusUpdateWebSocket(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	otherPassenger, _ := randomPassenger(t)
+	driver, _ := randomDriver(t)
+	otherDriver, _ := randomDriver(t)
+
+	trip := randomTrip(passenger.ID, db.TripStatusAccepted)
+	trip.DriverID = sql.NullInt64{Int64: driver.ID, Valid: true}
+
+	testCases := []struct {
+		name     string
+		username string
+		role     string
+		userID   int64
+		wantCode int
+	}{
+		{
+			name:     "Passenger",
+			username: passenger.Email,
+			role:     token.RolePassenger,
+			userID:   passenger.ID,
+			wantCode: http.StatusSwitchingProtocols,
+		},
+		{
+			name:     "AssignedDriver",
+			username: driver.Mobile,
+			role:     token.RoleDriver,
+			userID:   driver.ID,
+			wantCode: http.StatusSwitchingProtocols,
+		},
+		{
+			name:     "OtherPassenger",
+			username: otherPassenger.Email,
+			role:     token.RolePassenger,
+			userID:   otherPassenger.ID,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "OtherDriver",
+			username: otherDriver.Mobile,
+			role:     token.RoleDriver,
+			userID:   otherDriver.ID,
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetTripByBookingID(gomock.Any(), gomock.Eq(trip.BookingID)).
+				Times(1).
+				Return(trip, nil)
+
+			server := newTestServer(t, store)
+			httpServer := httptest.NewServer(server.router)
+			defer httpServer.Close()
+
+			accessToken, _, err := server.tokenMaker.CreateToken(tc.username, tc.role, tc.userID, token.TokenTypeAccess, uuid.Nil, time.Minute)
+			require.NoError(t, err)
+
+			wsURL := fmt.Sprintf("%s/api/v1/ws/trip/listen-update-status?token=%s&booking_id=%s",
+				strings.Replace(httpServer.URL, "http", "ws", 1), accessToken, trip.BookingID)
+			conn, rsp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+			if conn != nil {
+				conn.Close()
+			}
+			if tc.wantCode != http.StatusSwitchingProtocols {
+				require.ErrorIs(t, err, websocket.ErrBadHandshake)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.wantCode, rsp.StatusCode)
+		})
+	}
+}
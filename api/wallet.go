@@ -0,0 +1,162 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/helpers"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultCommissionRate is the share of each fare the platform keeps when
+// the config does not set a valid rate.
+const defaultCommissionRate = 0.15
+
+// commissionRate reads the platform commission from the config and falls
+// back to the default when it is unset or not a fraction of the fare.
+func (server *Server) commissionRate() float64 {
+	rate := server.config.CommissionRate
+	if rate <= 0 || rate >= 1 {
+		return defaultCommissionRate
+	}
+	return rate
+}
+
+type WalletResponse struct {
+	OwnerType string `json:"owner_type"`
+	Balance   int64  `json:"balance"`
+}
+
+type WalletStatementRequest struct {
+	PageNumber int32 `form:"page_number" binding:"required,min=1"`
+	PerPage    int32 `form:"per_page" binding:"required,min=1,max=50"`
+}
+
+type WalletPostingResponse struct {
+	ID           int64     `json:"id"`
+	Kind         string    `json:"kind"`
+	BookingID    *string   `json:"booking_id"`
+	Description  string    `json:"description"`
+	Amount       int64     `json:"amount"`
+	BalanceAfter int64     `json:"balance_after"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type WalletStatementResponse struct {
+	Balance    int64                   `json:"balance"`
+	TotalCount int64                   `json:"total_count"`
+	Postings   []WalletPostingResponse `json:"postings"`
+}
+
+// walletOwnerType maps the role in the token to the owner type of its wallet.
+func walletOwnerType(authPayload *token.Payload) string {
+	if authPayload.Role == token.RoleDriver {
+		return db.AccountOwnerDriver
+	}
+	return db.AccountOwnerPassenger
+}
+
+func (server *Server) getWallet(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+	ownerType := walletOwnerType(authPayload)
+
+	account, err := server.store.GetAccountByOwner(ctx, db.GetAccountByOwnerParams{
+		OwnerType: ownerType,
+		OwnerID:   authPayload.UserID,
+	})
+	// wallets are opened by the first posting, until then the balance is zero
+	if err != nil && err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Wallet retrieved successfully",
+		Data: WalletResponse{
+			OwnerType: ownerType,
+			Balance:   account.Balance,
+		},
+	}))
+}
+
+// getWalletStatement lists the postings of the wallet in the token, newest
+// first, with the balance left after each of them.
+func (server *Server) getWalletStatement(ctx *gin.Context) {
+	var req WalletStatementRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadkey).(*token.Payload)
+
+	account, err := server.store.GetAccountByOwner(ctx, db.GetAccountByOwnerParams{
+		OwnerType: walletOwnerType(authPayload),
+		OwnerID:   authPayload.UserID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+				Status:  true,
+				Message: "Wallet statement retrieved successfully",
+				Data:    WalletStatementResponse{Postings: []WalletPostingResponse{}},
+			}))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	postings, err := server.store.ListAccountPostings(ctx, db.ListAccountPostingsParams{
+		AccountID: account.ID,
+		Limit:     req.PerPage,
+		Offset:    (req.PageNumber - 1) * req.PerPage,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	total, err := server.store.CountAccountPostings(ctx, account.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, finalResponse(FinalResponse{
+			Status:  false,
+			Message: err.Error()}))
+		return
+	}
+
+	response := WalletStatementResponse{
+		Balance:    account.Balance,
+		TotalCount: total,
+		Postings:   make([]WalletPostingResponse, len(postings)),
+	}
+	for i, posting := range postings {
+		response.Postings[i] = WalletPostingResponse{
+			ID:           posting.ID,
+			Kind:         posting.Kind,
+			BookingID:    helpers.NullStringToPtr(posting.BookingID),
+			Description:  posting.Description,
+			Amount:       posting.Amount,
+			BalanceAfter: posting.BalanceAfter,
+			CreatedAt:    posting.CreatedAt,
+		}
+	}
+
+	ctx.JSON(http.StatusOK, finalResponse(FinalResponse{
+		Status:  true,
+		Message: "Wallet statement retrieved successfully",
+		Data:    response,
+	}))
+}
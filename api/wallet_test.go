@@ -0,0 +1,329 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/emonoid/toribook.git/db/mock"
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/token"
+	"github.com/emonoid/toribook.git/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommissionRate(t *testing.T) {
+	testCases := []struct {
+		name string
+		rate float64
+		want float64
+	}{
+		{name: "Configured", rate: 0.2, want: 0.2},
+		{name: "Unset", rate: 0, want: defaultCommissionRate},
+		{name: "Negative", rate: -0.1, want: defaultCommissionRate},
+		{name: "WholeFare", rate: 1, want: defaultCommissionRate},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := &Server{config: utils.Config{CommissionRate: tc.rate}}
+			require.Equal(t, tc.want, server.commissionRate())
+		})
+	}
+}
+
+func TestGetWalletAPI(t *testing.T) {
+	passenger, _ := randomPassenger(t)
+	driver, _ := randomDriver(t)
+
+	testCases := []struct {
+		name          string
+		url           string
+		role          string
+		userID        int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Passenger",
+			url:    "/api/v1/passenger/wallet",
+			role:   token.RolePassenger,
+			userID: passenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountByOwner(gomock.Any(), gomock.Eq(db.GetAccountByOwnerParams{
+						OwnerType: db.AccountOwnerPassenger,
+						OwnerID:   passenger.ID,
+					})).
+					Times(1).
+					Return(db.Account{ID: 1, OwnerType: db.AccountOwnerPassenger, OwnerID: passenger.ID, Balance: -350}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got WalletResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.Equal(t, db.AccountOwnerPassenger, got.OwnerType)
+				require.Equal(t, int64(-350), got.Balance)
+			},
+		},
+		{
+			name:   "Driver",
+			url:    "/api/v1/driver/wallet",
+			role:   token.RoleDriver,
+			userID: driver.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountByOwner(gomock.Any(), gomock.Eq(db.GetAccountByOwnerParams{
+						OwnerType: db.AccountOwnerDriver,
+						OwnerID:   driver.ID,
+					})).
+					Times(1).
+					Return(db.Account{ID: 2, OwnerType: db.AccountOwnerDriver, OwnerID: driver.ID, Balance: 298}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got WalletResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.Equal(t, db.AccountOwnerDriver, got.OwnerType)
+				require.Equal(t, int64(298), got.Balance)
+			},
+		},
+		{
+			name:   "NoWalletYet",
+			url:    "/api/v1/passenger/wallet",
+			role:   token.RolePassenger,
+			userID: passenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountByOwner(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got WalletResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.Equal(t, int64(0), got.Balance)
+			},
+		},
+		{
+			name:   "WrongRole",
+			url:    "/api/v1/driver/wallet",
+			role:   token.RolePassenger,
+			userID: passenger.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountByOwner(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "InternalError",
+			url:    "/api/v1/driver/wallet",
+			role:   token.RoleDriver,
+			userID: driver.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountByOwner(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, utils.RandomString(6), tc.role, tc.userID, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetWalletStatementAPI(t *testing.T) {
+	driver, _ := randomDriver(t)
+	account := db.Account{ID: 2, OwnerType: db.AccountOwnerDriver, OwnerID: driver.ID, Balance: 298}
+
+	postings := []db.ListAccountPostingsRow{
+		{
+			ID:           8,
+			Amount:       -52,
+			BalanceAfter: 298,
+			CreatedAt:    time.Now(),
+			Kind:         db.EntryKindCommission,
+			Description:  "Commission for trip TB-ABC234",
+			BookingID:    sql.NullString{String: "TB-ABC234", Valid: true},
+		},
+		{
+			ID:           7,
+			Amount:       350,
+			BalanceAfter: 350,
+			CreatedAt:    time.Now(),
+			Kind:         db.EntryKindTripFare,
+			Description:  "Fare for trip TB-ABC234",
+			BookingID:    sql.NullString{String: "TB-ABC234", Valid: true},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "page_number=2&per_page=2",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountByOwner(gomock.Any(), gomock.Eq(db.GetAccountByOwnerParams{
+						OwnerType: db.AccountOwnerDriver,
+						OwnerID:   driver.ID,
+					})).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					ListAccountPostings(gomock.Any(), gomock.Eq(db.ListAccountPostingsParams{
+						AccountID: account.ID,
+						Limit:     2,
+						Offset:    2,
+					})).
+					Times(1).
+					Return(postings, nil)
+				store.EXPECT().
+					CountAccountPostings(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(int64(4), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got WalletStatementResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.Equal(t, account.Balance, got.Balance)
+				require.Equal(t, int64(4), got.TotalCount)
+				require.Len(t, got.Postings, 2)
+				require.Equal(t, db.EntryKindCommission, got.Postings[0].Kind)
+				require.Equal(t, int64(-52), got.Postings[0].Amount)
+				require.Equal(t, int64(298), got.Postings[0].BalanceAfter)
+				require.NotNil(t, got.Postings[0].BookingID)
+				require.Equal(t, "TB-ABC234", *got.Postings[0].BookingID)
+			},
+		},
+		{
+			name:  "NoWalletYet",
+			query: "page_number=1&per_page=10",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountByOwner(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().
+					ListAccountPostings(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got WalletStatementResponse
+				requireBodyData(t, recorder.Body, &got)
+				require.Equal(t, int64(0), got.TotalCount)
+				require.Empty(t, got.Postings)
+			},
+		},
+		{
+			name:  "PageTooLarge",
+			query: "page_number=1&per_page=51",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountByOwner(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "MissingPageNumber",
+			query: "per_page=10",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountByOwner(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: "page_number=1&per_page=10",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountByOwner(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					ListAccountPostings(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+				store.EXPECT().
+					CountAccountPostings(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/api/v1/driver/wallet/statement?"+tc.query, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, driver.Mobile, token.RoleDriver, driver.ID, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS "trips" DROP COLUMN IF EXISTS "passenger_id";
@@ -0,0 +1,5 @@
+ALTER TABLE "trips" ADD COLUMN "passenger_id" bigint;
+
+ALTER TABLE "trips" ADD FOREIGN KEY ("passenger_id") REFERENCES "passengers" ("id");
+
+CREATE INDEX ON "trips" ("passenger_id");
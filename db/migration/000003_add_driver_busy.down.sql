@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS "drivers" DROP COLUMN IF EXISTS "is_busy";
@@ -0,0 +1 @@
+ALTER TABLE "drivers" ADD COLUMN "is_busy" bool NOT NULL DEFAULT false;
@@ -0,0 +1,34 @@
+ALTER TABLE IF EXISTS "trips"
+  DROP CONSTRAINT IF EXISTS "trips_pickup_lat_range",
+  DROP CONSTRAINT IF EXISTS "trips_pickup_long_range",
+  DROP CONSTRAINT IF EXISTS "trips_dropoff_lat_range",
+  DROP CONSTRAINT IF EXISTS "trips_dropoff_long_range";
+
+ALTER TABLE IF EXISTS "trips"
+  ALTER COLUMN "pickup_lat" TYPE varchar USING "pickup_lat"::varchar,
+  ALTER COLUMN "pickup_long" TYPE varchar USING "pickup_long"::varchar,
+  ALTER COLUMN "dropoff_lat" TYPE varchar USING "dropoff_lat"::varchar,
+  ALTER COLUMN "dropoff_long" TYPE varchar USING "dropoff_long"::varchar;
+
+-- put back the legacy values the up migration could not convert
+UPDATE "trips" AS t
+SET "pickup_lat" = i."raw_value"
+FROM "trip_coordinate_backfill_issues" AS i
+WHERE i."trip_id" = t."id" AND i."column_name" = 'pickup_lat';
+
+UPDATE "trips" AS t
+SET "pickup_long" = i."raw_value"
+FROM "trip_coordinate_backfill_issues" AS i
+WHERE i."trip_id" = t."id" AND i."column_name" = 'pickup_long';
+
+UPDATE "trips" AS t
+SET "dropoff_lat" = i."raw_value"
+FROM "trip_coordinate_backfill_issues" AS i
+WHERE i."trip_id" = t."id" AND i."column_name" = 'dropoff_lat';
+
+UPDATE "trips" AS t
+SET "dropoff_long" = i."raw_value"
+FROM "trip_coordinate_backfill_issues" AS i
+WHERE i."trip_id" = t."id" AND i."column_name" = 'dropoff_long';
+
+DROP TABLE IF EXISTS "trip_coordinate_backfill_issues";
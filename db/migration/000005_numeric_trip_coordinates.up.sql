@@ -0,0 +1,68 @@
+-- Legacy trips stored coordinates as free text. Values that are not numbers
+-- or fall outside the valid range are copied here before the conversion so
+-- they can be repaired by hand; the trip itself keeps 0 for those values.
+CREATE TABLE "trip_coordinate_backfill_issues" (
+  "id" bigserial PRIMARY KEY,
+  "trip_id" bigint NOT NULL,
+  "booking_id" varchar NOT NULL,
+  "column_name" varchar NOT NULL,
+  "raw_value" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+INSERT INTO "trip_coordinate_backfill_issues" ("trip_id", "booking_id", "column_name", "raw_value")
+SELECT "id", "booking_id", "column_name", "raw_value"
+FROM "trips"
+CROSS JOIN LATERAL (VALUES
+  ('pickup_lat', "pickup_lat", 90),
+  ('pickup_long', "pickup_long", 180),
+  ('dropoff_lat', "dropoff_lat", 90),
+  ('dropoff_long', "dropoff_long", 180)
+) AS "coordinates" ("column_name", "raw_value", "max_abs")
+WHERE CASE
+  WHEN trim("raw_value") ~ '^[-+]?([0-9]+(\.[0-9]*)?|\.[0-9]+)$'
+    THEN abs(trim("raw_value")::double precision) > "max_abs"
+  ELSE true
+END;
+
+DO $$
+DECLARE
+  issues bigint;
+BEGIN
+  SELECT count(*) INTO issues FROM "trip_coordinate_backfill_issues";
+  IF issues > 0 THEN
+    RAISE NOTICE '% trip coordinates could not be converted, see trip_coordinate_backfill_issues', issues;
+  END IF;
+END $$;
+
+UPDATE "trips" AS t
+SET "pickup_lat" = '0'
+FROM "trip_coordinate_backfill_issues" AS i
+WHERE i."trip_id" = t."id" AND i."column_name" = 'pickup_lat';
+
+UPDATE "trips" AS t
+SET "pickup_long" = '0'
+FROM "trip_coordinate_backfill_issues" AS i
+WHERE i."trip_id" = t."id" AND i."column_name" = 'pickup_long';
+
+UPDATE "trips" AS t
+SET "dropoff_lat" = '0'
+FROM "trip_coordinate_backfill_issues" AS i
+WHERE i."trip_id" = t."id" AND i."column_name" = 'dropoff_lat';
+
+UPDATE "trips" AS t
+SET "dropoff_long" = '0'
+FROM "trip_coordinate_backfill_issues" AS i
+WHERE i."trip_id" = t."id" AND i."column_name" = 'dropoff_long';
+
+ALTER TABLE "trips"
+  ALTER COLUMN "pickup_lat" TYPE double precision USING trim("pickup_lat")::double precision,
+  ALTER COLUMN "pickup_long" TYPE double precision USING trim("pickup_long")::double precision,
+  ALTER COLUMN "dropoff_lat" TYPE double precision USING trim("dropoff_lat")::double precision,
+  ALTER COLUMN "dropoff_long" TYPE double precision USING trim("dropoff_long")::double precision;
+
+ALTER TABLE "trips"
+  ADD CONSTRAINT "trips_pickup_lat_range" CHECK ("pickup_lat" BETWEEN -90 AND 90),
+  ADD CONSTRAINT "trips_pickup_long_range" CHECK ("pickup_long" BETWEEN -180 AND 180),
+  ADD CONSTRAINT "trips_dropoff_lat_range" CHECK ("dropoff_lat" BETWEEN -90 AND 90),
+  ADD CONSTRAINT "trips_dropoff_long_range" CHECK ("dropoff_long" BETWEEN -180 AND 180);
@@ -0,0 +1,9 @@
+CREATE TABLE "fare_rules" (
+  "car_type" varchar PRIMARY KEY,
+  "base_fare" bigint NOT NULL CHECK ("base_fare" >= 0),
+  "per_km" bigint NOT NULL CHECK ("per_km" >= 0),
+  "per_minute" bigint NOT NULL CHECK ("per_minute" >= 0),
+  "minimum_fare" bigint NOT NULL CHECK ("minimum_fare" >= 0),
+  "booking_fee" bigint NOT NULL DEFAULT 0 CHECK ("booking_fee" >= 0),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
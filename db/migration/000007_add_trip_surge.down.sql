@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS "trips" DROP COLUMN IF EXISTS "surge_multiplier";
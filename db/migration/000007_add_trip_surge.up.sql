@@ -0,0 +1,3 @@
+ALTER TABLE "trips" ADD COLUMN "surge_multiplier" double precision NOT NULL DEFAULT 1 CHECK ("surge_multiplier" >= 1);
+
+CREATE INDEX ON "trips" ("trip_status", "created_at");
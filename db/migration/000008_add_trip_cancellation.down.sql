@@ -0,0 +1,7 @@
+ALTER TABLE IF EXISTS "fare_rules" DROP COLUMN IF EXISTS "cancellation_fee";
+
+ALTER TABLE IF EXISTS "trips"
+  DROP COLUMN IF EXISTS "cancelled_by",
+  DROP COLUMN IF EXISTS "cancel_reason",
+  DROP COLUMN IF EXISTS "cancellation_fee",
+  DROP COLUMN IF EXISTS "cancelled_at";
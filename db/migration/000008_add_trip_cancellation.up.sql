@@ -0,0 +1,7 @@
+ALTER TABLE "trips"
+  ADD COLUMN "cancelled_by" varchar CHECK ("cancelled_by" IN ('passenger', 'driver')),
+  ADD COLUMN "cancel_reason" varchar,
+  ADD COLUMN "cancellation_fee" bigint CHECK ("cancellation_fee" >= 0),
+  ADD COLUMN "cancelled_at" timestamptz;
+
+ALTER TABLE "fare_rules" ADD COLUMN "cancellation_fee" bigint NOT NULL DEFAULT 0 CHECK ("cancellation_fee" >= 0);
@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS "trips"
+  DROP COLUMN IF EXISTS "scheduled_at",
+  DROP COLUMN IF EXISTS "reminder_sent_at";
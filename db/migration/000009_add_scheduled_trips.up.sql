@@ -0,0 +1,5 @@
+ALTER TABLE "trips"
+  ADD COLUMN "scheduled_at" timestamptz,
+  ADD COLUMN "reminder_sent_at" timestamptz;
+
+CREATE INDEX ON "trips" ("scheduled_at") WHERE "scheduled_at" IS NOT NULL;
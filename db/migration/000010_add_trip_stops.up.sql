@@ -0,0 +1,16 @@
+CREATE TABLE "trip_stops" (
+  "id" bigserial PRIMARY KEY,
+  "trip_id" bigint NOT NULL,
+  "stop_order" int NOT NULL CHECK ("stop_order" >= 1),
+  "location" varchar NOT NULL,
+  "lat" double precision NOT NULL CHECK ("lat" BETWEEN -90 AND 90),
+  "long" double precision NOT NULL CHECK ("long" BETWEEN -180 AND 180),
+  "status" varchar NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'arrived', 'departed')),
+  "arrived_at" timestamptz,
+  "departed_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "trip_stops" ADD FOREIGN KEY ("trip_id") REFERENCES "trips" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX ON "trip_stops" ("trip_id", "stop_order");
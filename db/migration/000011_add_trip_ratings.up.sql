@@ -0,0 +1,22 @@
+CREATE TABLE "trip_ratings" (
+  "id" bigserial PRIMARY KEY,
+  "trip_id" bigint NOT NULL,
+  "rated_by" varchar NOT NULL CHECK ("rated_by" IN ('passenger', 'driver')),
+  "passenger_id" bigint NOT NULL,
+  "driver_id" bigint NOT NULL,
+  "score" int NOT NULL CHECK ("score" BETWEEN 1 AND 5),
+  "comment" text,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "trip_ratings" ADD FOREIGN KEY ("trip_id") REFERENCES "trips" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "trip_ratings" ADD FOREIGN KEY ("passenger_id") REFERENCES "passengers" ("id");
+
+ALTER TABLE "trip_ratings" ADD FOREIGN KEY ("driver_id") REFERENCES "drivers" ("id");
+
+CREATE UNIQUE INDEX ON "trip_ratings" ("trip_id", "rated_by");
+
+CREATE INDEX ON "trip_ratings" ("driver_id", "rated_by");
+
+CREATE INDEX ON "trip_ratings" ("passenger_id", "rated_by");
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "trips_passenger_id_created_at_id_idx";
+
+DROP INDEX IF EXISTS "trips_driver_id_created_at_id_idx";
@@ -0,0 +1,3 @@
+CREATE INDEX ON "trips" ("passenger_id", "created_at" DESC, "id" DESC);
+
+CREATE INDEX ON "trips" ("driver_id", "created_at" DESC, "id" DESC);
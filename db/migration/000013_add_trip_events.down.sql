@@ -0,0 +1,9 @@
+ALTER TABLE IF EXISTS "trips"
+  DROP COLUMN IF EXISTS "accepted_at",
+  DROP COLUMN IF EXISTS "arrived_at",
+  DROP COLUMN IF EXISTS "started_at",
+  DROP COLUMN IF EXISTS "completed_at";
+
+DROP TABLE IF EXISTS "trip_events";
+
+DROP FUNCTION IF EXISTS "trip_events_append_only"();
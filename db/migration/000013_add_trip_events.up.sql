@@ -0,0 +1,33 @@
+-- Every trip status change is appended here together with who made it and,
+-- for drivers, where they were at the time.
+CREATE TABLE "trip_events" (
+  "id" bigserial PRIMARY KEY,
+  "trip_id" bigint NOT NULL,
+  "from_status" varchar,
+  "to_status" varchar NOT NULL,
+  "actor_type" varchar NOT NULL CHECK ("actor_type" IN ('passenger', 'driver', 'system')),
+  "actor_id" bigint,
+  "lat" double precision CHECK ("lat" BETWEEN -90 AND 90),
+  "long" double precision CHECK ("long" BETWEEN -180 AND 180),
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "trip_events" ADD FOREIGN KEY ("trip_id") REFERENCES "trips" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "trip_events" ("trip_id", "created_at");
+
+CREATE FUNCTION "trip_events_append_only"() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'trip_events is append-only';
+END $$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trip_events_append_only"
+BEFORE UPDATE ON "trip_events"
+FOR EACH ROW EXECUTE FUNCTION "trip_events_append_only"();
+
+-- When the trip reached each milestone, copied from the events for cheap reads.
+ALTER TABLE "trips"
+  ADD COLUMN "accepted_at" timestamptz,
+  ADD COLUMN "arrived_at" timestamptz,
+  ADD COLUMN "started_at" timestamptz,
+  ADD COLUMN "completed_at" timestamptz;
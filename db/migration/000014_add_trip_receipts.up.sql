@@ -0,0 +1,12 @@
+-- Receipts are kept exactly as first issued so re-downloads never change,
+-- even when fare rules or driver details are edited later.
+CREATE TABLE "trip_receipts" (
+  "id" bigserial PRIMARY KEY,
+  "trip_id" bigint UNIQUE NOT NULL,
+  "receipt_number" varchar UNIQUE NOT NULL,
+  "document" jsonb NOT NULL,
+  "pdf" bytea NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "trip_receipts" ADD FOREIGN KEY ("trip_id") REFERENCES "trips" ("id") ON DELETE CASCADE;
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS "postings";
+
+DROP TABLE IF EXISTS "journal_entries";
+
+DROP TABLE IF EXISTS "accounts";
+
+DROP FUNCTION IF EXISTS "journal_entry_balanced"();
+
+DROP FUNCTION IF EXISTS "ledger_append_only"();
@@ -0,0 +1,73 @@
+-- Wallets of passengers, drivers and the platform. The platform has a single
+-- account with owner_id 0. balance always equals the sum of the postings of
+-- the account and is kept here so it can be read and locked cheaply.
+CREATE TABLE "accounts" (
+  "id" bigserial PRIMARY KEY,
+  "owner_type" varchar NOT NULL CHECK ("owner_type" IN ('passenger', 'driver', 'platform')),
+  "owner_id" bigint NOT NULL,
+  "balance" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("owner_type", "owner_id")
+);
+
+-- One business event moving money, like the fare of a trip. amount is the
+-- gross amount moved, its postings say between which accounts.
+CREATE TABLE "journal_entries" (
+  "id" bigserial PRIMARY KEY,
+  "kind" varchar NOT NULL CHECK ("kind" IN ('trip_fare', 'commission', 'refund')),
+  "trip_id" bigint,
+  "amount" bigint NOT NULL CHECK ("amount" > 0),
+  "description" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "journal_entries" ADD FOREIGN KEY ("trip_id") REFERENCES "trips" ("id");
+
+-- a trip is only ever charged its fare and commission once
+CREATE UNIQUE INDEX ON "journal_entries" ("trip_id", "kind") WHERE "kind" IN ('trip_fare', 'commission');
+
+CREATE TABLE "postings" (
+  "id" bigserial PRIMARY KEY,
+  "entry_id" bigint NOT NULL,
+  "account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL CHECK ("amount" <> 0),
+  "balance_after" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "postings" ADD FOREIGN KEY ("entry_id") REFERENCES "journal_entries" ("id");
+
+ALTER TABLE "postings" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+CREATE INDEX ON "postings" ("account_id", "id");
+
+CREATE INDEX ON "postings" ("entry_id");
+
+-- The postings of an entry must add up to zero by the time the transaction
+-- writing them commits.
+CREATE FUNCTION "journal_entry_balanced"() RETURNS trigger AS $$
+BEGIN
+  IF (SELECT sum("amount") FROM "postings" WHERE "entry_id" = NEW."entry_id") <> 0 THEN
+    RAISE EXCEPTION 'journal entry % is not balanced', NEW."entry_id";
+  END IF;
+  RETURN NULL;
+END $$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER "postings_balanced"
+AFTER INSERT ON "postings"
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW EXECUTE FUNCTION "journal_entry_balanced"();
+
+-- Mistakes are corrected with new entries, never by editing old ones.
+CREATE FUNCTION "ledger_append_only"() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END $$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "journal_entries_append_only"
+BEFORE UPDATE OR DELETE ON "journal_entries"
+FOR EACH ROW EXECUTE FUNCTION "ledger_append_only"();
+
+CREATE TRIGGER "postings_append_only"
+BEFORE UPDATE OR DELETE ON "postings"
+FOR EACH ROW EXECUTE FUNCTION "ledger_append_only"();
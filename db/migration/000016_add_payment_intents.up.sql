@@ -0,0 +1,36 @@
+-- A charge taken, or attempted, through a payment provider for a trip or a
+-- driver subscription. Declined attempts are kept with status failed and no
+-- provider reference.
+CREATE TABLE "payment_intents" (
+  "id" bigserial PRIMARY KEY,
+  "provider" varchar NOT NULL,
+  "provider_ref" varchar,
+  "trip_id" bigint,
+  "subscription_id" bigint,
+  "payer_type" varchar NOT NULL CHECK ("payer_type" IN ('passenger', 'driver')),
+  "payer_id" bigint NOT NULL,
+  "amount" bigint NOT NULL CHECK ("amount" > 0),
+  "captured_amount" bigint NOT NULL DEFAULT 0,
+  "refunded_amount" bigint NOT NULL DEFAULT 0,
+  "currency" varchar NOT NULL DEFAULT 'BDT',
+  "status" varchar NOT NULL CHECK ("status" IN ('authorized', 'captured', 'refunded', 'failed')),
+  "failure_reason" varchar,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("provider", "provider_ref"),
+  -- every payment is for exactly one trip or subscription
+  CHECK (("trip_id" IS NULL) <> ("subscription_id" IS NULL)),
+  CHECK ("captured_amount" BETWEEN 0 AND "amount"),
+  CHECK ("refunded_amount" BETWEEN 0 AND "captured_amount")
+);
+
+ALTER TABLE "payment_intents" ADD FOREIGN KEY ("trip_id") REFERENCES "trips" ("id");
+
+ALTER TABLE "payment_intents" ADD FOREIGN KEY ("subscription_id") REFERENCES "subscriptions" ("id");
+
+CREATE INDEX ON "payment_intents" ("subscription_id");
+
+-- a trip is paid at most once at a time
+CREATE UNIQUE INDEX ON "payment_intents" ("trip_id") WHERE "status" IN ('authorized', 'captured');
+
+CREATE INDEX ON "payment_intents" ("trip_id", "id");
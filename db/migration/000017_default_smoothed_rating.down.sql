@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS "passengers" ALTER COLUMN "rating" SET DEFAULT 0.0;
+
+ALTER TABLE IF EXISTS "drivers" ALTER COLUMN "rating" SET DEFAULT 0.0;
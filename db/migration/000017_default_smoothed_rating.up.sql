@@ -0,0 +1,18 @@
+-- new accounts start at the smoothed rating of no scores, SmoothedRating(0, 0)
+ALTER TABLE "drivers" ALTER COLUMN "rating" SET DEFAULT 4.5;
+
+ALTER TABLE "passengers" ALTER COLUMN "rating" SET DEFAULT 4.5;
+
+-- recompute every rating from the scores received so far with a prior of
+-- 5 ratings of 4.5, so accounts that were never rated no longer show 0
+UPDATE "drivers" SET "rating" = (
+  SELECT ROUND(((4.5 * 5 + COALESCE(SUM("score"), 0)) / (5 + COUNT(*)))::numeric, 2)
+  FROM "trip_ratings"
+  WHERE "trip_ratings"."driver_id" = "drivers"."id" AND "rated_by" = 'passenger'
+);
+
+UPDATE "passengers" SET "rating" = (
+  SELECT ROUND(((4.5 * 5 + COALESCE(SUM("score"), 0)) / (5 + COUNT(*)))::numeric, 2)
+  FROM "trip_ratings"
+  WHERE "trip_ratings"."passenger_id" = "passengers"."id" AND "rated_by" = 'driver'
+);
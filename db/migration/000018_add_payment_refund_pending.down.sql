@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS "payment_intents" DROP COLUMN IF EXISTS "refund_pending_amount";
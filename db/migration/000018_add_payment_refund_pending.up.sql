@@ -0,0 +1,6 @@
+-- refunds asked of the provider but not recorded yet. They are reserved
+-- before the provider is called, so a refund the provider made is never lost
+-- and is recorded once, either by the request or by the provider's webhook.
+ALTER TABLE "payment_intents" ADD COLUMN "refund_pending_amount" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "payment_intents" ADD CHECK ("refund_pending_amount" >= 0 AND "refunded_amount" + "refund_pending_amount" <= "captured_amount");
@@ -0,0 +1,6 @@
+-- Payments collected for a trip are posted too, so what a passenger still
+-- owes for a fare shows on their wallet.
+ALTER TABLE "journal_entries" DROP CONSTRAINT "journal_entries_kind_check";
+
+ALTER TABLE "journal_entries" ADD CONSTRAINT "journal_entries_kind_check"
+  CHECK ("kind" IN ('trip_fare', 'commission', 'refund', 'payment', 'payment_refund'));
@@ -0,0 +1,6 @@
+UPDATE "payment_intents" SET "status" = 'failed', "failure_reason" = 'voided' WHERE "status" = 'voided';
+
+ALTER TABLE IF EXISTS "payment_intents" DROP CONSTRAINT IF EXISTS "payment_intents_status_check";
+
+ALTER TABLE IF EXISTS "payment_intents" ADD CONSTRAINT "payment_intents_status_check"
+  CHECK ("status" IN ('authorized', 'captured', 'refunded', 'failed'));
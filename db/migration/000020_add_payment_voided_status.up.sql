@@ -0,0 +1,5 @@
+-- holds on trips that were cancelled or expired are released
+ALTER TABLE "payment_intents" DROP CONSTRAINT "payment_intents_status_check";
+
+ALTER TABLE "payment_intents" ADD CONSTRAINT "payment_intents_status_check"
+  CHECK ("status" IN ('authorized', 'captured', 'refunded', 'failed', 'voided'));
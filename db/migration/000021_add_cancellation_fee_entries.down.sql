@@ -0,0 +1,4 @@
+ALTER TABLE IF EXISTS "journal_entries" DROP CONSTRAINT IF EXISTS "journal_entries_kind_check";
+
+ALTER TABLE IF EXISTS "journal_entries" ADD CONSTRAINT "journal_entries_kind_check"
+  CHECK ("kind" IN ('trip_fare', 'commission', 'refund', 'payment', 'payment_refund'));
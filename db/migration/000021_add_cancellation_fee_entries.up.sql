@@ -0,0 +1,6 @@
+-- Cancellation fees are posted too, so a fee charged to a passenger shows on
+-- their wallet and on the driver's.
+ALTER TABLE "journal_entries" DROP CONSTRAINT "journal_entries_kind_check";
+
+ALTER TABLE "journal_entries" ADD CONSTRAINT "journal_entries_kind_check"
+  CHECK ("kind" IN ('trip_fare', 'commission', 'refund', 'payment', 'payment_refund', 'cancellation_fee'));
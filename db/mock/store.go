@@ -0,0 +1,1404 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/emonoid/toribook.git/db/sqlc (interfaces: Store)
+
+// Package mockdb is a generated GoMock package.
+package mockdb
+
+import (
+	context "context"
+	sql "database/sql"
+	reflect "reflect"
+
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+)
+
+// MockStore is a mock of Store interface.
+type MockStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockStoreMockRecorder
+}
+
+// MockStoreMockRecorder is the mock recorder for MockStore.
+type MockStoreMockRecorder struct {
+	mock *MockStore
+}
+
+// NewMockStore creates a new mock instance.
+func NewMockStore(ctrl *gomock.Controller) *MockStore {
+	mock := &MockStore{ctrl: ctrl}
+	mock.recorder = &MockStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStore) EXPECT() *MockStoreMockRecorder {
+	return m.recorder
+}
+
+// AcceptBidTx mocks base method.
+func (m *MockStore) AcceptBidTx(arg0 context.Context, arg1 db.AcceptBidTxParams) (db.AcceptBidTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptBidTx", arg0, arg1)
+	ret0, _ := ret[0].(db.AcceptBidTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptBidTx indicates an expected call of AcceptBidTx.
+func (mr *MockStoreMockRecorder) AcceptBidTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptBidTx", reflect.TypeOf((*MockStore)(nil).AcceptBidTx), arg0, arg1)
+}
+
+// AddAccountBalance mocks base method.
+func (m *MockStore) AddAccountBalance(arg0 context.Context, arg1 db.AddAccountBalanceParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAccountBalance", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAccountBalance indicates an expected call of AddAccountBalance.
+func (mr *MockStoreMockRecorder) AddAccountBalance(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountBalance", reflect.TypeOf((*MockStore)(nil).AddAccountBalance), arg0, arg1)
+}
+
+// ArriveTripStop mocks base method.
+func (m *MockStore) ArriveTripStop(arg0 context.Context, arg1 int64) (db.TripStop, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArriveTripStop", arg0, arg1)
+	ret0, _ := ret[0].(db.TripStop)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ArriveTripStop indicates an expected call of ArriveTripStop.
+func (mr *MockStoreMockRecorder) ArriveTripStop(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArriveTripStop", reflect.TypeOf((*MockStore)(nil).ArriveTripStop), arg0, arg1)
+}
+
+// AssignDriverTx mocks base method.
+func (m *MockStore) AssignDriverTx(arg0 context.Context, arg1 db.AssignDriverTxParams) (db.AssignDriverTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignDriverTx", arg0, arg1)
+	ret0, _ := ret[0].(db.AssignDriverTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssignDriverTx indicates an expected call of AssignDriverTx.
+func (mr *MockStoreMockRecorder) AssignDriverTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignDriverTx", reflect.TypeOf((*MockStore)(nil).AssignDriverTx), arg0, arg1)
+}
+
+// AssignTripDriver mocks base method.
+func (m *MockStore) AssignTripDriver(arg0 context.Context, arg1 db.AssignTripDriverParams) (db.Trip, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignTripDriver", arg0, arg1)
+	ret0, _ := ret[0].(db.Trip)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssignTripDriver indicates an expected call of AssignTripDriver.
+func (mr *MockStoreMockRecorder) AssignTripDriver(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignTripDriver", reflect.TypeOf((*MockStore)(nil).AssignTripDriver), arg0, arg1)
+}
+
+// BlockSession mocks base method.
+func (m *MockStore) BlockSession(arg0 context.Context, arg1 db.BlockSessionParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockSession", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockSession indicates an expected call of BlockSession.
+func (mr *MockStoreMockRecorder) BlockSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSession", reflect.TypeOf((*MockStore)(nil).BlockSession), arg0, arg1)
+}
+
+// BlockUserSessions mocks base method.
+func (m *MockStore) BlockUserSessions(arg0 context.Context, arg1 db.BlockUserSessionsParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockUserSessions", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BlockUserSessions indicates an expected call of BlockUserSessions.
+func (mr *MockStoreMockRecorder) BlockUserSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockUserSessions", reflect.TypeOf((*MockStore)(nil).BlockUserSessions), arg0, arg1)
+}
+
+// CancelTrip mocks base method.
+func (m *MockStore) CancelTrip(arg0 context.Context, arg1 db.CancelTripParams) (db.Trip, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelTrip", arg0, arg1)
+	ret0, _ := ret[0].(db.Trip)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelTrip indicates an expected call of CancelTrip.
+func (mr *MockStoreMockRecorder) CancelTrip(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelTrip", reflect.TypeOf((*MockStore)(nil).CancelTrip), arg0, arg1)
+}
+
+// CancelTripTx mocks base method.
+func (m *MockStore) CancelTripTx(arg0 context.Context, arg1 db.CancelTripTxParams) (db.CancelTripTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelTripTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CancelTripTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelTripTx indicates an expected call of CancelTripTx.
+func (mr *MockStoreMockRecorder) CancelTripTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelTripTx", reflect.TypeOf((*MockStore)(nil).CancelTripTx), arg0, arg1)
+}
+
+// CapturePaymentTx mocks base method.
+func (m *MockStore) CapturePaymentTx(arg0 context.Context, arg1 db.CapturePaymentTxParams) (db.CapturePaymentTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CapturePaymentTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CapturePaymentTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CapturePaymentTx indicates an expected call of CapturePaymentTx.
+func (mr *MockStoreMockRecorder) CapturePaymentTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CapturePaymentTx", reflect.TypeOf((*MockStore)(nil).CapturePaymentTx), arg0, arg1)
+}
+
+// CompleteTrip mocks base method.
+func (m *MockStore) CompleteTrip(arg0 context.Context, arg1 db.CompleteTripParams) (db.Trip, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteTrip", arg0, arg1)
+	ret0, _ := ret[0].(db.Trip)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteTrip indicates an expected call of CompleteTrip.
+func (mr *MockStoreMockRecorder) CompleteTrip(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteTrip", reflect.TypeOf((*MockStore)(nil).CompleteTrip), arg0, arg1)
+}
+
+// CompleteTripTx mocks base method.
+func (m *MockStore) CompleteTripTx(arg0 context.Context, arg1 db.CompleteTripTxParams) (db.CompleteTripTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteTripTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CompleteTripTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteTripTx indicates an expected call of CompleteTripTx.
+func (mr *MockStoreMockRecorder) CompleteTripTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteTripTx", reflect.TypeOf((*MockStore)(nil).CompleteTripTx), arg0, arg1)
+}
+
+// CountAccountPostings mocks base method.
+func (m *MockStore) CountAccountPostings(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountAccountPostings", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountAccountPostings indicates an expected call of CountAccountPostings.
+func (mr *MockStoreMockRecorder) CountAccountPostings(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountAccountPostings", reflect.TypeOf((*MockStore)(nil).CountAccountPostings), arg0, arg1)
+}
+
+// CountOpenTripsInArea mocks base method.
+func (m *MockStore) CountOpenTripsInArea(arg0 context.Context, arg1 db.CountOpenTripsInAreaParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountOpenTripsInArea", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountOpenTripsInArea indicates an expected call of CountOpenTripsInArea.
+func (mr *MockStoreMockRecorder) CountOpenTripsInArea(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountOpenTripsInArea", reflect.TypeOf((*MockStore)(nil).CountOpenTripsInArea), arg0, arg1)
+}
+
+// CountTripHistory mocks base method.
+func (m *MockStore) CountTripHistory(arg0 context.Context, arg1 db.CountTripHistoryParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountTripHistory", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountTripHistory indicates an expected call of CountTripHistory.
+func (mr *MockStoreMockRecorder) CountTripHistory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTripHistory", reflect.TypeOf((*MockStore)(nil).CountTripHistory), arg0, arg1)
+}
+
+// CreateCar mocks base method.
+func (m *MockStore) CreateCar(arg0 context.Context, arg1 db.CreateCarParams) (db.Car, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCar", arg0, arg1)
+	ret0, _ := ret[0].(db.Car)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCar indicates an expected call of CreateCar.
+func (mr *MockStoreMockRecorder) CreateCar(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCar", reflect.TypeOf((*MockStore)(nil).CreateCar), arg0, arg1)
+}
+
+// CreateDriver mocks base method.
+func (m *MockStore) CreateDriver(arg0 context.Context, arg1 db.CreateDriverParams) (db.Driver, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDriver", arg0, arg1)
+	ret0, _ := ret[0].(db.Driver)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDriver indicates an expected call of CreateDriver.
+func (mr *MockStoreMockRecorder) CreateDriver(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDriver", reflect.TypeOf((*MockStore)(nil).CreateDriver), arg0, arg1)
+}
+
+// CreateJournalEntry mocks base method.
+func (m *MockStore) CreateJournalEntry(arg0 context.Context, arg1 db.CreateJournalEntryParams) (db.JournalEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateJournalEntry", arg0, arg1)
+	ret0, _ := ret[0].(db.JournalEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateJournalEntry indicates an expected call of CreateJournalEntry.
+func (mr *MockStoreMockRecorder) CreateJournalEntry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJournalEntry", reflect.TypeOf((*MockStore)(nil).CreateJournalEntry), arg0, arg1)
+}
+
+// CreatePassenger mocks base method.
+func (m *MockStore) CreatePassenger(arg0 context.Context, arg1 db.CreatePassengerParams) (db.Passenger, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePassenger", arg0, arg1)
+	ret0, _ := ret[0].(db.Passenger)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePassenger indicates an expected call of CreatePassenger.
+func (mr *MockStoreMockRecorder) CreatePassenger(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePassenger", reflect.TypeOf((*MockStore)(nil).CreatePassenger), arg0, arg1)
+}
+
+// CreatePaymentIntent mocks base method.
+func (m *MockStore) CreatePaymentIntent(arg0 context.Context, arg1 db.CreatePaymentIntentParams) (db.PaymentIntent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePaymentIntent", arg0, arg1)
+	ret0, _ := ret[0].(db.PaymentIntent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePaymentIntent indicates an expected call of CreatePaymentIntent.
+func (mr *MockStoreMockRecorder) CreatePaymentIntent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePaymentIntent", reflect.TypeOf((*MockStore)(nil).CreatePaymentIntent), arg0, arg1)
+}
+
+// CreatePosting mocks base method.
+func (m *MockStore) CreatePosting(arg0 context.Context, arg1 db.CreatePostingParams) (db.Posting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePosting", arg0, arg1)
+	ret0, _ := ret[0].(db.Posting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePosting indicates an expected call of CreatePosting.
+func (mr *MockStoreMockRecorder) CreatePosting(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePosting", reflect.TypeOf((*MockStore)(nil).CreatePosting), arg0, arg1)
+}
+
+// CreateSession mocks base method.
+func (m *MockStore) CreateSession(arg0 context.Context, arg1 db.CreateSessionParams) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockStoreMockRecorder) CreateSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), arg0, arg1)
+}
+
+// CreateSubscription mocks base method.
+func (m *MockStore) CreateSubscription(arg0 context.Context, arg1 db.CreateSubscriptionParams) (db.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSubscription", arg0, arg1)
+	ret0, _ := ret[0].(db.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSubscription indicates an expected call of CreateSubscription.
+func (mr *MockStoreMockRecorder) CreateSubscription(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSubscription", reflect.TypeOf((*MockStore)(nil).CreateSubscription), arg0, arg1)
+}
+
+// CreateTrip mocks base method.
+func (m *MockStore) CreateTrip(arg0 context.Context, arg1 db.CreateTripParams) (db.Trip, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTrip", arg0, arg1)
+	ret0, _ := ret[0].(db.Trip)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTrip indicates an expected call of CreateTrip.
+func (mr *MockStoreMockRecorder) CreateTrip(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTrip", reflect.TypeOf((*MockStore)(nil).CreateTrip), arg0, arg1)
+}
+
+// CreateTripEvent mocks base method.
+func (m *MockStore) CreateTripEvent(arg0 context.Context, arg1 db.CreateTripEventParams) (db.TripEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTripEvent", arg0, arg1)
+	ret0, _ := ret[0].(db.TripEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTripEvent indicates an expected call of CreateTripEvent.
+func (mr *MockStoreMockRecorder) CreateTripEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTripEvent", reflect.TypeOf((*MockStore)(nil).CreateTripEvent), arg0, arg1)
+}
+
+// CreateTripRating mocks base method.
+func (m *MockStore) CreateTripRating(arg0 context.Context, arg1 db.CreateTripRatingParams) (db.TripRating, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTripRating", arg0, arg1)
+	ret0, _ := ret[0].(db.TripRating)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTripRating indicates an expected call of CreateTripRating.
+func (mr *MockStoreMockRecorder) CreateTripRating(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTripRating", reflect.TypeOf((*MockStore)(nil).CreateTripRating), arg0, arg1)
+}
+
+// CreateTripReceipt mocks base method.
+func (m *MockStore) CreateTripReceipt(arg0 context.Context, arg1 db.CreateTripReceiptParams) (db.TripReceipt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTripReceipt", arg0, arg1)
+	ret0, _ := ret[0].(db.TripReceipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTripReceipt indicates an expected call of CreateTripReceipt.
+func (mr *MockStoreMockRecorder) CreateTripReceipt(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTripReceipt", reflect.TypeOf((*MockStore)(nil).CreateTripReceipt), arg0, arg1)
+}
+
+// CreateTripStop mocks base method.
+func (m *MockStore) CreateTripStop(arg0 context.Context, arg1 db.CreateTripStopParams) (db.TripStop, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTripStop", arg0, arg1)
+	ret0, _ := ret[0].(db.TripStop)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTripStop indicates an expected call of CreateTripStop.
+func (mr *MockStoreMockRecorder) CreateTripStop(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTripStop", reflect.TypeOf((*MockStore)(nil).CreateTripStop), arg0, arg1)
+}
+
+// CreateTripTx mocks base method.
+func (m *MockStore) CreateTripTx(arg0 context.Context, arg1 db.CreateTripTxParams) (db.CreateTripTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTripTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CreateTripTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTripTx indicates an expected call of CreateTripTx.
+func (mr *MockStoreMockRecorder) CreateTripTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTripTx", reflect.TypeOf((*MockStore)(nil).CreateTripTx), arg0, arg1)
+}
+
+// DeleteCar mocks base method.
+func (m *MockStore) DeleteCar(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCar", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCar indicates an expected call of DeleteCar.
+func (mr *MockStoreMockRecorder) DeleteCar(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCar", reflect.TypeOf((*MockStore)(nil).DeleteCar), arg0, arg1)
+}
+
+// DeleteDriver mocks base method.
+func (m *MockStore) DeleteDriver(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDriver", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDriver indicates an expected call of DeleteDriver.
+func (mr *MockStoreMockRecorder) DeleteDriver(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDriver", reflect.TypeOf((*MockStore)(nil).DeleteDriver), arg0, arg1)
+}
+
+// DeletePassenger mocks base method.
+func (m *MockStore) DeletePassenger(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePassenger", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePassenger indicates an expected call of DeletePassenger.
+func (mr *MockStoreMockRecorder) DeletePassenger(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePassenger", reflect.TypeOf((*MockStore)(nil).DeletePassenger), arg0, arg1)
+}
+
+// DeleteSubscription mocks base method.
+func (m *MockStore) DeleteSubscription(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSubscription", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSubscription indicates an expected call of DeleteSubscription.
+func (mr *MockStoreMockRecorder) DeleteSubscription(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSubscription", reflect.TypeOf((*MockStore)(nil).DeleteSubscription), arg0, arg1)
+}
+
+// DeleteTrip mocks base method.
+func (m *MockStore) DeleteTrip(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTrip", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTrip indicates an expected call of DeleteTrip.
+func (mr *MockStoreMockRecorder) DeleteTrip(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTrip", reflect.TypeOf((*MockStore)(nil).DeleteTrip), arg0, arg1)
+}
+
+// DepartTripStop mocks base method.
+func (m *MockStore) DepartTripStop(arg0 context.Context, arg1 int64) (db.TripStop, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DepartTripStop", arg0, arg1)
+	ret0, _ := ret[0].(db.TripStop)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DepartTripStop indicates an expected call of DepartTripStop.
+func (mr *MockStoreMockRecorder) DepartTripStop(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DepartTripStop", reflect.TypeOf((*MockStore)(nil).DepartTripStop), arg0, arg1)
+}
+
+// GetAccountByOwner mocks base method.
+func (m *MockStore) GetAccountByOwner(arg0 context.Context, arg1 db.GetAccountByOwnerParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountByOwner", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountByOwner indicates an expected call of GetAccountByOwner.
+func (mr *MockStoreMockRecorder) GetAccountByOwner(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountByOwner", reflect.TypeOf((*MockStore)(nil).GetAccountByOwner), arg0, arg1)
+}
+
+// GetCar mocks base method.
+func (m *MockStore) GetCar(arg0 context.Context, arg1 int64) (db.Car, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCar", arg0, arg1)
+	ret0, _ := ret[0].(db.Car)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCar indicates an expected call of GetCar.
+func (mr *MockStoreMockRecorder) GetCar(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCar", reflect.TypeOf((*MockStore)(nil).GetCar), arg0, arg1)
+}
+
+// GetDriver mocks base method.
+func (m *MockStore) GetDriver(arg0 context.Context, arg1 int64) (db.Driver, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDriver", arg0, arg1)
+	ret0, _ := ret[0].(db.Driver)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDriver indicates an expected call of GetDriver.
+func (mr *MockStoreMockRecorder) GetDriver(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDriver", reflect.TypeOf((*MockStore)(nil).GetDriver), arg0, arg1)
+}
+
+// GetDriverActiveTrip mocks base method.
+func (m *MockStore) GetDriverActiveTrip(arg0 context.Context, arg1 sql.NullInt64) (db.Trip, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDriverActiveTrip", arg0, arg1)
+	ret0, _ := ret[0].(db.Trip)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDriverActiveTrip indicates an expected call of GetDriverActiveTrip.
+func (mr *MockStoreMockRecorder) GetDriverActiveTrip(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDriverActiveTrip", reflect.TypeOf((*MockStore)(nil).GetDriverActiveTrip), arg0, arg1)
+}
+
+// GetDriverByMobile mocks base method.
+func (m *MockStore) GetDriverByMobile(arg0 context.Context, arg1 string) (db.Driver, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDriverByMobile", arg0, arg1)
+	ret0, _ := ret[0].(db.Driver)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDriverByMobile indicates an expected call of GetDriverByMobile.
+func (mr *MockStoreMockRecorder) GetDriverByMobile(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDriverByMobile", reflect.TypeOf((*MockStore)(nil).GetDriverByMobile), arg0, arg1)
+}
+
+// GetDriverForUpdate mocks base method.
+func (m *MockStore) GetDriverForUpdate(arg0 context.Context, arg1 int64) (db.Driver, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDriverForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.Driver)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDriverForUpdate indicates an expected call of GetDriverForUpdate.
+func (mr *MockStoreMockRecorder) GetDriverForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDriverForUpdate", reflect.TypeOf((*MockStore)(nil).GetDriverForUpdate), arg0, arg1)
+}
+
+// GetDriverRatingSummary mocks base method.
+func (m *MockStore) GetDriverRatingSummary(arg0 context.Context, arg1 int64) (db.GetDriverRatingSummaryRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDriverRatingSummary", arg0, arg1)
+	ret0, _ := ret[0].(db.GetDriverRatingSummaryRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDriverRatingSummary indicates an expected call of GetDriverRatingSummary.
+func (mr *MockStoreMockRecorder) GetDriverRatingSummary(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDriverRatingSummary", reflect.TypeOf((*MockStore)(nil).GetDriverRatingSummary), arg0, arg1)
+}
+
+// GetFareRule mocks base method.
+func (m *MockStore) GetFareRule(arg0 context.Context, arg1 string) (db.FareRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFareRule", arg0, arg1)
+	ret0, _ := ret[0].(db.FareRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFareRule indicates an expected call of GetFareRule.
+func (mr *MockStoreMockRecorder) GetFareRule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFareRule", reflect.TypeOf((*MockStore)(nil).GetFareRule), arg0, arg1)
+}
+
+// GetOrCreateAccount mocks base method.
+func (m *MockStore) GetOrCreateAccount(arg0 context.Context, arg1 db.GetOrCreateAccountParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrCreateAccount", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrCreateAccount indicates an expected call of GetOrCreateAccount.
+func (mr *MockStoreMockRecorder) GetOrCreateAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrCreateAccount", reflect.TypeOf((*MockStore)(nil).GetOrCreateAccount), arg0, arg1)
+}
+
+// GetPassenger mocks base method.
+func (m *MockStore) GetPassenger(arg0 context.Context, arg1 int64) (db.Passenger, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPassenger", arg0, arg1)
+	ret0, _ := ret[0].(db.Passenger)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPassenger indicates an expected call of GetPassenger.
+func (mr *MockStoreMockRecorder) GetPassenger(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPassenger", reflect.TypeOf((*MockStore)(nil).GetPassenger), arg0, arg1)
+}
+
+// GetPassengerByEmail mocks base method.
+func (m *MockStore) GetPassengerByEmail(arg0 context.Context, arg1 string) (db.Passenger, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPassengerByEmail", arg0, arg1)
+	ret0, _ := ret[0].(db.Passenger)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPassengerByEmail indicates an expected call of GetPassengerByEmail.
+func (mr *MockStoreMockRecorder) GetPassengerByEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPassengerByEmail", reflect.TypeOf((*MockStore)(nil).GetPassengerByEmail), arg0, arg1)
+}
+
+// GetPassengerRatingSummary mocks base method.
+func (m *MockStore) GetPassengerRatingSummary(arg0 context.Context, arg1 int64) (db.GetPassengerRatingSummaryRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPassengerRatingSummary", arg0, arg1)
+	ret0, _ := ret[0].(db.GetPassengerRatingSummaryRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPassengerRatingSummary indicates an expected call of GetPassengerRatingSummary.
+func (mr *MockStoreMockRecorder) GetPassengerRatingSummary(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPassengerRatingSummary", reflect.TypeOf((*MockStore)(nil).GetPassengerRatingSummary), arg0, arg1)
+}
+
+// GetPaymentIntentByProviderRef mocks base method.
+func (m *MockStore) GetPaymentIntentByProviderRef(arg0 context.Context, arg1 db.GetPaymentIntentByProviderRefParams) (db.PaymentIntent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentIntentByProviderRef", arg0, arg1)
+	ret0, _ := ret[0].(db.PaymentIntent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentIntentByProviderRef indicates an expected call of GetPaymentIntentByProviderRef.
+func (mr *MockStoreMockRecorder) GetPaymentIntentByProviderRef(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentIntentByProviderRef", reflect.TypeOf((*MockStore)(nil).GetPaymentIntentByProviderRef), arg0, arg1)
+}
+
+// GetPaymentIntentForUpdate mocks base method.
+func (m *MockStore) GetPaymentIntentForUpdate(arg0 context.Context, arg1 int64) (db.PaymentIntent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentIntentForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.PaymentIntent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentIntentForUpdate indicates an expected call of GetPaymentIntentForUpdate.
+func (mr *MockStoreMockRecorder) GetPaymentIntentForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentIntentForUpdate", reflect.TypeOf((*MockStore)(nil).GetPaymentIntentForUpdate), arg0, arg1)
+}
+
+// GetSession mocks base method.
+func (m *MockStore) GetSession(arg0 context.Context, arg1 uuid.UUID) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockStoreMockRecorder) GetSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockStore)(nil).GetSession), arg0, arg1)
+}
+
+// GetSubscription mocks base method.
+func (m *MockStore) GetSubscription(arg0 context.Context, arg1 int64) (db.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubscription", arg0, arg1)
+	ret0, _ := ret[0].(db.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubscription indicates an expected call of GetSubscription.
+func (mr *MockStoreMockRecorder) GetSubscription(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubscription", reflect.TypeOf((*MockStore)(nil).GetSubscription), arg0, arg1)
+}
+
+// GetTrip mocks base method.
+func (m *MockStore) GetTrip(arg0 context.Context, arg1 int64) (db.Trip, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrip", arg0, arg1)
+	ret0, _ := ret[0].(db.Trip)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrip indicates an expected call of GetTrip.
+func (mr *MockStoreMockRecorder) GetTrip(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrip", reflect.TypeOf((*MockStore)(nil).GetTrip), arg0, arg1)
+}
+
+// GetTripByBookingID mocks base method.
+func (m *MockStore) GetTripByBookingID(arg0 context.Context, arg1 string) (db.Trip, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTripByBookingID", arg0, arg1)
+	ret0, _ := ret[0].(db.Trip)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTripByBookingID indicates an expected call of GetTripByBookingID.
+func (mr *MockStoreMockRecorder) GetTripByBookingID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTripByBookingID", reflect.TypeOf((*MockStore)(nil).GetTripByBookingID), arg0, arg1)
+}
+
+// GetTripByBookingIDForUpdate mocks base method.
+func (m *MockStore) GetTripByBookingIDForUpdate(arg0 context.Context, arg1 string) (db.Trip, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTripByBookingIDForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.Trip)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTripByBookingIDForUpdate indicates an expected call of GetTripByBookingIDForUpdate.
+func (mr *MockStoreMockRecorder) GetTripByBookingIDForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTripByBookingIDForUpdate", reflect.TypeOf((*MockStore)(nil).GetTripByBookingIDForUpdate), arg0, arg1)
+}
+
+// GetTripForUpdate mocks base method.
+func (m *MockStore) GetTripForUpdate(arg0 context.Context, arg1 int64) (db.Trip, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTripForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.Trip)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTripForUpdate indicates an expected call of GetTripForUpdate.
+func (mr *MockStoreMockRecorder) GetTripForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTripForUpdate", reflect.TypeOf((*MockStore)(nil).GetTripForUpdate), arg0, arg1)
+}
+
+// GetTripPaymentIntent mocks base method.
+func (m *MockStore) GetTripPaymentIntent(arg0 context.Context, arg1 sql.NullInt64) (db.PaymentIntent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTripPaymentIntent", arg0, arg1)
+	ret0, _ := ret[0].(db.PaymentIntent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTripPaymentIntent indicates an expected call of GetTripPaymentIntent.
+func (mr *MockStoreMockRecorder) GetTripPaymentIntent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTripPaymentIntent", reflect.TypeOf((*MockStore)(nil).GetTripPaymentIntent), arg0, arg1)
+}
+
+// GetTripReceipt mocks base method.
+func (m *MockStore) GetTripReceipt(arg0 context.Context, arg1 int64) (db.TripReceipt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTripReceipt", arg0, arg1)
+	ret0, _ := ret[0].(db.TripReceipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTripReceipt indicates an expected call of GetTripReceipt.
+func (mr *MockStoreMockRecorder) GetTripReceipt(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTripReceipt", reflect.TypeOf((*MockStore)(nil).GetTripReceipt), arg0, arg1)
+}
+
+// ListAccountPostings mocks base method.
+func (m *MockStore) ListAccountPostings(arg0 context.Context, arg1 db.ListAccountPostingsParams) ([]db.ListAccountPostingsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountPostings", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListAccountPostingsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountPostings indicates an expected call of ListAccountPostings.
+func (mr *MockStoreMockRecorder) ListAccountPostings(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountPostings", reflect.TypeOf((*MockStore)(nil).ListAccountPostings), arg0, arg1)
+}
+
+// ListAvailableDrivers mocks base method.
+func (m *MockStore) ListAvailableDrivers(arg0 context.Context, arg1 db.ListAvailableDriversParams) ([]db.Driver, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAvailableDrivers", arg0, arg1)
+	ret0, _ := ret[0].([]db.Driver)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAvailableDrivers indicates an expected call of ListAvailableDrivers.
+func (mr *MockStoreMockRecorder) ListAvailableDrivers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAvailableDrivers", reflect.TypeOf((*MockStore)(nil).ListAvailableDrivers), arg0, arg1)
+}
+
+// ListCars mocks base method.
+func (m *MockStore) ListCars(arg0 context.Context) ([]db.Car, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCars", arg0)
+	ret0, _ := ret[0].([]db.Car)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCars indicates an expected call of ListCars.
+func (mr *MockStoreMockRecorder) ListCars(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCars", reflect.TypeOf((*MockStore)(nil).ListCars), arg0)
+}
+
+// ListDriverTrips mocks base method.
+func (m *MockStore) ListDriverTrips(arg0 context.Context, arg1 db.ListDriverTripsParams) ([]db.Trip, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDriverTrips", arg0, arg1)
+	ret0, _ := ret[0].([]db.Trip)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDriverTrips indicates an expected call of ListDriverTrips.
+func (mr *MockStoreMockRecorder) ListDriverTrips(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDriverTrips", reflect.TypeOf((*MockStore)(nil).ListDriverTrips), arg0, arg1)
+}
+
+// ListDrivers mocks base method.
+func (m *MockStore) ListDrivers(arg0 context.Context) ([]db.Driver, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDrivers", arg0)
+	ret0, _ := ret[0].([]db.Driver)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDrivers indicates an expected call of ListDrivers.
+func (mr *MockStoreMockRecorder) ListDrivers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDrivers", reflect.TypeOf((*MockStore)(nil).ListDrivers), arg0)
+}
+
+// ListDueScheduledTrips mocks base method.
+func (m *MockStore) ListDueScheduledTrips(arg0 context.Context, arg1 sql.NullTime) ([]db.Trip, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDueScheduledTrips", arg0, arg1)
+	ret0, _ := ret[0].([]db.Trip)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDueScheduledTrips indicates an expected call of ListDueScheduledTrips.
+func (mr *MockStoreMockRecorder) ListDueScheduledTrips(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDueScheduledTrips", reflect.TypeOf((*MockStore)(nil).ListDueScheduledTrips), arg0, arg1)
+}
+
+// ListExpiredScheduledTrips mocks base method.
+func (m *MockStore) ListExpiredScheduledTrips(arg0 context.Context, arg1 sql.NullTime) ([]db.Trip, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpiredScheduledTrips", arg0, arg1)
+	ret0, _ := ret[0].([]db.Trip)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpiredScheduledTrips indicates an expected call of ListExpiredScheduledTrips.
+func (mr *MockStoreMockRecorder) ListExpiredScheduledTrips(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpiredScheduledTrips", reflect.TypeOf((*MockStore)(nil).ListExpiredScheduledTrips), arg0, arg1)
+}
+
+// ListFareRules mocks base method.
+func (m *MockStore) ListFareRules(arg0 context.Context) ([]db.FareRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFareRules", arg0)
+	ret0, _ := ret[0].([]db.FareRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFareRules indicates an expected call of ListFareRules.
+func (mr *MockStoreMockRecorder) ListFareRules(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFareRules", reflect.TypeOf((*MockStore)(nil).ListFareRules), arg0)
+}
+
+// ListPassengerTrips mocks base method.
+func (m *MockStore) ListPassengerTrips(arg0 context.Context, arg1 db.ListPassengerTripsParams) ([]db.Trip, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPassengerTrips", arg0, arg1)
+	ret0, _ := ret[0].([]db.Trip)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPassengerTrips indicates an expected call of ListPassengerTrips.
+func (mr *MockStoreMockRecorder) ListPassengerTrips(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPassengerTrips", reflect.TypeOf((*MockStore)(nil).ListPassengerTrips), arg0, arg1)
+}
+
+// ListPassengers mocks base method.
+func (m *MockStore) ListPassengers(arg0 context.Context) ([]db.Passenger, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPassengers", arg0)
+	ret0, _ := ret[0].([]db.Passenger)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPassengers indicates an expected call of ListPassengers.
+func (mr *MockStoreMockRecorder) ListPassengers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPassengers", reflect.TypeOf((*MockStore)(nil).ListPassengers), arg0)
+}
+
+// ListScheduledTripsToRemind mocks base method.
+func (m *MockStore) ListScheduledTripsToRemind(arg0 context.Context, arg1 sql.NullTime) ([]db.Trip, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduledTripsToRemind", arg0, arg1)
+	ret0, _ := ret[0].([]db.Trip)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduledTripsToRemind indicates an expected call of ListScheduledTripsToRemind.
+func (mr *MockStoreMockRecorder) ListScheduledTripsToRemind(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledTripsToRemind", reflect.TypeOf((*MockStore)(nil).ListScheduledTripsToRemind), arg0, arg1)
+}
+
+// ListSubscriptions mocks base method.
+func (m *MockStore) ListSubscriptions(arg0 context.Context) ([]db.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubscriptions", arg0)
+	ret0, _ := ret[0].([]db.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubscriptions indicates an expected call of ListSubscriptions.
+func (mr *MockStoreMockRecorder) ListSubscriptions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubscriptions", reflect.TypeOf((*MockStore)(nil).ListSubscriptions), arg0)
+}
+
+// ListTripEvents mocks base method.
+func (m *MockStore) ListTripEvents(arg0 context.Context, arg1 int64) ([]db.TripEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTripEvents", arg0, arg1)
+	ret0, _ := ret[0].([]db.TripEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTripEvents indicates an expected call of ListTripEvents.
+func (mr *MockStoreMockRecorder) ListTripEvents(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTripEvents", reflect.TypeOf((*MockStore)(nil).ListTripEvents), arg0, arg1)
+}
+
+// ListTripHistory mocks base method.
+func (m *MockStore) ListTripHistory(arg0 context.Context, arg1 db.ListTripHistoryParams) ([]db.Trip, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTripHistory", arg0, arg1)
+	ret0, _ := ret[0].([]db.Trip)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTripHistory indicates an expected call of ListTripHistory.
+func (mr *MockStoreMockRecorder) ListTripHistory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTripHistory", reflect.TypeOf((*MockStore)(nil).ListTripHistory), arg0, arg1)
+}
+
+// ListTripRatings mocks base method.
+func (m *MockStore) ListTripRatings(arg0 context.Context, arg1 int64) ([]db.TripRating, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTripRatings", arg0, arg1)
+	ret0, _ := ret[0].([]db.TripRating)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTripRatings indicates an expected call of ListTripRatings.
+func (mr *MockStoreMockRecorder) ListTripRatings(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTripRatings", reflect.TypeOf((*MockStore)(nil).ListTripRatings), arg0, arg1)
+}
+
+// ListTripStops mocks base method.
+func (m *MockStore) ListTripStops(arg0 context.Context, arg1 int64) ([]db.TripStop, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTripStops", arg0, arg1)
+	ret0, _ := ret[0].([]db.TripStop)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTripStops indicates an expected call of ListTripStops.
+func (mr *MockStoreMockRecorder) ListTripStops(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTripStops", reflect.TypeOf((*MockStore)(nil).ListTripStops), arg0, arg1)
+}
+
+// ListTrips mocks base method.
+func (m *MockStore) ListTrips(arg0 context.Context, arg1 db.ListTripsParams) ([]db.Trip, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTrips", arg0, arg1)
+	ret0, _ := ret[0].([]db.Trip)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTrips indicates an expected call of ListTrips.
+func (mr *MockStoreMockRecorder) ListTrips(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrips", reflect.TypeOf((*MockStore)(nil).ListTrips), arg0, arg1)
+}
+
+// MarkTripReminderSent mocks base method.
+func (m *MockStore) MarkTripReminderSent(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkTripReminderSent", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkTripReminderSent indicates an expected call of MarkTripReminderSent.
+func (mr *MockStoreMockRecorder) MarkTripReminderSent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkTripReminderSent", reflect.TypeOf((*MockStore)(nil).MarkTripReminderSent), arg0, arg1)
+}
+
+// RateTripTx mocks base method.
+func (m *MockStore) RateTripTx(arg0 context.Context, arg1 db.RateTripTxParams) (db.RateTripTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RateTripTx", arg0, arg1)
+	ret0, _ := ret[0].(db.RateTripTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RateTripTx indicates an expected call of RateTripTx.
+func (mr *MockStoreMockRecorder) RateTripTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RateTripTx", reflect.TypeOf((*MockStore)(nil).RateTripTx), arg0, arg1)
+}
+
+// RefundPaymentTx mocks base method.
+func (m *MockStore) RefundPaymentTx(arg0 context.Context, arg1 db.RefundPaymentTxParams) (db.RefundPaymentTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefundPaymentTx", arg0, arg1)
+	ret0, _ := ret[0].(db.RefundPaymentTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefundPaymentTx indicates an expected call of RefundPaymentTx.
+func (mr *MockStoreMockRecorder) RefundPaymentTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefundPaymentTx", reflect.TypeOf((*MockStore)(nil).RefundPaymentTx), arg0, arg1)
+}
+
+// RefundTripTx mocks base method.
+func (m *MockStore) RefundTripTx(arg0 context.Context, arg1 db.RefundTripTxParams) (db.RefundTripTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefundTripTx", arg0, arg1)
+	ret0, _ := ret[0].(db.RefundTripTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefundTripTx indicates an expected call of RefundTripTx.
+func (mr *MockStoreMockRecorder) RefundTripTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefundTripTx", reflect.TypeOf((*MockStore)(nil).RefundTripTx), arg0, arg1)
+}
+
+// ReleasePaymentRefund mocks base method.
+func (m *MockStore) ReleasePaymentRefund(arg0 context.Context, arg1 db.ReleasePaymentRefundParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleasePaymentRefund", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleasePaymentRefund indicates an expected call of ReleasePaymentRefund.
+func (mr *MockStoreMockRecorder) ReleasePaymentRefund(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleasePaymentRefund", reflect.TypeOf((*MockStore)(nil).ReleasePaymentRefund), arg0, arg1)
+}
+
+// ReservePaymentRefund mocks base method.
+func (m *MockStore) ReservePaymentRefund(arg0 context.Context, arg1 db.ReservePaymentRefundParams) (db.PaymentIntent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReservePaymentRefund", arg0, arg1)
+	ret0, _ := ret[0].(db.PaymentIntent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReservePaymentRefund indicates an expected call of ReservePaymentRefund.
+func (mr *MockStoreMockRecorder) ReservePaymentRefund(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReservePaymentRefund", reflect.TypeOf((*MockStore)(nil).ReservePaymentRefund), arg0, arg1)
+}
+
+// SumTripJournalEntries mocks base method.
+func (m *MockStore) SumTripJournalEntries(arg0 context.Context, arg1 db.SumTripJournalEntriesParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SumTripJournalEntries", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SumTripJournalEntries indicates an expected call of SumTripJournalEntries.
+func (mr *MockStoreMockRecorder) SumTripJournalEntries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumTripJournalEntries", reflect.TypeOf((*MockStore)(nil).SumTripJournalEntries), arg0, arg1)
+}
+
+// UpdateCar mocks base method.
+func (m *MockStore) UpdateCar(arg0 context.Context, arg1 db.UpdateCarParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCar", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCar indicates an expected call of UpdateCar.
+func (mr *MockStoreMockRecorder) UpdateCar(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCar", reflect.TypeOf((*MockStore)(nil).UpdateCar), arg0, arg1)
+}
+
+// UpdateDriver mocks base method.
+func (m *MockStore) UpdateDriver(arg0 context.Context, arg1 db.UpdateDriverParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDriver", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDriver indicates an expected call of UpdateDriver.
+func (mr *MockStoreMockRecorder) UpdateDriver(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDriver", reflect.TypeOf((*MockStore)(nil).UpdateDriver), arg0, arg1)
+}
+
+// UpdateDriverBusyStatus mocks base method.
+func (m *MockStore) UpdateDriverBusyStatus(arg0 context.Context, arg1 db.UpdateDriverBusyStatusParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDriverBusyStatus", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDriverBusyStatus indicates an expected call of UpdateDriverBusyStatus.
+func (mr *MockStoreMockRecorder) UpdateDriverBusyStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDriverBusyStatus", reflect.TypeOf((*MockStore)(nil).UpdateDriverBusyStatus), arg0, arg1)
+}
+
+// UpdateDriverRating mocks base method.
+func (m *MockStore) UpdateDriverRating(arg0 context.Context, arg1 db.UpdateDriverRatingParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDriverRating", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDriverRating indicates an expected call of UpdateDriverRating.
+func (mr *MockStoreMockRecorder) UpdateDriverRating(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDriverRating", reflect.TypeOf((*MockStore)(nil).UpdateDriverRating), arg0, arg1)
+}
+
+// UpdatePassenger mocks base method.
+func (m *MockStore) UpdatePassenger(arg0 context.Context, arg1 db.UpdatePassengerParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassenger", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassenger indicates an expected call of UpdatePassenger.
+func (mr *MockStoreMockRecorder) UpdatePassenger(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassenger", reflect.TypeOf((*MockStore)(nil).UpdatePassenger), arg0, arg1)
+}
+
+// UpdatePassengerRating mocks base method.
+func (m *MockStore) UpdatePassengerRating(arg0 context.Context, arg1 db.UpdatePassengerRatingParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassengerRating", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassengerRating indicates an expected call of UpdatePassengerRating.
+func (mr *MockStoreMockRecorder) UpdatePassengerRating(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassengerRating", reflect.TypeOf((*MockStore)(nil).UpdatePassengerRating), arg0, arg1)
+}
+
+// UpdatePaymentIntentRefund mocks base method.
+func (m *MockStore) UpdatePaymentIntentRefund(arg0 context.Context, arg1 db.UpdatePaymentIntentRefundParams) (db.PaymentIntent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePaymentIntentRefund", arg0, arg1)
+	ret0, _ := ret[0].(db.PaymentIntent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePaymentIntentRefund indicates an expected call of UpdatePaymentIntentRefund.
+func (mr *MockStoreMockRecorder) UpdatePaymentIntentRefund(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePaymentIntentRefund", reflect.TypeOf((*MockStore)(nil).UpdatePaymentIntentRefund), arg0, arg1)
+}
+
+// UpdatePaymentIntentStatus mocks base method.
+func (m *MockStore) UpdatePaymentIntentStatus(arg0 context.Context, arg1 db.UpdatePaymentIntentStatusParams) (db.PaymentIntent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePaymentIntentStatus", arg0, arg1)
+	ret0, _ := ret[0].(db.PaymentIntent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePaymentIntentStatus indicates an expected call of UpdatePaymentIntentStatus.
+func (mr *MockStoreMockRecorder) UpdatePaymentIntentStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePaymentIntentStatus", reflect.TypeOf((*MockStore)(nil).UpdatePaymentIntentStatus), arg0, arg1)
+}
+
+// UpdateSubscription mocks base method.
+func (m *MockStore) UpdateSubscription(arg0 context.Context, arg1 db.UpdateSubscriptionParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSubscription", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSubscription indicates an expected call of UpdateSubscription.
+func (mr *MockStoreMockRecorder) UpdateSubscription(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSubscription", reflect.TypeOf((*MockStore)(nil).UpdateSubscription), arg0, arg1)
+}
+
+// UpdateTripStatus mocks base method.
+func (m *MockStore) UpdateTripStatus(arg0 context.Context, arg1 db.UpdateTripStatusParams) (db.Trip, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTripStatus", arg0, arg1)
+	ret0, _ := ret[0].(db.Trip)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTripStatus indicates an expected call of UpdateTripStatus.
+func (mr *MockStoreMockRecorder) UpdateTripStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTripStatus", reflect.TypeOf((*MockStore)(nil).UpdateTripStatus), arg0, arg1)
+}
+
+// UpdateTripStatusTx mocks base method.
+func (m *MockStore) UpdateTripStatusTx(arg0 context.Context, arg1 db.UpdateTripStatusTxParams) (db.UpdateTripStatusTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTripStatusTx", arg0, arg1)
+	ret0, _ := ret[0].(db.UpdateTripStatusTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTripStatusTx indicates an expected call of UpdateTripStatusTx.
+func (mr *MockStoreMockRecorder) UpdateTripStatusTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTripStatusTx", reflect.TypeOf((*MockStore)(nil).UpdateTripStatusTx), arg0, arg1)
+}
@@ -3,17 +3,29 @@
 -- name: GetDriver :one
 SELECT * FROM drivers WHERE id = $1 LIMIT 1;
 
+-- name: GetDriverForUpdate :one
+SELECT * FROM drivers WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
 -- name: GetDriverByMobile :one
 SELECT * FROM drivers WHERE mobile = $1 LIMIT 1;
 
 -- name: ListDrivers :many
 SELECT * FROM drivers ORDER BY full_name;
 
+-- name: ListAvailableDrivers :many
+SELECT * FROM drivers
+WHERE id = ANY(@ids::bigint[])
+  AND online_status = true
+  AND subscription_status = true
+  AND is_busy = false
+  AND (sqlc.narg('car_type')::varchar IS NULL OR car_type = sqlc.narg('car_type'));
+
 -- name: CreateDriver :one
 INSERT INTO drivers (
-  hashed_password, full_name, driving_license, mobile, car_id, car_type, car_image, online_status, rating, profile_status, subscription_status, subscription_package, subscription_amount, subscription_validity
+  hashed_password, full_name, driving_license, mobile, car_id, car_type, car_image, online_status, profile_status, subscription_status, subscription_package, subscription_amount, subscription_validity
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
 )
 RETURNING *;
 
@@ -35,5 +47,15 @@ SET hashed_password = $2,
     subscription_validity = $15
 WHERE id = $1;
 
+-- name: UpdateDriverBusyStatus :exec
+UPDATE drivers
+SET is_busy = $2
+WHERE id = $1;
+
+-- name: UpdateDriverRating :exec
+UPDATE drivers
+SET rating = $2
+WHERE id = $1;
+
 -- name: DeleteDriver :exec
 DELETE FROM drivers WHERE id = $1;
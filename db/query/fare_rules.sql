@@ -0,0 +1,6 @@
+-- Fare rules
+-- name: GetFareRule :one
+SELECT * FROM fare_rules WHERE car_type = $1 LIMIT 1;
+
+-- name: ListFareRules :many
+SELECT * FROM fare_rules ORDER BY car_type;
@@ -0,0 +1,55 @@
+-- Ledger
+-- name: GetOrCreateAccount :one
+INSERT INTO accounts (
+  owner_type, owner_id
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (owner_type, owner_id) DO UPDATE SET owner_type = EXCLUDED.owner_type
+RETURNING *;
+
+-- name: GetAccountByOwner :one
+SELECT * FROM accounts
+WHERE owner_type = $1 AND owner_id = $2 LIMIT 1;
+
+-- name: AddAccountBalance :one
+UPDATE accounts
+SET balance = balance + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CreateJournalEntry :one
+INSERT INTO journal_entries (
+  kind, trip_id, amount, description
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: SumTripJournalEntries :one
+SELECT COALESCE(sum(amount), 0)::bigint AS total
+FROM journal_entries
+WHERE trip_id = $1 AND kind = $2;
+
+-- name: CreatePosting :one
+INSERT INTO postings (
+  entry_id, account_id, amount, balance_after
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: ListAccountPostings :many
+SELECT postings.id, postings.amount, postings.balance_after, postings.created_at,
+       journal_entries.kind, journal_entries.description, trips.booking_id
+FROM postings
+JOIN journal_entries ON journal_entries.id = postings.entry_id
+LEFT JOIN trips ON trips.id = journal_entries.trip_id
+WHERE postings.account_id = $1
+ORDER BY postings.id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: CountAccountPostings :one
+SELECT count(*) FROM postings
+WHERE account_id = $1;
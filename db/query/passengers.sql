@@ -10,9 +10,9 @@ SELECT * FROM passengers ORDER BY full_name;
 
 -- name: CreatePassenger :one
 INSERT INTO passengers (
-  hashed_password, full_name, email, rating
+  hashed_password, full_name, email
 ) VALUES (
-  $1, $2, $3, $4
+  $1, $2, $3
 )
 RETURNING *;
 
@@ -24,5 +24,10 @@ SET hashed_password = $2,
     rating = $5
 WHERE id = $1;
 
+-- name: UpdatePassengerRating :exec
+UPDATE passengers
+SET rating = $2
+WHERE id = $1;
+
 -- name: DeletePassenger :exec
 DELETE FROM passengers WHERE id = $1;
\ No newline at end of file
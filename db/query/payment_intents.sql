@@ -0,0 +1,56 @@
+-- Payment intents
+-- name: CreatePaymentIntent :one
+INSERT INTO payment_intents (
+  provider, provider_ref, trip_id, subscription_id, payer_type, payer_id, amount, captured_amount, status, failure_reason
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+RETURNING *;
+
+-- name: GetPaymentIntentForUpdate :one
+SELECT * FROM payment_intents
+WHERE id = $1 LIMIT 1
+FOR UPDATE;
+
+-- name: GetPaymentIntentByProviderRef :one
+SELECT * FROM payment_intents
+WHERE provider = $1 AND provider_ref = $2 LIMIT 1;
+
+-- name: GetTripPaymentIntent :one
+SELECT * FROM payment_intents
+WHERE trip_id = $1 AND status <> 'failed'
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: UpdatePaymentIntentStatus :one
+UPDATE payment_intents
+SET status = sqlc.arg(status),
+    captured_amount = sqlc.arg(captured_amount),
+    failure_reason = sqlc.arg(failure_reason),
+    updated_at = now()
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(current_status)
+RETURNING *;
+
+-- name: UpdatePaymentIntentRefund :one
+UPDATE payment_intents
+SET refunded_amount = $1,
+    refund_pending_amount = $2,
+    status = $3,
+    updated_at = now()
+WHERE id = $4
+RETURNING *;
+
+-- name: ReservePaymentRefund :one
+UPDATE payment_intents
+SET refund_pending_amount = refund_pending_amount + sqlc.arg(amount),
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+  AND status = 'captured'
+  AND refunded_amount + refund_pending_amount + sqlc.arg(amount) <= captured_amount
+RETURNING *;
+
+-- name: ReleasePaymentRefund :exec
+UPDATE payment_intents
+SET refund_pending_amount = GREATEST(refund_pending_amount - sqlc.arg(amount), 0),
+    updated_at = now()
+WHERE id = sqlc.arg(id);
@@ -0,0 +1,21 @@
+-- Sessions
+-- name: CreateSession :one
+INSERT INTO sessions (
+  id, username, role, user_id, refresh_token, user_agent, client_ip, is_blocked, expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: GetSession :one
+SELECT * FROM sessions WHERE id = $1 LIMIT 1;
+
+-- name: BlockSession :execrows
+UPDATE sessions
+SET is_blocked = true
+WHERE id = $1 AND role = $2 AND user_id = $3;
+
+-- name: BlockUserSessions :exec
+UPDATE sessions
+SET is_blocked = true
+WHERE role = $1 AND user_id = $2 AND is_blocked = false;
@@ -0,0 +1,13 @@
+-- Trip events
+-- name: CreateTripEvent :one
+INSERT INTO trip_events (
+  trip_id, from_status, to_status, actor_type, actor_id, lat, long
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: ListTripEvents :many
+SELECT * FROM trip_events
+WHERE trip_id = $1
+ORDER BY created_at, id;
@@ -0,0 +1,23 @@
+-- Trip ratings
+-- name: CreateTripRating :one
+INSERT INTO trip_ratings (
+  trip_id, rated_by, passenger_id, driver_id, score, comment
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: ListTripRatings :many
+SELECT * FROM trip_ratings
+WHERE trip_id = $1
+ORDER BY created_at;
+
+-- name: GetDriverRatingSummary :one
+SELECT count(*) AS rating_count, COALESCE(sum(score), 0)::bigint AS score_sum
+FROM trip_ratings
+WHERE driver_id = $1 AND rated_by = 'passenger';
+
+-- name: GetPassengerRatingSummary :one
+SELECT count(*) AS rating_count, COALESCE(sum(score), 0)::bigint AS score_sum
+FROM trip_ratings
+WHERE passenger_id = $1 AND rated_by = 'driver';
@@ -0,0 +1,13 @@
+-- Trip receipts
+-- name: CreateTripReceipt :one
+INSERT INTO trip_receipts (
+  trip_id, receipt_number, document, pdf
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (trip_id) DO UPDATE SET trip_id = EXCLUDED.trip_id
+RETURNING *;
+
+-- name: GetTripReceipt :one
+SELECT * FROM trip_receipts
+WHERE trip_id = $1 LIMIT 1;
@@ -0,0 +1,25 @@
+-- Trip stops
+-- name: CreateTripStop :one
+INSERT INTO trip_stops (
+  trip_id, stop_order, location, lat, long
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: ListTripStops :many
+SELECT * FROM trip_stops
+WHERE trip_id = $1
+ORDER BY stop_order;
+
+-- name: ArriveTripStop :one
+UPDATE trip_stops
+SET status = 'arrived', arrived_at = now()
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: DepartTripStop :one
+UPDATE trip_stops
+SET status = 'departed', departed_at = now()
+WHERE id = $1 AND status = 'arrived'
+RETURNING *;
@@ -6,34 +6,137 @@ SELECT * FROM trips WHERE id = $1 LIMIT 1;
 -- name: GetTripByBookingID :one
 SELECT * FROM trips WHERE booking_id = $1 LIMIT 1;
 
+-- name: GetTripByBookingIDForUpdate :one
+SELECT * FROM trips WHERE booking_id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetTripForUpdate :one
+SELECT * FROM trips WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetDriverActiveTrip :one
+SELECT * FROM trips
+WHERE driver_id = $1 AND trip_status IN ('accepted', 'driver_arriving', 'driver_arrived', 'in_progress')
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: CountOpenTripsInArea :one
+SELECT count(*) FROM trips
+WHERE trip_status IN ('requested', 'bidding')
+  AND created_at >= @since
+  AND pickup_lat >= @min_lat AND pickup_lat < @max_lat
+  AND pickup_long >= @min_long AND pickup_long < @max_long;
+
+-- name: ListDueScheduledTrips :many
+SELECT * FROM trips
+WHERE trip_status = 'scheduled' AND scheduled_at <= $1
+ORDER BY scheduled_at;
+
+-- name: ListScheduledTripsToRemind :many
+SELECT * FROM trips
+WHERE trip_status = 'scheduled' AND reminder_sent_at IS NULL AND scheduled_at <= $1
+ORDER BY scheduled_at;
+
+-- name: MarkTripReminderSent :execrows
+UPDATE trips
+SET reminder_sent_at = now()
+WHERE id = $1 AND reminder_sent_at IS NULL;
+
+-- name: ListExpiredScheduledTrips :many
+SELECT * FROM trips
+WHERE trip_status IN ('requested', 'bidding') AND scheduled_at < $1
+ORDER BY scheduled_at;
+
 -- name: ListTrips :many
 SELECT * FROM trips ORDER BY created_at DESC LIMIT $1 OFFSET $2;
 
+-- name: ListPassengerTrips :many
+SELECT * FROM trips
+WHERE passenger_id = $1
+ORDER BY created_at DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: ListDriverTrips :many
+SELECT * FROM trips
+WHERE driver_id = $1
+ORDER BY created_at DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: ListTripHistory :many
+SELECT * FROM trips
+WHERE (sqlc.narg('passenger_id')::bigint IS NULL OR passenger_id = sqlc.narg('passenger_id'))
+  AND (sqlc.narg('driver_id')::bigint IS NULL OR driver_id = sqlc.narg('driver_id'))
+  AND (sqlc.narg('trip_status')::varchar IS NULL OR trip_status = sqlc.narg('trip_status'))
+  AND (sqlc.narg('car_type')::varchar IS NULL OR car_type = sqlc.narg('car_type'))
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR created_at >= sqlc.narg('created_from'))
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR created_at < sqlc.narg('created_to'))
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT @page_size;
+
+-- name: CountTripHistory :one
+SELECT count(*) FROM trips
+WHERE (sqlc.narg('passenger_id')::bigint IS NULL OR passenger_id = sqlc.narg('passenger_id'))
+  AND (sqlc.narg('driver_id')::bigint IS NULL OR driver_id = sqlc.narg('driver_id'))
+  AND (sqlc.narg('trip_status')::varchar IS NULL OR trip_status = sqlc.narg('trip_status'))
+  AND (sqlc.narg('car_type')::varchar IS NULL OR car_type = sqlc.narg('car_type'))
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR created_at >= sqlc.narg('created_from'))
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR created_at < sqlc.narg('created_to'));
+
 -- name: CreateTrip :one
 INSERT INTO trips (
-  booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare
+  booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, passenger_id, surge_multiplier, scheduled_at
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18
 )
 RETURNING *;
 
 -- name: UpdateTripStatus :one
 UPDATE trips
-SET trip_status = $2
-WHERE booking_id = $1
+SET
+  trip_status = @trip_status,
+  arrived_at = CASE WHEN @trip_status::varchar = 'driver_arrived' THEN now() ELSE arrived_at END,
+  started_at = CASE WHEN @trip_status::varchar = 'in_progress' THEN now() ELSE started_at END
+WHERE booking_id = @booking_id AND trip_status = @current_status
+RETURNING *;
+
+-- name: AssignTripDriver :one
+UPDATE trips
+SET
+  trip_status = @trip_status,
+  driver_id = @driver_id,
+  driver_name = @driver_name,
+  driver_mobile = @driver_mobile,
+  car_id = @car_id,
+  car_type = @car_type,
+  car_image = @car_image,
+  fare = @fare,
+  accepted_at = now()
+WHERE booking_id = @booking_id AND trip_status = @current_status
 RETURNING *;
 
--- name: TripAccept :one 
+-- name: CompleteTrip :one
 UPDATE trips
 SET
-  trip_status = $2,
-  driver_id = $3,
-  driver_name = $4,
-  driver_mobile = $5,
-  fare = $6
-WHERE booking_id = $1
+  trip_status = @trip_status,
+  fare = @fare,
+  completed_at = now()
+WHERE booking_id = @booking_id AND trip_status = @current_status
 RETURNING *;
 
+-- name: CancelTrip :one
+UPDATE trips
+SET
+  trip_status = @trip_status,
+  cancelled_by = @cancelled_by,
+  cancel_reason = @cancel_reason,
+  cancellation_fee = @cancellation_fee,
+  cancelled_at = now()
+WHERE booking_id = @booking_id AND trip_status = @current_status
+RETURNING *;
 
 -- name: DeleteTrip :exec
 DELETE FROM trips WHERE id = $1;
@@ -7,32 +7,34 @@ package db
 
 import (
 	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
 )
 
 const createDriver = `-- name: CreateDriver :one
 INSERT INTO drivers (
-  hashed_password, full_name, driving_license, mobile, car_id, car_type, car_image, online_status, rating, profile_status, subscription_status, subscription_package, subscription_amount, subscription_validity
+  hashed_password, full_name, driving_license, mobile, car_id, car_type, car_image, online_status, profile_status, subscription_status, subscription_package, subscription_amount, subscription_validity
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
 )
-RETURNING id, hashed_password, full_name, driving_license, mobile, car_id, car_type, car_image, online_status, rating, profile_status, subscription_status, subscription_package, subscription_amount, subscription_validity, subscription_expire_at, password_changed_at, created_at
+RETURNING id, hashed_password, full_name, driving_license, mobile, car_id, car_type, car_image, online_status, rating, profile_status, subscription_status, subscription_package, subscription_amount, subscription_validity, subscription_expire_at, password_changed_at, created_at, is_busy
 `
 
 type CreateDriverParams struct {
-	HashedPassword       string  `json:"hashed_password"`
-	FullName             string  `json:"full_name"`
-	DrivingLicense       string  `json:"driving_license"`
-	Mobile               string  `json:"mobile"`
-	CarID                int64   `json:"car_id"`
-	CarType              string  `json:"car_type"`
-	CarImage             string  `json:"car_image"`
-	OnlineStatus         bool    `json:"online_status"`
-	Rating               float64 `json:"rating"`
-	ProfileStatus        int32   `json:"profile_status"`
-	SubscriptionStatus   bool    `json:"subscription_status"`
-	SubscriptionPackage  string  `json:"subscription_package"`
-	SubscriptionAmount   string  `json:"subscription_amount"`
-	SubscriptionValidity int32   `json:"subscription_validity"`
+	HashedPassword       string `json:"hashed_password"`
+	FullName             string `json:"full_name"`
+	DrivingLicense       string `json:"driving_license"`
+	Mobile               string `json:"mobile"`
+	CarID                int64  `json:"car_id"`
+	CarType              string `json:"car_type"`
+	CarImage             string `json:"car_image"`
+	OnlineStatus         bool   `json:"online_status"`
+	ProfileStatus        int32  `json:"profile_status"`
+	SubscriptionStatus   bool   `json:"subscription_status"`
+	SubscriptionPackage  string `json:"subscription_package"`
+	SubscriptionAmount   string `json:"subscription_amount"`
+	SubscriptionValidity int32  `json:"subscription_validity"`
 }
 
 func (q *Queries) CreateDriver(ctx context.Context, arg CreateDriverParams) (Driver, error) {
@@ -45,7 +47,6 @@ func (q *Queries) CreateDriver(ctx context.Context, arg CreateDriverParams) (Dri
 		arg.CarType,
 		arg.CarImage,
 		arg.OnlineStatus,
-		arg.Rating,
 		arg.ProfileStatus,
 		arg.SubscriptionStatus,
 		arg.SubscriptionPackage,
@@ -72,6 +73,7 @@ func (q *Queries) CreateDriver(ctx context.Context, arg CreateDriverParams) (Dri
 		&i.SubscriptionExpireAt,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.IsBusy,
 	)
 	return i, err
 }
@@ -86,7 +88,7 @@ func (q *Queries) DeleteDriver(ctx context.Context, id int64) error {
 }
 
 const getDriver = `-- name: GetDriver :one
-SELECT id, hashed_password, full_name, driving_license, mobile, car_id, car_type, car_image, online_status, rating, profile_status, subscription_status, subscription_package, subscription_amount, subscription_validity, subscription_expire_at, password_changed_at, created_at FROM drivers WHERE id = $1 LIMIT 1
+SELECT id, hashed_password, full_name, driving_license, mobile, car_id, car_type, car_image, online_status, rating, profile_status, subscription_status, subscription_package, subscription_amount, subscription_validity, subscription_expire_at, password_changed_at, created_at, is_busy FROM drivers WHERE id = $1 LIMIT 1
 `
 
 // Drivers
@@ -112,12 +114,13 @@ func (q *Queries) GetDriver(ctx context.Context, id int64) (Driver, error) {
 		&i.SubscriptionExpireAt,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.IsBusy,
 	)
 	return i, err
 }
 
 const getDriverByMobile = `-- name: GetDriverByMobile :one
-SELECT id, hashed_password, full_name, driving_license, mobile, car_id, car_type, car_image, online_status, rating, profile_status, subscription_status, subscription_package, subscription_amount, subscription_validity, subscription_expire_at, password_changed_at, created_at FROM drivers WHERE mobile = $1 LIMIT 1
+SELECT id, hashed_password, full_name, driving_license, mobile, car_id, car_type, car_image, online_status, rating, profile_status, subscription_status, subscription_package, subscription_amount, subscription_validity, subscription_expire_at, password_changed_at, created_at, is_busy FROM drivers WHERE mobile = $1 LIMIT 1
 `
 
 func (q *Queries) GetDriverByMobile(ctx context.Context, mobile string) (Driver, error) {
@@ -142,12 +145,102 @@ func (q *Queries) GetDriverByMobile(ctx context.Context, mobile string) (Driver,
 		&i.SubscriptionExpireAt,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.IsBusy,
 	)
 	return i, err
 }
 
+const getDriverForUpdate = `-- name: GetDriverForUpdate :one
+SELECT id, hashed_password, full_name, driving_license, mobile, car_id, car_type, car_image, online_status, rating, profile_status, subscription_status, subscription_package, subscription_amount, subscription_validity, subscription_expire_at, password_changed_at, created_at, is_busy FROM drivers WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetDriverForUpdate(ctx context.Context, id int64) (Driver, error) {
+	row := q.db.QueryRowContext(ctx, getDriverForUpdate, id)
+	var i Driver
+	err := row.Scan(
+		&i.ID,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.DrivingLicense,
+		&i.Mobile,
+		&i.CarID,
+		&i.CarType,
+		&i.CarImage,
+		&i.OnlineStatus,
+		&i.Rating,
+		&i.ProfileStatus,
+		&i.SubscriptionStatus,
+		&i.SubscriptionPackage,
+		&i.SubscriptionAmount,
+		&i.SubscriptionValidity,
+		&i.SubscriptionExpireAt,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsBusy,
+	)
+	return i, err
+}
+
+const listAvailableDrivers = `-- name: ListAvailableDrivers :many
+SELECT id, hashed_password, full_name, driving_license, mobile, car_id, car_type, car_image, online_status, rating, profile_status, subscription_status, subscription_package, subscription_amount, subscription_validity, subscription_expire_at, password_changed_at, created_at, is_busy FROM drivers
+WHERE id = ANY($1::bigint[])
+  AND online_status = true
+  AND subscription_status = true
+  AND is_busy = false
+  AND ($2::varchar IS NULL OR car_type = $2)
+`
+
+type ListAvailableDriversParams struct {
+	Ids     []int64        `json:"ids"`
+	CarType sql.NullString `json:"car_type"`
+}
+
+func (q *Queries) ListAvailableDrivers(ctx context.Context, arg ListAvailableDriversParams) ([]Driver, error) {
+	rows, err := q.db.QueryContext(ctx, listAvailableDrivers, pq.Array(arg.Ids), arg.CarType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Driver
+	for rows.Next() {
+		var i Driver
+		if err := rows.Scan(
+			&i.ID,
+			&i.HashedPassword,
+			&i.FullName,
+			&i.DrivingLicense,
+			&i.Mobile,
+			&i.CarID,
+			&i.CarType,
+			&i.CarImage,
+			&i.OnlineStatus,
+			&i.Rating,
+			&i.ProfileStatus,
+			&i.SubscriptionStatus,
+			&i.SubscriptionPackage,
+			&i.SubscriptionAmount,
+			&i.SubscriptionValidity,
+			&i.SubscriptionExpireAt,
+			&i.PasswordChangedAt,
+			&i.CreatedAt,
+			&i.IsBusy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listDrivers = `-- name: ListDrivers :many
-SELECT id, hashed_password, full_name, driving_license, mobile, car_id, car_type, car_image, online_status, rating, profile_status, subscription_status, subscription_package, subscription_amount, subscription_validity, subscription_expire_at, password_changed_at, created_at FROM drivers ORDER BY full_name
+SELECT id, hashed_password, full_name, driving_license, mobile, car_id, car_type, car_image, online_status, rating, profile_status, subscription_status, subscription_package, subscription_amount, subscription_validity, subscription_expire_at, password_changed_at, created_at, is_busy FROM drivers ORDER BY full_name
 `
 
 func (q *Queries) ListDrivers(ctx context.Context) ([]Driver, error) {
@@ -178,6 +271,7 @@ func (q *Queries) ListDrivers(ctx context.Context) ([]Driver, error) {
 			&i.SubscriptionExpireAt,
 			&i.PasswordChangedAt,
 			&i.CreatedAt,
+			&i.IsBusy,
 		); err != nil {
 			return nil, err
 		}
@@ -212,21 +306,20 @@ WHERE id = $1
 `
 
 type UpdateDriverParams struct {
-	ID                   int64   `json:"id"`
-	HashedPassword       string  `json:"hashed_password"`
-	FullName             string  `json:"full_name"`
-	DrivingLicense       string  `json:"driving_license"`
-	Mobile               string  `json:"mobile"`
-	CarID                int64   `json:"car_id"`
-	CarType              string  `json:"car_type"`
-	CarImage             string  `json:"car_image"`
-	OnlineStatus         bool    `json:"online_status"`
-	Rating               float64 `json:"rating"`
-	ProfileStatus        int32   `json:"profile_status"`
-	SubscriptionStatus   bool    `json:"subscription_status"`
-	SubscriptionPackage  string  `json:"subscription_package"`
-	SubscriptionAmount   string  `json:"subscription_amount"`
-	SubscriptionValidity int32   `json:"subscription_validity"`
+	ID                   int64  `json:"id"`
+	HashedPassword       string `json:"hashed_password"`
+	FullName             string `json:"full_name"`
+	DrivingLicense       string `json:"driving_license"`
+	Mobile               string `json:"mobile"`
+	CarID                int64  `json:"car_id"`
+	CarType              string `json:"car_type"`
+	CarImage             string `json:"car_image"`
+	OnlineStatus         bool   `json:"online_status"`
+	ProfileStatus        int32  `json:"profile_status"`
+	SubscriptionStatus   bool   `json:"subscription_status"`
+	SubscriptionPackage  string `json:"subscription_package"`
+	SubscriptionAmount   string `json:"subscription_amount"`
+	SubscriptionValidity int32  `json:"subscription_validity"`
 }
 
 func (q *Queries) UpdateDriver(ctx context.Context, arg UpdateDriverParams) error {
@@ -240,7 +333,6 @@ func (q *Queries) UpdateDriver(ctx context.Context, arg UpdateDriverParams) erro
 		arg.CarType,
 		arg.CarImage,
 		arg.OnlineStatus,
-		arg.Rating,
 		arg.ProfileStatus,
 		arg.SubscriptionStatus,
 		arg.SubscriptionPackage,
@@ -249,3 +341,35 @@ func (q *Queries) UpdateDriver(ctx context.Context, arg UpdateDriverParams) erro
 	)
 	return err
 }
+
+const updateDriverBusyStatus = `-- name: UpdateDriverBusyStatus :exec
+UPDATE drivers
+SET is_busy = $2
+WHERE id = $1
+`
+
+type UpdateDriverBusyStatusParams struct {
+	ID     int64 `json:"id"`
+	IsBusy bool  `json:"is_busy"`
+}
+
+func (q *Queries) UpdateDriverBusyStatus(ctx context.Context, arg UpdateDriverBusyStatusParams) error {
+	_, err := q.db.ExecContext(ctx, updateDriverBusyStatus, arg.ID, arg.IsBusy)
+	return err
+}
+
+const updateDriverRating = `-- name: UpdateDriverRating :exec
+UPDATE drivers
+SET rating = $2
+WHERE id = $1
+`
+
+type UpdateDriverRatingParams struct {
+	ID     int64   `json:"id"`
+	Rating float64 `json:"rating"`
+}
+
+func (q *Queries) UpdateDriverRating(ctx context.Context, arg UpdateDriverRatingParams) error {
+	_, err := q.db.ExecContext(ctx, updateDriverRating, arg.ID, arg.Rating)
+	return err
+}
@@ -0,0 +1,15 @@
+package db
+
+import "github.com/emonoid/toribook.git/fare"
+
+// NewFareRule returns the pricing rule of a stored fare rule.
+func NewFareRule(rule FareRule) fare.Rule {
+	return fare.Rule{
+		CarType:     rule.CarType,
+		BaseFare:    rule.BaseFare,
+		PerKm:       rule.PerKm,
+		PerMinute:   rule.PerMinute,
+		MinimumFare: rule.MinimumFare,
+		BookingFee:  rule.BookingFee,
+	}
+}
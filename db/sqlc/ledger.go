@@ -0,0 +1,308 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Owner types stored in accounts.owner_type.
+const (
+	AccountOwnerPassenger = "passenger"
+	AccountOwnerDriver    = "driver"
+	AccountOwnerPlatform  = "platform"
+)
+
+// PlatformOwnerID is the owner_id of the single platform account.
+const PlatformOwnerID = 0
+
+// Kinds stored in journal_entries.kind.
+const (
+	EntryKindTripFare   = "trip_fare"
+	EntryKindCommission = "commission"
+	EntryKindRefund     = "refund"
+	// EntryKindPayment is money collected from the passenger for a trip, by
+	// the driver in cash or by the platform through a card. Whatever of the
+	// fare it does not cover stays owed on the passenger's wallet.
+	EntryKindPayment = "payment"
+	// EntryKindPaymentRefund gives back part of a payment.
+	EntryKindPaymentRefund = "payment_refund"
+	// EntryKindCancellationFee charges the passenger for cancelling, or not
+	// showing up, after the driver arrived at the pickup.
+	EntryKindCancellationFee = "cancellation_fee"
+)
+
+var (
+	// ErrUnbalancedEntry is returned when the postings of a journal entry do
+	// not add up to zero.
+	ErrUnbalancedEntry = errors.New("journal entry postings do not balance")
+	// ErrRefundExceedsFare is returned when a refund would pay back more than
+	// what is left of the fare of the trip.
+	ErrRefundExceedsFare = errors.New("refund exceeds the refundable fare")
+)
+
+// Commission is the platform's cut of fare at rate, rounded to the nearest
+// whole taka.
+func Commission(fare int64, rate float64) int64 {
+	return int64(math.Round(float64(fare) * rate))
+}
+
+// ledgerPosting is one leg of a journal entry. Positive amounts credit the
+// account, negative ones debit it.
+type ledgerPosting struct {
+	AccountID int64
+	Amount    int64
+}
+
+// ownerAccount returns the wallet of an owner, opening it on first use.
+func ownerAccount(ctx context.Context, q *Queries, ownerType string, ownerID int64) (Account, error) {
+	return q.GetOrCreateAccount(ctx, GetOrCreateAccountParams{
+		OwnerType: ownerType,
+		OwnerID:   ownerID,
+	})
+}
+
+// postEntry writes a journal entry with its postings and moves the balances
+// of the accounts involved. Balances are updated in account id order so
+// concurrent entries touching the same accounts cannot deadlock.
+func postEntry(ctx context.Context, q *Queries, arg CreateJournalEntryParams, postings []ledgerPosting) (JournalEntry, error) {
+	var sum int64
+	for _, p := range postings {
+		sum += p.Amount
+	}
+	if sum != 0 {
+		return JournalEntry{}, ErrUnbalancedEntry
+	}
+
+	entry, err := q.CreateJournalEntry(ctx, arg)
+	if err != nil {
+		return JournalEntry{}, err
+	}
+
+	sorted := make([]ledgerPosting, 0, len(postings))
+	for _, p := range postings {
+		if p.Amount != 0 {
+			sorted = append(sorted, p)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].AccountID < sorted[j].AccountID })
+
+	for _, p := range sorted {
+		account, err := q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			Amount: p.Amount,
+			ID:     p.AccountID,
+		})
+		if err != nil {
+			return JournalEntry{}, err
+		}
+
+		_, err = q.CreatePosting(ctx, CreatePostingParams{
+			EntryID:      entry.ID,
+			AccountID:    p.AccountID,
+			Amount:       p.Amount,
+			BalanceAfter: account.Balance,
+		})
+		if err != nil {
+			return JournalEntry{}, err
+		}
+	}
+
+	return entry, nil
+}
+
+// tripAccounts are the wallets money moves between for a trip.
+type tripAccounts struct {
+	Passenger Account
+	Driver    Account
+	Platform  Account
+}
+
+func loadTripAccounts(ctx context.Context, q *Queries, trip Trip) (tripAccounts, error) {
+	var accounts tripAccounts
+	var err error
+
+	accounts.Passenger, err = ownerAccount(ctx, q, AccountOwnerPassenger, trip.PassengerID.Int64)
+	if err != nil {
+		return accounts, err
+	}
+	accounts.Driver, err = ownerAccount(ctx, q, AccountOwnerDriver, trip.DriverID.Int64)
+	if err != nil {
+		return accounts, err
+	}
+	accounts.Platform, err = ownerAccount(ctx, q, AccountOwnerPlatform, PlatformOwnerID)
+	return accounts, err
+}
+
+// postTripFare charges the passenger the fare of a completed trip, pays it
+// to the driver and takes the platform commission from the driver. Trips
+// without a passenger, a driver or a fare move no money.
+func postTripFare(ctx context.Context, q *Queries, trip Trip, commissionRate float64) error {
+	if !trip.PassengerID.Valid || !trip.DriverID.Valid || !trip.Fare.Valid || trip.Fare.Int64 <= 0 {
+		return nil
+	}
+
+	accounts, err := loadTripAccounts(ctx, q, trip)
+	if err != nil {
+		return err
+	}
+
+	tripID := sql.NullInt64{Int64: trip.ID, Valid: true}
+	fare := trip.Fare.Int64
+
+	_, err = postEntry(ctx, q, CreateJournalEntryParams{
+		Kind:        EntryKindTripFare,
+		TripID:      tripID,
+		Amount:      fare,
+		Description: fmt.Sprintf("Fare for trip %s", trip.BookingID),
+	}, []ledgerPosting{
+		{AccountID: accounts.Passenger.ID, Amount: -fare},
+		{AccountID: accounts.Driver.ID, Amount: fare},
+	})
+	if err != nil {
+		return err
+	}
+
+	commission := Commission(fare, commissionRate)
+	if commission <= 0 {
+		return nil
+	}
+
+	_, err = postEntry(ctx, q, CreateJournalEntryParams{
+		Kind:        EntryKindCommission,
+		TripID:      tripID,
+		Amount:      commission,
+		Description: fmt.Sprintf("Commission for trip %s", trip.BookingID),
+	}, []ledgerPosting{
+		{AccountID: accounts.Driver.ID, Amount: -commission},
+		{AccountID: accounts.Platform.ID, Amount: commission},
+	})
+	return err
+}
+
+// postCancellationFee charges the passenger the cancellation fee of a
+// cancelled trip and pays it to the driver who came to the pickup. The
+// platform takes no commission on it. Trips without a passenger, a driver or
+// a fee move no money.
+func postCancellationFee(ctx context.Context, q *Queries, trip Trip) error {
+	if !trip.PassengerID.Valid || !trip.DriverID.Valid || !trip.CancellationFee.Valid || trip.CancellationFee.Int64 <= 0 {
+		return nil
+	}
+
+	accounts, err := loadTripAccounts(ctx, q, trip)
+	if err != nil {
+		return err
+	}
+
+	fee := trip.CancellationFee.Int64
+
+	_, err = postEntry(ctx, q, CreateJournalEntryParams{
+		Kind:        EntryKindCancellationFee,
+		TripID:      sql.NullInt64{Int64: trip.ID, Valid: true},
+		Amount:      fee,
+		Description: fmt.Sprintf("Cancellation fee for trip %s", trip.BookingID),
+	}, []ledgerPosting{
+		{AccountID: accounts.Passenger.ID, Amount: -fee},
+		{AccountID: accounts.Driver.ID, Amount: fee},
+	})
+	return err
+}
+
+// refundTrip posts a refund of amount to the passenger of a locked, completed
+// trip. The driver and the platform give back their shares in the same
+// proportion the fare was split between them.
+func refundTrip(ctx context.Context, q *Queries, trip Trip, amount int64, reason string) (JournalEntry, error) {
+	if trip.TripStatus != TripStatusCompleted {
+		return JournalEntry{}, ErrTripNotCompleted
+	}
+
+	tripID := sql.NullInt64{Int64: trip.ID, Valid: true}
+
+	charged, err := q.SumTripJournalEntries(ctx, SumTripJournalEntriesParams{
+		TripID: tripID,
+		Kind:   EntryKindTripFare,
+	})
+	if err != nil {
+		return JournalEntry{}, err
+	}
+	refunded, err := q.SumTripJournalEntries(ctx, SumTripJournalEntriesParams{
+		TripID: tripID,
+		Kind:   EntryKindRefund,
+	})
+	if err != nil {
+		return JournalEntry{}, err
+	}
+	if amount <= 0 || amount > charged-refunded {
+		return JournalEntry{}, ErrRefundExceedsFare
+	}
+
+	commission, err := q.SumTripJournalEntries(ctx, SumTripJournalEntriesParams{
+		TripID: tripID,
+		Kind:   EntryKindCommission,
+	})
+	if err != nil {
+		return JournalEntry{}, err
+	}
+
+	accounts, err := loadTripAccounts(ctx, q, trip)
+	if err != nil {
+		return JournalEntry{}, err
+	}
+
+	platformShare := amount * commission / charged
+
+	description := fmt.Sprintf("Refund for trip %s", trip.BookingID)
+	if reason != "" {
+		description += ": " + reason
+	}
+
+	return postEntry(ctx, q, CreateJournalEntryParams{
+		Kind:        EntryKindRefund,
+		TripID:      tripID,
+		Amount:      amount,
+		Description: description,
+	}, []ledgerPosting{
+		{AccountID: accounts.Passenger.ID, Amount: amount},
+		{AccountID: accounts.Driver.ID, Amount: -(amount - platformShare)},
+		{AccountID: accounts.Platform.ID, Amount: -platformShare},
+	})
+}
+
+// postTripPayment records amount collected from the passenger of trip. A
+// payment credits the passenger's wallet and debits the collector, the
+// driver or the platform, while a payment refund does the reverse. Trips
+// without a passenger or a driver move no money.
+func postTripPayment(ctx context.Context, q *Queries, trip Trip, kind string, amount int64, collectedBy string) (JournalEntry, error) {
+	if !trip.PassengerID.Valid || !trip.DriverID.Valid || amount <= 0 {
+		return JournalEntry{}, nil
+	}
+
+	accounts, err := loadTripAccounts(ctx, q, trip)
+	if err != nil {
+		return JournalEntry{}, err
+	}
+
+	collector := accounts.Platform
+	if collectedBy == AccountOwnerDriver {
+		collector = accounts.Driver
+	}
+
+	description := fmt.Sprintf("Payment for trip %s", trip.BookingID)
+	credit := amount
+	if kind == EntryKindPaymentRefund {
+		description = fmt.Sprintf("Payment refund for trip %s", trip.BookingID)
+		credit = -amount
+	}
+
+	return postEntry(ctx, q, CreateJournalEntryParams{
+		Kind:        kind,
+		TripID:      sql.NullInt64{Int64: trip.ID, Valid: true},
+		Amount:      amount,
+		Description: description,
+	}, []ledgerPosting{
+		{AccountID: accounts.Passenger.ID, Amount: credit},
+		{AccountID: collector.ID, Amount: -credit},
+	})
+}
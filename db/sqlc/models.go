@@ -6,9 +6,20 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
 )
 
+type Account struct {
+	ID        int64     `json:"id"`
+	OwnerType string    `json:"owner_type"`
+	OwnerID   int64     `json:"owner_id"`
+	Balance   int64     `json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Car struct {
 	ID        int64     `json:"id"`
 	CarType   string    `json:"car_type"`
@@ -36,6 +47,27 @@ type Driver struct {
 	SubscriptionExpireAt time.Time `json:"subscription_expire_at"`
 	PasswordChangedAt    time.Time `json:"password_changed_at"`
 	CreatedAt            time.Time `json:"created_at"`
+	IsBusy               bool      `json:"is_busy"`
+}
+
+type FareRule struct {
+	CarType         string    `json:"car_type"`
+	BaseFare        int64     `json:"base_fare"`
+	PerKm           int64     `json:"per_km"`
+	PerMinute       int64     `json:"per_minute"`
+	MinimumFare     int64     `json:"minimum_fare"`
+	BookingFee      int64     `json:"booking_fee"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	CancellationFee int64     `json:"cancellation_fee"`
+}
+
+type JournalEntry struct {
+	ID          int64         `json:"id"`
+	Kind        string        `json:"kind"`
+	TripID      sql.NullInt64 `json:"trip_id"`
+	Amount      int64         `json:"amount"`
+	Description string        `json:"description"`
+	CreatedAt   time.Time     `json:"created_at"`
 }
 
 type Passenger struct {
@@ -48,6 +80,47 @@ type Passenger struct {
 	CreatedAt         time.Time `json:"created_at"`
 }
 
+type PaymentIntent struct {
+	ID                  int64          `json:"id"`
+	Provider            string         `json:"provider"`
+	ProviderRef         sql.NullString `json:"provider_ref"`
+	TripID              sql.NullInt64  `json:"trip_id"`
+	SubscriptionID      sql.NullInt64  `json:"subscription_id"`
+	PayerType           string         `json:"payer_type"`
+	PayerID             int64          `json:"payer_id"`
+	Amount              int64          `json:"amount"`
+	CapturedAmount      int64          `json:"captured_amount"`
+	RefundedAmount      int64          `json:"refunded_amount"`
+	Currency            string         `json:"currency"`
+	Status              string         `json:"status"`
+	FailureReason       sql.NullString `json:"failure_reason"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	RefundPendingAmount int64          `json:"refund_pending_amount"`
+}
+
+type Posting struct {
+	ID           int64     `json:"id"`
+	EntryID      int64     `json:"entry_id"`
+	AccountID    int64     `json:"account_id"`
+	Amount       int64     `json:"amount"`
+	BalanceAfter int64     `json:"balance_after"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	Role         string    `json:"role"`
+	UserID       int64     `json:"user_id"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIP     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
 type Subscription struct {
 	ID                   int64  `json:"id"`
 	SubscriptionPackage  string `json:"subscription_package"`
@@ -61,11 +134,11 @@ type Trip struct {
 	BookingID       string         `json:"booking_id"`
 	TripStatus      string         `json:"trip_status"`
 	PickupLocation  string         `json:"pickup_location"`
-	PickupLat       string         `json:"pickup_lat"`
-	PickupLong      string         `json:"pickup_long"`
+	PickupLat       float64        `json:"pickup_lat"`
+	PickupLong      float64        `json:"pickup_long"`
 	DropoffLocation string         `json:"dropoff_location"`
-	DropoffLat      string         `json:"dropoff_lat"`
-	DropoffLong     string         `json:"dropoff_long"`
+	DropoffLat      float64        `json:"dropoff_lat"`
+	DropoffLong     float64        `json:"dropoff_long"`
 	DriverID        sql.NullInt64  `json:"driver_id"`
 	DriverName      sql.NullString `json:"driver_name"`
 	DriverMobile    sql.NullString `json:"driver_mobile"`
@@ -74,4 +147,70 @@ type Trip struct {
 	CarImage        sql.NullString `json:"car_image"`
 	Fare            sql.NullInt64  `json:"fare"`
 	CreatedAt       time.Time      `json:"created_at"`
+	PassengerID     sql.NullInt64  `json:"passenger_id"`
+	SurgeMultiplier float64        `json:"surge_multiplier"`
+	CancelledBy     sql.NullString `json:"cancelled_by"`
+	CancelReason    sql.NullString `json:"cancel_reason"`
+	CancellationFee sql.NullInt64  `json:"cancellation_fee"`
+	CancelledAt     sql.NullTime   `json:"cancelled_at"`
+	ScheduledAt     sql.NullTime   `json:"scheduled_at"`
+	ReminderSentAt  sql.NullTime   `json:"reminder_sent_at"`
+	AcceptedAt      sql.NullTime   `json:"accepted_at"`
+	ArrivedAt       sql.NullTime   `json:"arrived_at"`
+	StartedAt       sql.NullTime   `json:"started_at"`
+	CompletedAt     sql.NullTime   `json:"completed_at"`
+}
+
+type TripCoordinateBackfillIssue struct {
+	ID         int64     `json:"id"`
+	TripID     int64     `json:"trip_id"`
+	BookingID  string    `json:"booking_id"`
+	ColumnName string    `json:"column_name"`
+	RawValue   string    `json:"raw_value"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type TripEvent struct {
+	ID         int64           `json:"id"`
+	TripID     int64           `json:"trip_id"`
+	FromStatus sql.NullString  `json:"from_status"`
+	ToStatus   string          `json:"to_status"`
+	ActorType  string          `json:"actor_type"`
+	ActorID    sql.NullInt64   `json:"actor_id"`
+	Lat        sql.NullFloat64 `json:"lat"`
+	Long       sql.NullFloat64 `json:"long"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type TripRating struct {
+	ID          int64          `json:"id"`
+	TripID      int64          `json:"trip_id"`
+	RatedBy     string         `json:"rated_by"`
+	PassengerID int64          `json:"passenger_id"`
+	DriverID    int64          `json:"driver_id"`
+	Score       int32          `json:"score"`
+	Comment     sql.NullString `json:"comment"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+type TripReceipt struct {
+	ID            int64           `json:"id"`
+	TripID        int64           `json:"trip_id"`
+	ReceiptNumber string          `json:"receipt_number"`
+	Document      json.RawMessage `json:"document"`
+	PDF           []byte          `json:"pdf"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+type TripStop struct {
+	ID         int64        `json:"id"`
+	TripID     int64        `json:"trip_id"`
+	StopOrder  int32        `json:"stop_order"`
+	Location   string       `json:"location"`
+	Lat        float64      `json:"lat"`
+	Long       float64      `json:"long"`
+	Status     string       `json:"status"`
+	ArrivedAt  sql.NullTime `json:"arrived_at"`
+	DepartedAt sql.NullTime `json:"departed_at"`
+	CreatedAt  time.Time    `json:"created_at"`
 }
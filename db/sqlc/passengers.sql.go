@@ -11,27 +11,21 @@ import (
 
 const createPassenger = `-- name: CreatePassenger :one
 INSERT INTO passengers (
-  hashed_password, full_name, email, rating
+  hashed_password, full_name, email
 ) VALUES (
-  $1, $2, $3, $4
+  $1, $2, $3
 )
 RETURNING id, hashed_password, full_name, email, rating, password_changed_at, created_at
 `
 
 type CreatePassengerParams struct {
-	HashedPassword string  `json:"hashed_password"`
-	FullName       string  `json:"full_name"`
-	Email          string  `json:"email"`
-	Rating         float64 `json:"rating"`
+	HashedPassword string `json:"hashed_password"`
+	FullName       string `json:"full_name"`
+	Email          string `json:"email"`
 }
 
 func (q *Queries) CreatePassenger(ctx context.Context, arg CreatePassengerParams) (Passenger, error) {
-	row := q.db.QueryRowContext(ctx, createPassenger,
-		arg.HashedPassword,
-		arg.FullName,
-		arg.Email,
-		arg.Rating,
-	)
+	row := q.db.QueryRowContext(ctx, createPassenger, arg.HashedPassword, arg.FullName, arg.Email)
 	var i Passenger
 	err := row.Scan(
 		&i.ID,
@@ -155,3 +149,19 @@ func (q *Queries) UpdatePassenger(ctx context.Context, arg UpdatePassengerParams
 	)
 	return err
 }
+
+const updatePassengerRating = `-- name: UpdatePassengerRating :exec
+UPDATE passengers
+SET rating = $2
+WHERE id = $1
+`
+
+type UpdatePassengerRatingParams struct {
+	ID     int64   `json:"id"`
+	Rating float64 `json:"rating"`
+}
+
+func (q *Queries) UpdatePassengerRating(ctx context.Context, arg UpdatePassengerRatingParams) error {
+	_, err := q.db.ExecContext(ctx, updatePassengerRating, arg.ID, arg.Rating)
+	return err
+}
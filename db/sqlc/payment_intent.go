@@ -0,0 +1,18 @@
+package db
+
+import "errors"
+
+// Statuses stored in payment_intents.status. A partly refunded payment stays
+// captured until everything captured has been paid back. A voided payment was
+// authorized and then released without taking anything.
+const (
+	PaymentStatusAuthorized = "authorized"
+	PaymentStatusCaptured   = "captured"
+	PaymentStatusRefunded   = "refunded"
+	PaymentStatusFailed     = "failed"
+	PaymentStatusVoided     = "voided"
+)
+
+// ErrRefundExceedsPayment is returned when a refund would pay back more than
+// was captured on a payment.
+var ErrRefundExceedsPayment = errors.New("refund exceeds the captured payment")
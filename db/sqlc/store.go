@@ -1,20 +1,819 @@
 package db
 
-import ( 
-	"database/sql" 
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/emonoid/toribook.git/fare"
+	"github.com/lib/pq"
 )
 
 // Store provides all functions to execute SQL queries and transactions.
+// Handlers depend on this interface so they can be tested against a mock.
+type Store interface {
+	Querier
+	AcceptBidTx(ctx context.Context, arg AcceptBidTxParams) (AcceptBidTxResult, error)
+	AssignDriverTx(ctx context.Context, arg AssignDriverTxParams) (AssignDriverTxResult, error)
+	CancelTripTx(ctx context.Context, arg CancelTripTxParams) (CancelTripTxResult, error)
+	CapturePaymentTx(ctx context.Context, arg CapturePaymentTxParams) (CapturePaymentTxResult, error)
+	CompleteTripTx(ctx context.Context, arg CompleteTripTxParams) (CompleteTripTxResult, error)
+	CreateTripTx(ctx context.Context, arg CreateTripTxParams) (CreateTripTxResult, error)
+	RateTripTx(ctx context.Context, arg RateTripTxParams) (RateTripTxResult, error)
+	RefundPaymentTx(ctx context.Context, arg RefundPaymentTxParams) (RefundPaymentTxResult, error)
+	RefundTripTx(ctx context.Context, arg RefundTripTxParams) (RefundTripTxResult, error)
+	UpdateTripStatusTx(ctx context.Context, arg UpdateTripStatusTxParams) (UpdateTripStatusTxResult, error)
+}
+
+// SQLStore provides all functions to execute SQL queries and transactions.
 // It wraps the generated Queries type and provides a database connection.
-type Store struct {
+type SQLStore struct {
 	*Queries         // embedded Queries pointer as like inheritance
 	db       *sql.DB // same
 }
 
 // NewStore creates a new Store
-func NewStore(db *sql.DB) *Store {
-	return &Store{
+func NewStore(db *sql.DB) Store {
+	return &SQLStore{
 		Queries: New(db), // initialize Queries with the provided db connection
 		db:      db,      // store the db connection
 	}
-}
\ No newline at end of file
+}
+
+// maxTxAttempts is how many times execTx runs a transaction that keeps
+// failing with a serialization failure or deadlock before giving up.
+const maxTxAttempts = 3
+
+var (
+	// ErrTripNotOwned is returned when a passenger acts on a trip booked by someone else.
+	ErrTripNotOwned = errors.New("trip does not belong to this passenger")
+	// ErrDriverBusy is returned when assigning a driver who is already on a trip.
+	ErrDriverBusy = errors.New("driver is already assigned to another trip")
+	// ErrTripNotAssigned is returned when a driver acts on a trip assigned to someone else.
+	ErrTripNotAssigned = errors.New("trip is not assigned to this driver")
+	// ErrTripNotCompleted is returned when rating a trip that has not been completed.
+	ErrTripNotCompleted = errors.New("trip is not completed")
+	// ErrTripAlreadyRated is returned when the same side rates a trip twice.
+	ErrTripAlreadyRated = errors.New("trip is already rated")
+	// ErrTripOpenForBids is returned when a driver accepts a trip directly
+	// while the passenger is choosing between bids.
+	ErrTripOpenForBids = errors.New("trip is open for bids")
+	// ErrNoFareRule is returned when a trip has to be priced for a car type
+	// without a fare rule.
+	ErrNoFareRule = errors.New("no fare rule for this car type")
+)
+
+// execTx executes a function within a serializable database transaction,
+// retrying the whole function when postgres aborts it because of a
+// concurrent writer.
+func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
+	var err error
+	for attempt := 0; attempt < maxTxAttempts; attempt++ {
+		err = store.runTx(ctx, fn)
+		if !isRetryableTxError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (store *SQLStore) runTx(ctx context.Context, fn func(*Queries) error) error {
+	tx, err := store.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return err
+	}
+
+	q := New(tx)
+	err = fn(q)
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("tx err: %w, rb err: %v", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// isRetryableTxError reports whether err is a serialization failure or a
+// deadlock, both of which succeed when the transaction is simply run again.
+func isRetryableTxError(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Name() {
+		case "serialization_failure", "deadlock_detected":
+			return true
+		}
+	}
+	return false
+}
+
+// assignDriver moves a locked trip to accepted with the given driver, car and
+// fare on behalf of actor, and marks the locked driver busy.
+func assignDriver(ctx context.Context, q *Queries, trip Trip, driver Driver, arg AssignTripDriverParams, actor TripActor) (Trip, error) {
+	if driver.IsBusy {
+		return trip, ErrDriverBusy
+	}
+
+	if err := ValidateTripStatusTransition(trip.TripStatus, TripStatusAccepted); err != nil {
+		return trip, err
+	}
+
+	arg.TripStatus = TripStatusAccepted
+	arg.BookingID = trip.BookingID
+	arg.CurrentStatus = trip.TripStatus
+
+	trip, err := q.AssignTripDriver(ctx, arg)
+	if err != nil {
+		return trip, err
+	}
+
+	if err := recordTripEvent(ctx, q, trip, arg.CurrentStatus, actor); err != nil {
+		return trip, err
+	}
+
+	err = q.UpdateDriverBusyStatus(ctx, UpdateDriverBusyStatusParams{
+		ID:     driver.ID,
+		IsBusy: true,
+	})
+	return trip, err
+}
+
+// priceTrip returns the fare a trip is accepted at directly: the estimate of
+// the fare rule of carType over its route and stops at the surge it was
+// requested under. Trips accepted through a bid are charged the bid instead.
+func priceTrip(ctx context.Context, q *Queries, trip Trip, carType string) (int64, error) {
+	rule, err := q.GetFareRule(ctx, carType)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrNoFareRule
+		}
+		return 0, err
+	}
+
+	stops, err := q.ListTripStops(ctx, trip.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	points := make([]fare.Point, 0, len(stops)+2)
+	points = append(points, fare.Point{Lat: trip.PickupLat, Long: trip.PickupLong})
+	for _, stop := range stops {
+		points = append(points, fare.Point{Lat: stop.Lat, Long: stop.Long})
+	}
+	points = append(points, fare.Point{Lat: trip.DropoffLat, Long: trip.DropoffLong})
+
+	route := fare.EstimateStops(points)
+	price := NewFareRule(rule).Price(route.DistanceKm, route.DurationMinutes, trip.SurgeMultiplier)
+	return price, nil
+}
+
+// AcceptBidTxParams contains the input parameters of the accept bid transaction
+type AcceptBidTxParams struct {
+	BookingID    string `json:"booking_id"`
+	PassengerID  int64  `json:"passenger_id"`
+	DriverID     int64  `json:"driver_id"`
+	DriverName   string `json:"driver_name"`
+	DriverMobile string `json:"driver_mobile"`
+	CarID        int64  `json:"car_id"`
+	CarType      string `json:"car_type"`
+	CarImage     string `json:"car_image"`
+	Fare         int64  `json:"fare"`
+}
+
+// AcceptBidTxResult is the result of the accept bid transaction
+type AcceptBidTxResult struct {
+	Trip Trip `json:"trip"`
+}
+
+// AcceptBidTx locks the passenger's trip, checks it can still be accepted
+// and copies the winning driver, car and fare onto it.
+func (store *SQLStore) AcceptBidTx(ctx context.Context, arg AcceptBidTxParams) (AcceptBidTxResult, error) {
+	var result AcceptBidTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		trip, err := q.GetTripByBookingIDForUpdate(ctx, arg.BookingID)
+		if err != nil {
+			return err
+		}
+
+		if !trip.PassengerID.Valid || trip.PassengerID.Int64 != arg.PassengerID {
+			return ErrTripNotOwned
+		}
+
+		driver, err := q.GetDriverForUpdate(ctx, arg.DriverID)
+		if err != nil {
+			return err
+		}
+
+		result.Trip, err = assignDriver(ctx, q, trip, driver, AssignTripDriverParams{
+			DriverID:     sql.NullInt64{Int64: arg.DriverID, Valid: true},
+			DriverName:   sql.NullString{String: arg.DriverName, Valid: true},
+			DriverMobile: sql.NullString{String: arg.DriverMobile, Valid: true},
+			CarID:        sql.NullInt64{Int64: arg.CarID, Valid: true},
+			CarType:      sql.NullString{String: arg.CarType, Valid: true},
+			CarImage:     sql.NullString{String: arg.CarImage, Valid: true},
+			Fare:         sql.NullInt64{Int64: arg.Fare, Valid: true},
+		}, TripActor{
+			Type: ActorTypePassenger,
+			ID:   sql.NullInt64{Int64: arg.PassengerID, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// AssignDriverTxParams contains the input parameters of the assign driver transaction
+type AssignDriverTxParams struct {
+	BookingID string    `json:"booking_id"`
+	DriverID  int64     `json:"driver_id"`
+	Actor     TripActor `json:"actor"`
+}
+
+// AssignDriverTxResult is the result of the assign driver transaction
+type AssignDriverTxResult struct {
+	Trip   Trip   `json:"trip"`
+	Driver Driver `json:"driver"`
+}
+
+// AssignDriverTx assigns a driver and their car to a requested trip and marks
+// the driver busy, all in one transaction. The trip keeps the fare the
+// passenger booked it at, or is priced for the driver's car type otherwise.
+// Trips open for bids can only be assigned by the passenger accepting a bid.
+func (store *SQLStore) AssignDriverTx(ctx context.Context, arg AssignDriverTxParams) (AssignDriverTxResult, error) {
+	var result AssignDriverTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		trip, err := q.GetTripByBookingIDForUpdate(ctx, arg.BookingID)
+		if err != nil {
+			return err
+		}
+
+		if trip.TripStatus == TripStatusBidding {
+			return ErrTripOpenForBids
+		}
+
+		result.Driver, err = q.GetDriverForUpdate(ctx, arg.DriverID)
+		if err != nil {
+			return err
+		}
+
+		tripFare, err := priceTrip(ctx, q, trip, result.Driver.CarType)
+		if err != nil {
+			return err
+		}
+
+		result.Trip, err = assignDriver(ctx, q, trip, result.Driver, AssignTripDriverParams{
+			DriverID:     sql.NullInt64{Int64: result.Driver.ID, Valid: true},
+			DriverName:   sql.NullString{String: result.Driver.FullName, Valid: true},
+			DriverMobile: sql.NullString{String: result.Driver.Mobile, Valid: true},
+			CarID:        sql.NullInt64{Int64: result.Driver.CarID, Valid: true},
+			CarType:      sql.NullString{String: result.Driver.CarType, Valid: true},
+			CarImage:     sql.NullString{String: result.Driver.CarImage, Valid: true},
+			Fare:         sql.NullInt64{Int64: tripFare, Valid: true},
+		}, arg.Actor)
+		if err != nil {
+			return err
+		}
+
+		result.Driver.IsBusy = true
+		return nil
+	})
+
+	return result, err
+}
+
+// TripStopParams is one waypoint of a trip between its pickup and dropoff.
+type TripStopParams struct {
+	Location string  `json:"location"`
+	Lat      float64 `json:"lat"`
+	Long     float64 `json:"long"`
+}
+
+// CreateTripTxParams contains the input parameters of the create trip transaction
+type CreateTripTxParams struct {
+	CreateTripParams
+	// Stops are visited in the given order.
+	Stops []TripStopParams `json:"stops"`
+}
+
+// CreateTripTxResult is the result of the create trip transaction
+type CreateTripTxResult struct {
+	Trip  Trip       `json:"trip"`
+	Stops []TripStop `json:"stops"`
+}
+
+// CreateTripTx creates a trip together with its ordered stops.
+func (store *SQLStore) CreateTripTx(ctx context.Context, arg CreateTripTxParams) (CreateTripTxResult, error) {
+	var result CreateTripTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.Trip, err = q.CreateTrip(ctx, arg.CreateTripParams)
+		if err != nil {
+			return err
+		}
+
+		err = recordTripEvent(ctx, q, result.Trip, "", TripActor{
+			Type: ActorTypePassenger,
+			ID:   result.Trip.PassengerID,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Stops = make([]TripStop, 0, len(arg.Stops))
+		for i, stop := range arg.Stops {
+			created, err := q.CreateTripStop(ctx, CreateTripStopParams{
+				TripID:    result.Trip.ID,
+				StopOrder: int32(i + 1),
+				Location:  stop.Location,
+				Lat:       stop.Lat,
+				Long:      stop.Long,
+			})
+			if err != nil {
+				return err
+			}
+			result.Stops = append(result.Stops, created)
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// CompleteTripTxParams contains the input parameters of the complete trip transaction
+type CompleteTripTxParams struct {
+	BookingID string `json:"booking_id"`
+	// Fare overrides the agreed fare when set, e.g. after a route change.
+	Fare  *int64    `json:"fare"`
+	Actor TripActor `json:"actor"`
+	// CommissionRate is the share of the fare the platform takes from the
+	// driver, e.g. 0.15.
+	CommissionRate float64 `json:"commission_rate"`
+}
+
+// CompleteTripTxResult is the result of the complete trip transaction
+type CompleteTripTxResult struct {
+	Trip Trip `json:"trip"`
+}
+
+// CompleteTripTx marks an in progress trip completed, records its final fare,
+// posts the fare and the platform commission to the ledger and frees the
+// assigned driver for the next trip. A driver can only complete a trip
+// assigned to them.
+func (store *SQLStore) CompleteTripTx(ctx context.Context, arg CompleteTripTxParams) (CompleteTripTxResult, error) {
+	var result CompleteTripTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		trip, err := q.GetTripByBookingIDForUpdate(ctx, arg.BookingID)
+		if err != nil {
+			return err
+		}
+
+		if arg.Actor.Type == ActorTypeDriver && (!trip.DriverID.Valid || trip.DriverID != arg.Actor.ID) {
+			return ErrTripNotAssigned
+		}
+
+		if err := ValidateTripStatusTransition(trip.TripStatus, TripStatusCompleted); err != nil {
+			return err
+		}
+
+		fare := trip.Fare
+		if arg.Fare != nil {
+			fare = sql.NullInt64{Int64: *arg.Fare, Valid: true}
+		}
+
+		result.Trip, err = q.CompleteTrip(ctx, CompleteTripParams{
+			TripStatus:    TripStatusCompleted,
+			Fare:          fare,
+			BookingID:     trip.BookingID,
+			CurrentStatus: trip.TripStatus,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := recordTripEvent(ctx, q, result.Trip, trip.TripStatus, arg.Actor); err != nil {
+			return err
+		}
+
+		if err := postTripFare(ctx, q, result.Trip, arg.CommissionRate); err != nil {
+			return err
+		}
+
+		if !result.Trip.DriverID.Valid {
+			return nil
+		}
+
+		return q.UpdateDriverBusyStatus(ctx, UpdateDriverBusyStatusParams{
+			ID:     result.Trip.DriverID.Int64,
+			IsBusy: false,
+		})
+	})
+
+	return result, err
+}
+
+// CancelTripTxParams contains the input parameters of the cancel trip transaction
+type CancelTripTxParams struct {
+	BookingID string `json:"booking_id"`
+	// Actor is the passenger or driver cancelling; its type is stored as
+	// cancelled_by.
+	Actor  TripActor `json:"actor"`
+	Reason string    `json:"reason"`
+}
+
+// CancelTripTxResult is the result of the cancel trip transaction
+type CancelTripTxResult struct {
+	Trip Trip `json:"trip"`
+}
+
+// CancelTripTx cancels a trip on behalf of its passenger or its driver,
+// records who cancelled and why, charges the cancellation fee of the car type
+// when it applies, posts it to the ledger and frees the assigned driver.
+func (store *SQLStore) CancelTripTx(ctx context.Context, arg CancelTripTxParams) (CancelTripTxResult, error) {
+	var result CancelTripTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		trip, err := q.GetTripByBookingIDForUpdate(ctx, arg.BookingID)
+		if err != nil {
+			return err
+		}
+
+		switch arg.Actor.Type {
+		case CancelledByPassenger:
+			if !trip.PassengerID.Valid || trip.PassengerID != arg.Actor.ID {
+				return ErrTripNotOwned
+			}
+		case CancelledByDriver:
+			if !trip.DriverID.Valid || trip.DriverID != arg.Actor.ID {
+				return ErrTripNotAssigned
+			}
+		}
+
+		if err := ValidateTripStatusTransition(trip.TripStatus, TripStatusCancelled); err != nil {
+			return err
+		}
+
+		var fee sql.NullInt64
+		if CancellationFeeApplies(trip.TripStatus, arg.Actor.Type, arg.Reason) {
+			rule, err := q.GetFareRule(ctx, trip.CarType.String)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+			// without a fare rule there is no fee to charge
+			if err == nil {
+				fee = sql.NullInt64{Int64: rule.CancellationFee, Valid: true}
+			}
+		}
+
+		result.Trip, err = q.CancelTrip(ctx, CancelTripParams{
+			TripStatus:      TripStatusCancelled,
+			CancelledBy:     sql.NullString{String: arg.Actor.Type, Valid: true},
+			CancelReason:    sql.NullString{String: arg.Reason, Valid: true},
+			CancellationFee: fee,
+			BookingID:       trip.BookingID,
+			CurrentStatus:   trip.TripStatus,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := recordTripEvent(ctx, q, result.Trip, trip.TripStatus, arg.Actor); err != nil {
+			return err
+		}
+
+		if err := postCancellationFee(ctx, q, result.Trip); err != nil {
+			return err
+		}
+
+		if !result.Trip.DriverID.Valid {
+			return nil
+		}
+
+		return q.UpdateDriverBusyStatus(ctx, UpdateDriverBusyStatusParams{
+			ID:     result.Trip.DriverID.Int64,
+			IsBusy: false,
+		})
+	})
+
+	return result, err
+}
+
+// UpdateTripStatusTxParams contains the input parameters of the update trip status transaction
+type UpdateTripStatusTxParams struct {
+	UpdateTripStatusParams
+	Actor TripActor `json:"actor"`
+}
+
+// UpdateTripStatusTxResult is the result of the update trip status transaction
+type UpdateTripStatusTxResult struct {
+	Trip Trip `json:"trip"`
+}
+
+// UpdateTripStatusTx moves a trip from CurrentStatus to TripStatus and records
+// the change in its timeline. It returns sql.ErrNoRows when the trip is no
+// longer in CurrentStatus. Drivers can only move trips assigned to them,
+// except for opening bidding on a requested trip.
+func (store *SQLStore) UpdateTripStatusTx(ctx context.Context, arg UpdateTripStatusTxParams) (UpdateTripStatusTxResult, error) {
+	var result UpdateTripStatusTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		trip, err := q.GetTripByBookingIDForUpdate(ctx, arg.BookingID)
+		if err != nil {
+			return err
+		}
+
+		if arg.Actor.Type == ActorTypeDriver && arg.TripStatus != TripStatusBidding &&
+			(!trip.DriverID.Valid || trip.DriverID != arg.Actor.ID) {
+			return ErrTripNotAssigned
+		}
+
+		result.Trip, err = q.UpdateTripStatus(ctx, arg.UpdateTripStatusParams)
+		if err != nil {
+			return err
+		}
+
+		return recordTripEvent(ctx, q, result.Trip, arg.CurrentStatus, arg.Actor)
+	})
+
+	return result, err
+}
+
+// RateTripTxParams contains the input parameters of the rate trip transaction
+type RateTripTxParams struct {
+	BookingID string `json:"booking_id"`
+	// RatedBy is RatedByPassenger or RatedByDriver and RaterID the id of that
+	// passenger or driver.
+	RatedBy string         `json:"rated_by"`
+	RaterID int64          `json:"rater_id"`
+	Score   int32          `json:"score"`
+	Comment sql.NullString `json:"comment"`
+}
+
+// RateTripTxResult is the result of the rate trip transaction
+type RateTripTxResult struct {
+	Rating TripRating `json:"rating"`
+	// RateeRating is the new overall rating of the driver or passenger rated.
+	RateeRating float64 `json:"ratee_rating"`
+}
+
+// RateTripTx records the rating one side of a completed trip gives the other
+// and recomputes the overall rating of the rated driver or passenger.
+func (store *SQLStore) RateTripTx(ctx context.Context, arg RateTripTxParams) (RateTripTxResult, error) {
+	var result RateTripTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		trip, err := q.GetTripByBookingIDForUpdate(ctx, arg.BookingID)
+		if err != nil {
+			return err
+		}
+
+		switch arg.RatedBy {
+		case RatedByPassenger:
+			if !trip.PassengerID.Valid || trip.PassengerID.Int64 != arg.RaterID {
+				return ErrTripNotOwned
+			}
+		case RatedByDriver:
+			if !trip.DriverID.Valid || trip.DriverID.Int64 != arg.RaterID {
+				return ErrTripNotAssigned
+			}
+		}
+
+		if trip.TripStatus != TripStatusCompleted || !trip.PassengerID.Valid || !trip.DriverID.Valid {
+			return ErrTripNotCompleted
+		}
+
+		result.Rating, err = q.CreateTripRating(ctx, CreateTripRatingParams{
+			TripID:      trip.ID,
+			RatedBy:     arg.RatedBy,
+			PassengerID: trip.PassengerID.Int64,
+			DriverID:    trip.DriverID.Int64,
+			Score:       arg.Score,
+			Comment:     arg.Comment,
+		})
+		if err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+				return ErrTripAlreadyRated
+			}
+			return err
+		}
+
+		if arg.RatedBy == RatedByPassenger {
+			summary, err := q.GetDriverRatingSummary(ctx, trip.DriverID.Int64)
+			if err != nil {
+				return err
+			}
+
+			result.RateeRating = SmoothedRating(summary.RatingCount, summary.ScoreSum)
+			return q.UpdateDriverRating(ctx, UpdateDriverRatingParams{
+				ID:     trip.DriverID.Int64,
+				Rating: result.RateeRating,
+			})
+		}
+
+		summary, err := q.GetPassengerRatingSummary(ctx, trip.PassengerID.Int64)
+		if err != nil {
+			return err
+		}
+
+		result.RateeRating = SmoothedRating(summary.RatingCount, summary.ScoreSum)
+		return q.UpdatePassengerRating(ctx, UpdatePassengerRatingParams{
+			ID:     trip.PassengerID.Int64,
+			Rating: result.RateeRating,
+		})
+	})
+
+	return result, err
+}
+
+// RefundTripTxParams contains the input parameters of the refund trip transaction
+type RefundTripTxParams struct {
+	BookingID string `json:"booking_id"`
+	Amount    int64  `json:"amount"`
+	Reason    string `json:"reason"`
+}
+
+// RefundTripTxResult is the result of the refund trip transaction
+type RefundTripTxResult struct {
+	Entry JournalEntry `json:"entry"`
+}
+
+// RefundTripTx pays part or all of the fare of a completed trip back to its
+// passenger. The driver and the platform give back their shares of the
+// refunded amount in the same proportion they were paid, and the total
+// refunded never exceeds the fare.
+func (store *SQLStore) RefundTripTx(ctx context.Context, arg RefundTripTxParams) (RefundTripTxResult, error) {
+	var result RefundTripTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		trip, err := q.GetTripByBookingIDForUpdate(ctx, arg.BookingID)
+		if err != nil {
+			return err
+		}
+
+		result.Entry, err = refundTrip(ctx, q, trip, arg.Amount, arg.Reason)
+		return err
+	})
+
+	return result, err
+}
+
+// CapturePaymentTxParams contains the input parameters of the capture payment transaction
+type CapturePaymentTxParams struct {
+	PaymentIntentID int64 `json:"payment_intent_id"`
+	Amount          int64 `json:"amount"`
+	// CollectedBy is the owner type of the wallet the money went to, the
+	// driver for cash and the platform for cards.
+	CollectedBy string `json:"collected_by"`
+}
+
+// CapturePaymentTxResult is the result of the capture payment transaction
+type CapturePaymentTxResult struct {
+	PaymentIntent PaymentIntent `json:"payment_intent"`
+	// Entry is the ledger payment of a trip, empty for subscriptions.
+	Entry JournalEntry `json:"entry"`
+}
+
+// CapturePaymentTx records the capture of an authorized payment. Captures of
+// trip payments are posted to the ledger in the same transaction, so any part
+// of the fare the payment does not cover stays owed on the passenger's
+// wallet. It returns sql.ErrNoRows when the payment is no longer authorized.
+func (store *SQLStore) CapturePaymentTx(ctx context.Context, arg CapturePaymentTxParams) (CapturePaymentTxResult, error) {
+	var result CapturePaymentTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.PaymentIntent, err = q.UpdatePaymentIntentStatus(ctx, UpdatePaymentIntentStatusParams{
+			Status:         PaymentStatusCaptured,
+			CapturedAmount: arg.Amount,
+			ID:             arg.PaymentIntentID,
+			CurrentStatus:  PaymentStatusAuthorized,
+		})
+		if err != nil {
+			return err
+		}
+
+		if !result.PaymentIntent.TripID.Valid {
+			return nil
+		}
+
+		trip, err := q.GetTripForUpdate(ctx, result.PaymentIntent.TripID.Int64)
+		if err != nil {
+			return err
+		}
+
+		result.Entry, err = postTripPayment(ctx, q, trip, EntryKindPayment, arg.Amount, arg.CollectedBy)
+		return err
+	})
+
+	return result, err
+}
+
+// RefundPaymentTxParams contains the input parameters of the refund payment transaction
+type RefundPaymentTxParams struct {
+	PaymentIntentID int64 `json:"payment_intent_id"`
+	Amount          int64 `json:"amount"`
+	// Reserved marks an Amount reserved with ReservePaymentRefund before the
+	// provider was asked for it. Only what is still pending of it is
+	// recorded, since the provider's webhook may have recorded it already.
+	Reserved bool `json:"reserved"`
+	// RefundedTotal is set instead of Amount when the provider reports its
+	// running total of refunds. Only what it adds to the refunded amount is
+	// recorded, so totals at or below it change nothing.
+	RefundedTotal int64  `json:"refunded_total"`
+	Reason        string `json:"reason"`
+	// CollectedBy is the owner type of the wallet the payment went to, which
+	// gives the refunded part of it back.
+	CollectedBy string `json:"collected_by"`
+}
+
+// RefundPaymentTxResult is the result of the refund payment transaction
+type RefundPaymentTxResult struct {
+	PaymentIntent PaymentIntent `json:"payment_intent"`
+	// Entry is the ledger refund of a trip payment, empty for subscriptions.
+	Entry JournalEntry `json:"entry"`
+}
+
+// RefundPaymentTx records a refund the payment provider has made on a
+// captured payment and settles the pending refunds it covers. Refunds of trip
+// payments are posted to the ledger in the same transaction, together with
+// the part of the payment the collector gives back, so the payment and the
+// wallets always agree.
+func (store *SQLStore) RefundPaymentTx(ctx context.Context, arg RefundPaymentTxParams) (RefundPaymentTxResult, error) {
+	var result RefundPaymentTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		intent, err := q.GetPaymentIntentForUpdate(ctx, arg.PaymentIntentID)
+		if err != nil {
+			return err
+		}
+
+		amount := arg.Amount
+		pending := intent.RefundPendingAmount
+		switch {
+		case arg.RefundedTotal > 0:
+			amount = arg.RefundedTotal - intent.RefundedAmount
+			if amount <= 0 {
+				result.PaymentIntent = intent
+				return nil
+			}
+			// the provider's total includes the refunds pending here
+			pending -= min(amount, pending)
+		case arg.Reserved:
+			amount = min(amount, pending)
+			if amount <= 0 {
+				result.PaymentIntent = intent
+				return nil
+			}
+			pending -= amount
+		}
+
+		if intent.Status != PaymentStatusCaptured {
+			return ErrRefundExceedsPayment
+		}
+
+		refunded := intent.RefundedAmount + amount
+		if amount <= 0 || refunded+pending > intent.CapturedAmount {
+			return ErrRefundExceedsPayment
+		}
+
+		status := PaymentStatusCaptured
+		if refunded == intent.CapturedAmount {
+			status = PaymentStatusRefunded
+		}
+
+		result.PaymentIntent, err = q.UpdatePaymentIntentRefund(ctx, UpdatePaymentIntentRefundParams{
+			RefundedAmount:      refunded,
+			RefundPendingAmount: pending,
+			Status:              status,
+			ID:                  intent.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if !intent.TripID.Valid {
+			return nil
+		}
+
+		trip, err := q.GetTripForUpdate(ctx, intent.TripID.Int64)
+		if err != nil {
+			return err
+		}
+
+		result.Entry, err = refundTrip(ctx, q, trip, amount, arg.Reason)
+		if err != nil {
+			return err
+		}
+
+		_, err = postTripPayment(ctx, q, trip, EntryKindPaymentRefund, amount, arg.CollectedBy)
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,58 @@
+package db
+
+// Who cancelled a trip, stored in trips.cancelled_by.
+const (
+	CancelledByPassenger = "passenger"
+	CancelledByDriver    = "driver"
+)
+
+// Reason codes stored in trips.cancel_reason.
+const (
+	CancelReasonChangedPlans         = "changed_plans"
+	CancelReasonDriverLate           = "driver_late"
+	CancelReasonWrongPickup          = "wrong_pickup"
+	CancelReasonFoundOtherRide       = "found_other_ride"
+	CancelReasonPassengerNoShow      = "passenger_no_show"
+	CancelReasonPassengerUnreachable = "passenger_unreachable"
+	CancelReasonVehicleIssue         = "vehicle_issue"
+	CancelReasonUnsafePickup         = "unsafe_pickup"
+	CancelReasonOther                = "other"
+)
+
+// cancelReasons lists the reason codes each side may give.
+var cancelReasons = map[string][]string{
+	CancelledByPassenger: {
+		CancelReasonChangedPlans,
+		CancelReasonDriverLate,
+		CancelReasonWrongPickup,
+		CancelReasonFoundOtherRide,
+		CancelReasonOther,
+	},
+	CancelledByDriver: {
+		CancelReasonPassengerNoShow,
+		CancelReasonPassengerUnreachable,
+		CancelReasonVehicleIssue,
+		CancelReasonUnsafePickup,
+		CancelReasonOther,
+	},
+}
+
+// IsValidCancelReason reports whether cancelledBy may cancel a trip giving reason.
+func IsValidCancelReason(cancelledBy, reason string) bool {
+	for _, r := range cancelReasons[cancelledBy] {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// CancellationFeeApplies reports whether the passenger is charged for a
+// cancellation. That only happens once the driver has arrived at the pickup,
+// either because the passenger cancels or because they did not show up.
+func CancellationFeeApplies(status, cancelledBy, reason string) bool {
+	if status != TripStatusDriverArrived {
+		return false
+	}
+	return cancelledBy == CancelledByPassenger || reason == CancelReasonPassengerNoShow
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Actor types stored in trip_events.actor_type. Changes made by the server
+// itself, like the scheduler opening or expiring trips, use ActorTypeSystem.
+const (
+	ActorTypePassenger = "passenger"
+	ActorTypeDriver    = "driver"
+	ActorTypeSystem    = "system"
+)
+
+// TripActor is whoever changed the status of a trip and, when known, where
+// they were at the time.
+type TripActor struct {
+	Type string          `json:"type"`
+	ID   sql.NullInt64   `json:"id"`
+	Lat  sql.NullFloat64 `json:"lat"`
+	Long sql.NullFloat64 `json:"long"`
+}
+
+// recordTripEvent appends the move of trip from status from to its current
+// status. from is empty for a newly created trip.
+func recordTripEvent(ctx context.Context, q *Queries, trip Trip, from string, actor TripActor) error {
+	_, err := q.CreateTripEvent(ctx, CreateTripEventParams{
+		TripID:     trip.ID,
+		FromStatus: sql.NullString{String: from, Valid: from != ""},
+		ToStatus:   trip.TripStatus,
+		ActorType:  actor.Type,
+		ActorID:    actor.ID,
+		Lat:        actor.Lat,
+		Long:       actor.Long,
+	})
+	return err
+}
@@ -0,0 +1,25 @@
+package db
+
+import "math"
+
+// Sides stored in trip_ratings.rated_by. A passenger rates the driver of the
+// trip and a driver rates its passenger.
+const (
+	RatedByPassenger = "passenger"
+	RatedByDriver    = "driver"
+)
+
+// New accounts start from a prior of ratingPriorWeight ratings of
+// ratingPriorMean each, so a single early score cannot swing them to the
+// extremes.
+const (
+	ratingPriorMean   = 4.5
+	ratingPriorWeight = 5
+)
+
+// SmoothedRating returns the Bayesian average of count scores adding up to
+// sum, rounded to two decimals.
+func SmoothedRating(count, sum int64) float64 {
+	rating := (ratingPriorMean*ratingPriorWeight + float64(sum)) / float64(ratingPriorWeight+count)
+	return math.Round(rating*100) / 100
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Trip lifecycle statuses stored in trips.trip_status.
+const (
+	TripStatusScheduled      = "scheduled"
+	TripStatusRequested      = "requested"
+	TripStatusBidding        = "bidding"
+	TripStatusAccepted       = "accepted"
+	TripStatusDriverArriving = "driver_arriving"
+	TripStatusDriverArrived  = "driver_arrived"
+	TripStatusInProgress     = "in_progress"
+	TripStatusCompleted      = "completed"
+	TripStatusCancelled      = "cancelled"
+	TripStatusExpired        = "expired"
+)
+
+// ErrInvalidTripStatusTransition is returned when a trip is asked to move
+// to a status that is not reachable from its current one.
+var ErrInvalidTripStatusTransition = errors.New("invalid trip status transition")
+
+// tripStatusTransitions lists, for every status, the statuses a trip may move to next.
+var tripStatusTransitions = map[string][]string{
+	TripStatusScheduled:      {TripStatusRequested, TripStatusCancelled},
+	TripStatusRequested:      {TripStatusBidding, TripStatusAccepted, TripStatusCancelled, TripStatusExpired},
+	TripStatusBidding:        {TripStatusAccepted, TripStatusCancelled, TripStatusExpired},
+	TripStatusAccepted:       {TripStatusDriverArriving, TripStatusCancelled},
+	TripStatusDriverArriving: {TripStatusDriverArrived, TripStatusCancelled},
+	TripStatusDriverArrived:  {TripStatusInProgress, TripStatusCancelled},
+	TripStatusInProgress:     {TripStatusCompleted},
+	TripStatusCompleted:      {},
+	TripStatusCancelled:      {},
+	TripStatusExpired:        {},
+}
+
+// IsValidTripStatus reports whether status is part of the trip lifecycle.
+func IsValidTripStatus(status string) bool {
+	_, ok := tripStatusTransitions[status]
+	return ok
+}
+
+// ValidateTripStatusTransition returns ErrInvalidTripStatusTransition when
+// a trip in status from cannot move to status to.
+func ValidateTripStatusTransition(from, to string) error {
+	for _, next := range tripStatusTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: cannot change trip status from %q to %q", ErrInvalidTripStatusTransition, from, to)
+}
@@ -0,0 +1,26 @@
+package db
+
+import "fmt"
+
+// Trip stop statuses stored in trip_stops.status. A stop starts pending and
+// the driver marks it arrived and then departed.
+const (
+	TripStopStatusPending  = "pending"
+	TripStopStatusArrived  = "arrived"
+	TripStopStatusDeparted = "departed"
+)
+
+// tripStopStatusTransitions maps every stop status to the one that follows it.
+var tripStopStatusTransitions = map[string]string{
+	TripStopStatusPending: TripStopStatusArrived,
+	TripStopStatusArrived: TripStopStatusDeparted,
+}
+
+// ValidateTripStopTransition returns ErrInvalidTripStatusTransition when a
+// stop in status from cannot move to status to.
+func ValidateTripStopTransition(from, to string) error {
+	if next, ok := tripStopStatusTransitions[from]; ok && next == to {
+		return nil
+	}
+	return fmt.Errorf("%w: cannot change stop status from %q to %q", ErrInvalidTripStatusTransition, from, to)
+}
@@ -8,26 +8,290 @@ package db
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
+const assignTripDriver = `-- name: AssignTripDriver :one
+UPDATE trips
+SET
+  trip_status = $1,
+  driver_id = $2,
+  driver_name = $3,
+  driver_mobile = $4,
+  car_id = $5,
+  car_type = $6,
+  car_image = $7,
+  fare = $8,
+  accepted_at = now()
+WHERE booking_id = $9 AND trip_status = $10
+RETURNING id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at, passenger_id, surge_multiplier, cancelled_by, cancel_reason, cancellation_fee, cancelled_at, scheduled_at, reminder_sent_at, accepted_at, arrived_at, started_at, completed_at
+`
+
+type AssignTripDriverParams struct {
+	TripStatus    string         `json:"trip_status"`
+	DriverID      sql.NullInt64  `json:"driver_id"`
+	DriverName    sql.NullString `json:"driver_name"`
+	DriverMobile  sql.NullString `json:"driver_mobile"`
+	CarID         sql.NullInt64  `json:"car_id"`
+	CarType       sql.NullString `json:"car_type"`
+	CarImage      sql.NullString `json:"car_image"`
+	Fare          sql.NullInt64  `json:"fare"`
+	BookingID     string         `json:"booking_id"`
+	CurrentStatus string         `json:"current_status"`
+}
+
+func (q *Queries) AssignTripDriver(ctx context.Context, arg AssignTripDriverParams) (Trip, error) {
+	row := q.db.QueryRowContext(ctx, assignTripDriver,
+		arg.TripStatus,
+		arg.DriverID,
+		arg.DriverName,
+		arg.DriverMobile,
+		arg.CarID,
+		arg.CarType,
+		arg.CarImage,
+		arg.Fare,
+		arg.BookingID,
+		arg.CurrentStatus,
+	)
+	var i Trip
+	err := row.Scan(
+		&i.ID,
+		&i.BookingID,
+		&i.TripStatus,
+		&i.PickupLocation,
+		&i.PickupLat,
+		&i.PickupLong,
+		&i.DropoffLocation,
+		&i.DropoffLat,
+		&i.DropoffLong,
+		&i.DriverID,
+		&i.DriverName,
+		&i.DriverMobile,
+		&i.CarID,
+		&i.CarType,
+		&i.CarImage,
+		&i.Fare,
+		&i.CreatedAt,
+		&i.PassengerID,
+		&i.SurgeMultiplier,
+		&i.CancelledBy,
+		&i.CancelReason,
+		&i.CancellationFee,
+		&i.CancelledAt,
+		&i.ScheduledAt,
+		&i.ReminderSentAt,
+		&i.AcceptedAt,
+		&i.ArrivedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const cancelTrip = `-- name: CancelTrip :one
+UPDATE trips
+SET
+  trip_status = $1,
+  cancelled_by = $2,
+  cancel_reason = $3,
+  cancellation_fee = $4,
+  cancelled_at = now()
+WHERE booking_id = $5 AND trip_status = $6
+RETURNING id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at, passenger_id, surge_multiplier, cancelled_by, cancel_reason, cancellation_fee, cancelled_at, scheduled_at, reminder_sent_at, accepted_at, arrived_at, started_at, completed_at
+`
+
+type CancelTripParams struct {
+	TripStatus      string         `json:"trip_status"`
+	CancelledBy     sql.NullString `json:"cancelled_by"`
+	CancelReason    sql.NullString `json:"cancel_reason"`
+	CancellationFee sql.NullInt64  `json:"cancellation_fee"`
+	BookingID       string         `json:"booking_id"`
+	CurrentStatus   string         `json:"current_status"`
+}
+
+func (q *Queries) CancelTrip(ctx context.Context, arg CancelTripParams) (Trip, error) {
+	row := q.db.QueryRowContext(ctx, cancelTrip,
+		arg.TripStatus,
+		arg.CancelledBy,
+		arg.CancelReason,
+		arg.CancellationFee,
+		arg.BookingID,
+		arg.CurrentStatus,
+	)
+	var i Trip
+	err := row.Scan(
+		&i.ID,
+		&i.BookingID,
+		&i.TripStatus,
+		&i.PickupLocation,
+		&i.PickupLat,
+		&i.PickupLong,
+		&i.DropoffLocation,
+		&i.DropoffLat,
+		&i.DropoffLong,
+		&i.DriverID,
+		&i.DriverName,
+		&i.DriverMobile,
+		&i.CarID,
+		&i.CarType,
+		&i.CarImage,
+		&i.Fare,
+		&i.CreatedAt,
+		&i.PassengerID,
+		&i.SurgeMultiplier,
+		&i.CancelledBy,
+		&i.CancelReason,
+		&i.CancellationFee,
+		&i.CancelledAt,
+		&i.ScheduledAt,
+		&i.ReminderSentAt,
+		&i.AcceptedAt,
+		&i.ArrivedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const completeTrip = `-- name: CompleteTrip :one
+UPDATE trips
+SET
+  trip_status = $1,
+  fare = $2,
+  completed_at = now()
+WHERE booking_id = $3 AND trip_status = $4
+RETURNING id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at, passenger_id, surge_multiplier, cancelled_by, cancel_reason, cancellation_fee, cancelled_at, scheduled_at, reminder_sent_at, accepted_at, arrived_at, started_at, completed_at
+`
+
+type CompleteTripParams struct {
+	TripStatus    string        `json:"trip_status"`
+	Fare          sql.NullInt64 `json:"fare"`
+	BookingID     string        `json:"booking_id"`
+	CurrentStatus string        `json:"current_status"`
+}
+
+func (q *Queries) CompleteTrip(ctx context.Context, arg CompleteTripParams) (Trip, error) {
+	row := q.db.QueryRowContext(ctx, completeTrip,
+		arg.TripStatus,
+		arg.Fare,
+		arg.BookingID,
+		arg.CurrentStatus,
+	)
+	var i Trip
+	err := row.Scan(
+		&i.ID,
+		&i.BookingID,
+		&i.TripStatus,
+		&i.PickupLocation,
+		&i.PickupLat,
+		&i.PickupLong,
+		&i.DropoffLocation,
+		&i.DropoffLat,
+		&i.DropoffLong,
+		&i.DriverID,
+		&i.DriverName,
+		&i.DriverMobile,
+		&i.CarID,
+		&i.CarType,
+		&i.CarImage,
+		&i.Fare,
+		&i.CreatedAt,
+		&i.PassengerID,
+		&i.SurgeMultiplier,
+		&i.CancelledBy,
+		&i.CancelReason,
+		&i.CancellationFee,
+		&i.CancelledAt,
+		&i.ScheduledAt,
+		&i.ReminderSentAt,
+		&i.AcceptedAt,
+		&i.ArrivedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const countOpenTripsInArea = `-- name: CountOpenTripsInArea :one
+SELECT count(*) FROM trips
+WHERE trip_status IN ('requested', 'bidding')
+  AND created_at >= $1
+  AND pickup_lat >= $2 AND pickup_lat < $3
+  AND pickup_long >= $4 AND pickup_long < $5
+`
+
+type CountOpenTripsInAreaParams struct {
+	Since   time.Time `json:"since"`
+	MinLat  float64   `json:"min_lat"`
+	MaxLat  float64   `json:"max_lat"`
+	MinLong float64   `json:"min_long"`
+	MaxLong float64   `json:"max_long"`
+}
+
+func (q *Queries) CountOpenTripsInArea(ctx context.Context, arg CountOpenTripsInAreaParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countOpenTripsInArea,
+		arg.Since,
+		arg.MinLat,
+		arg.MaxLat,
+		arg.MinLong,
+		arg.MaxLong,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countTripHistory = `-- name: CountTripHistory :one
+SELECT count(*) FROM trips
+WHERE ($1::bigint IS NULL OR passenger_id = $1)
+  AND ($2::bigint IS NULL OR driver_id = $2)
+  AND ($3::varchar IS NULL OR trip_status = $3)
+  AND ($4::varchar IS NULL OR car_type = $4)
+  AND ($5::timestamptz IS NULL OR created_at >= $5)
+  AND ($6::timestamptz IS NULL OR created_at < $6)
+`
+
+type CountTripHistoryParams struct {
+	PassengerID sql.NullInt64  `json:"passenger_id"`
+	DriverID    sql.NullInt64  `json:"driver_id"`
+	TripStatus  sql.NullString `json:"trip_status"`
+	CarType     sql.NullString `json:"car_type"`
+	CreatedFrom sql.NullTime   `json:"created_from"`
+	CreatedTo   sql.NullTime   `json:"created_to"`
+}
+
+func (q *Queries) CountTripHistory(ctx context.Context, arg CountTripHistoryParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countTripHistory,
+		arg.PassengerID,
+		arg.DriverID,
+		arg.TripStatus,
+		arg.CarType,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createTrip = `-- name: CreateTrip :one
 INSERT INTO trips (
-  booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare
+  booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, passenger_id, surge_multiplier, scheduled_at
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18
 )
-RETURNING id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at
+RETURNING id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at, passenger_id, surge_multiplier, cancelled_by, cancel_reason, cancellation_fee, cancelled_at, scheduled_at, reminder_sent_at, accepted_at, arrived_at, started_at, completed_at
 `
 
 type CreateTripParams struct {
 	BookingID       string         `json:"booking_id"`
 	TripStatus      string         `json:"trip_status"`
 	PickupLocation  string         `json:"pickup_location"`
-	PickupLat       string         `json:"pickup_lat"`
-	PickupLong      string         `json:"pickup_long"`
+	PickupLat       float64        `json:"pickup_lat"`
+	PickupLong      float64        `json:"pickup_long"`
 	DropoffLocation string         `json:"dropoff_location"`
-	DropoffLat      string         `json:"dropoff_lat"`
-	DropoffLong     string         `json:"dropoff_long"`
+	DropoffLat      float64        `json:"dropoff_lat"`
+	DropoffLong     float64        `json:"dropoff_long"`
 	DriverID        sql.NullInt64  `json:"driver_id"`
 	DriverName      sql.NullString `json:"driver_name"`
 	DriverMobile    sql.NullString `json:"driver_mobile"`
@@ -35,6 +299,9 @@ type CreateTripParams struct {
 	CarType         sql.NullString `json:"car_type"`
 	CarImage        sql.NullString `json:"car_image"`
 	Fare            sql.NullInt64  `json:"fare"`
+	PassengerID     sql.NullInt64  `json:"passenger_id"`
+	SurgeMultiplier float64        `json:"surge_multiplier"`
+	ScheduledAt     sql.NullTime   `json:"scheduled_at"`
 }
 
 func (q *Queries) CreateTrip(ctx context.Context, arg CreateTripParams) (Trip, error) {
@@ -54,6 +321,9 @@ func (q *Queries) CreateTrip(ctx context.Context, arg CreateTripParams) (Trip, e
 		arg.CarType,
 		arg.CarImage,
 		arg.Fare,
+		arg.PassengerID,
+		arg.SurgeMultiplier,
+		arg.ScheduledAt,
 	)
 	var i Trip
 	err := row.Scan(
@@ -74,6 +344,18 @@ func (q *Queries) CreateTrip(ctx context.Context, arg CreateTripParams) (Trip, e
 		&i.CarImage,
 		&i.Fare,
 		&i.CreatedAt,
+		&i.PassengerID,
+		&i.SurgeMultiplier,
+		&i.CancelledBy,
+		&i.CancelReason,
+		&i.CancellationFee,
+		&i.CancelledAt,
+		&i.ScheduledAt,
+		&i.ReminderSentAt,
+		&i.AcceptedAt,
+		&i.ArrivedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
 	)
 	return i, err
 }
@@ -87,8 +369,52 @@ func (q *Queries) DeleteTrip(ctx context.Context, id int64) error {
 	return err
 }
 
+const getDriverActiveTrip = `-- name: GetDriverActiveTrip :one
+SELECT id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at, passenger_id, surge_multiplier, cancelled_by, cancel_reason, cancellation_fee, cancelled_at, scheduled_at, reminder_sent_at, accepted_at, arrived_at, started_at, completed_at FROM trips
+WHERE driver_id = $1 AND trip_status IN ('accepted', 'driver_arriving', 'driver_arrived', 'in_progress')
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetDriverActiveTrip(ctx context.Context, driverID sql.NullInt64) (Trip, error) {
+	row := q.db.QueryRowContext(ctx, getDriverActiveTrip, driverID)
+	var i Trip
+	err := row.Scan(
+		&i.ID,
+		&i.BookingID,
+		&i.TripStatus,
+		&i.PickupLocation,
+		&i.PickupLat,
+		&i.PickupLong,
+		&i.DropoffLocation,
+		&i.DropoffLat,
+		&i.DropoffLong,
+		&i.DriverID,
+		&i.DriverName,
+		&i.DriverMobile,
+		&i.CarID,
+		&i.CarType,
+		&i.CarImage,
+		&i.Fare,
+		&i.CreatedAt,
+		&i.PassengerID,
+		&i.SurgeMultiplier,
+		&i.CancelledBy,
+		&i.CancelReason,
+		&i.CancellationFee,
+		&i.CancelledAt,
+		&i.ScheduledAt,
+		&i.ReminderSentAt,
+		&i.AcceptedAt,
+		&i.ArrivedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
 const getTrip = `-- name: GetTrip :one
-SELECT id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at FROM trips WHERE id = $1 LIMIT 1
+SELECT id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at, passenger_id, surge_multiplier, cancelled_by, cancel_reason, cancellation_fee, cancelled_at, scheduled_at, reminder_sent_at, accepted_at, arrived_at, started_at, completed_at FROM trips WHERE id = $1 LIMIT 1
 `
 
 // Trips
@@ -113,12 +439,24 @@ func (q *Queries) GetTrip(ctx context.Context, id int64) (Trip, error) {
 		&i.CarImage,
 		&i.Fare,
 		&i.CreatedAt,
+		&i.PassengerID,
+		&i.SurgeMultiplier,
+		&i.CancelledBy,
+		&i.CancelReason,
+		&i.CancellationFee,
+		&i.CancelledAt,
+		&i.ScheduledAt,
+		&i.ReminderSentAt,
+		&i.AcceptedAt,
+		&i.ArrivedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
 	)
 	return i, err
 }
 
 const getTripByBookingID = `-- name: GetTripByBookingID :one
-SELECT id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at FROM trips WHERE booking_id = $1 LIMIT 1
+SELECT id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at, passenger_id, surge_multiplier, cancelled_by, cancel_reason, cancellation_fee, cancelled_at, scheduled_at, reminder_sent_at, accepted_at, arrived_at, started_at, completed_at FROM trips WHERE booking_id = $1 LIMIT 1
 `
 
 func (q *Queries) GetTripByBookingID(ctx context.Context, bookingID string) (Trip, error) {
@@ -142,12 +480,508 @@ func (q *Queries) GetTripByBookingID(ctx context.Context, bookingID string) (Tri
 		&i.CarImage,
 		&i.Fare,
 		&i.CreatedAt,
+		&i.PassengerID,
+		&i.SurgeMultiplier,
+		&i.CancelledBy,
+		&i.CancelReason,
+		&i.CancellationFee,
+		&i.CancelledAt,
+		&i.ScheduledAt,
+		&i.ReminderSentAt,
+		&i.AcceptedAt,
+		&i.ArrivedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const getTripByBookingIDForUpdate = `-- name: GetTripByBookingIDForUpdate :one
+SELECT id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at, passenger_id, surge_multiplier, cancelled_by, cancel_reason, cancellation_fee, cancelled_at, scheduled_at, reminder_sent_at, accepted_at, arrived_at, started_at, completed_at FROM trips WHERE booking_id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTripByBookingIDForUpdate(ctx context.Context, bookingID string) (Trip, error) {
+	row := q.db.QueryRowContext(ctx, getTripByBookingIDForUpdate, bookingID)
+	var i Trip
+	err := row.Scan(
+		&i.ID,
+		&i.BookingID,
+		&i.TripStatus,
+		&i.PickupLocation,
+		&i.PickupLat,
+		&i.PickupLong,
+		&i.DropoffLocation,
+		&i.DropoffLat,
+		&i.DropoffLong,
+		&i.DriverID,
+		&i.DriverName,
+		&i.DriverMobile,
+		&i.CarID,
+		&i.CarType,
+		&i.CarImage,
+		&i.Fare,
+		&i.CreatedAt,
+		&i.PassengerID,
+		&i.SurgeMultiplier,
+		&i.CancelledBy,
+		&i.CancelReason,
+		&i.CancellationFee,
+		&i.CancelledAt,
+		&i.ScheduledAt,
+		&i.ReminderSentAt,
+		&i.AcceptedAt,
+		&i.ArrivedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+	)
+	return i, err
+}
+
+const getTripForUpdate = `-- name: GetTripForUpdate :one
+SELECT id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at, passenger_id, surge_multiplier, cancelled_by, cancel_reason, cancellation_fee, cancelled_at, scheduled_at, reminder_sent_at, accepted_at, arrived_at, started_at, completed_at FROM trips WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTripForUpdate(ctx context.Context, id int64) (Trip, error) {
+	row := q.db.QueryRowContext(ctx, getTripForUpdate, id)
+	var i Trip
+	err := row.Scan(
+		&i.ID,
+		&i.BookingID,
+		&i.TripStatus,
+		&i.PickupLocation,
+		&i.PickupLat,
+		&i.PickupLong,
+		&i.DropoffLocation,
+		&i.DropoffLat,
+		&i.DropoffLong,
+		&i.DriverID,
+		&i.DriverName,
+		&i.DriverMobile,
+		&i.CarID,
+		&i.CarType,
+		&i.CarImage,
+		&i.Fare,
+		&i.CreatedAt,
+		&i.PassengerID,
+		&i.SurgeMultiplier,
+		&i.CancelledBy,
+		&i.CancelReason,
+		&i.CancellationFee,
+		&i.CancelledAt,
+		&i.ScheduledAt,
+		&i.ReminderSentAt,
+		&i.AcceptedAt,
+		&i.ArrivedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
 	)
 	return i, err
 }
 
+const listDriverTrips = `-- name: ListDriverTrips :many
+SELECT id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at, passenger_id, surge_multiplier, cancelled_by, cancel_reason, cancellation_fee, cancelled_at, scheduled_at, reminder_sent_at, accepted_at, arrived_at, started_at, completed_at FROM trips
+WHERE driver_id = $1
+ORDER BY created_at DESC
+LIMIT $2
+OFFSET $3
+`
+
+type ListDriverTripsParams struct {
+	DriverID sql.NullInt64 `json:"driver_id"`
+	Limit    int32         `json:"limit"`
+	Offset   int32         `json:"offset"`
+}
+
+func (q *Queries) ListDriverTrips(ctx context.Context, arg ListDriverTripsParams) ([]Trip, error) {
+	rows, err := q.db.QueryContext(ctx, listDriverTrips, arg.DriverID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Trip
+	for rows.Next() {
+		var i Trip
+		if err := rows.Scan(
+			&i.ID,
+			&i.BookingID,
+			&i.TripStatus,
+			&i.PickupLocation,
+			&i.PickupLat,
+			&i.PickupLong,
+			&i.DropoffLocation,
+			&i.DropoffLat,
+			&i.DropoffLong,
+			&i.DriverID,
+			&i.DriverName,
+			&i.DriverMobile,
+			&i.CarID,
+			&i.CarType,
+			&i.CarImage,
+			&i.Fare,
+			&i.CreatedAt,
+			&i.PassengerID,
+			&i.SurgeMultiplier,
+			&i.CancelledBy,
+			&i.CancelReason,
+			&i.CancellationFee,
+			&i.CancelledAt,
+			&i.ScheduledAt,
+			&i.ReminderSentAt,
+			&i.AcceptedAt,
+			&i.ArrivedAt,
+			&i.StartedAt,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDueScheduledTrips = `-- name: ListDueScheduledTrips :many
+SELECT id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at, passenger_id, surge_multiplier, cancelled_by, cancel_reason, cancellation_fee, cancelled_at, scheduled_at, reminder_sent_at, accepted_at, arrived_at, started_at, completed_at FROM trips
+WHERE trip_status = 'scheduled' AND scheduled_at <= $1
+ORDER BY scheduled_at
+`
+
+func (q *Queries) ListDueScheduledTrips(ctx context.Context, scheduledAt sql.NullTime) ([]Trip, error) {
+	rows, err := q.db.QueryContext(ctx, listDueScheduledTrips, scheduledAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Trip
+	for rows.Next() {
+		var i Trip
+		if err := rows.Scan(
+			&i.ID,
+			&i.BookingID,
+			&i.TripStatus,
+			&i.PickupLocation,
+			&i.PickupLat,
+			&i.PickupLong,
+			&i.DropoffLocation,
+			&i.DropoffLat,
+			&i.DropoffLong,
+			&i.DriverID,
+			&i.DriverName,
+			&i.DriverMobile,
+			&i.CarID,
+			&i.CarType,
+			&i.CarImage,
+			&i.Fare,
+			&i.CreatedAt,
+			&i.PassengerID,
+			&i.SurgeMultiplier,
+			&i.CancelledBy,
+			&i.CancelReason,
+			&i.CancellationFee,
+			&i.CancelledAt,
+			&i.ScheduledAt,
+			&i.ReminderSentAt,
+			&i.AcceptedAt,
+			&i.ArrivedAt,
+			&i.StartedAt,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpiredScheduledTrips = `-- name: ListExpiredScheduledTrips :many
+SELECT id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at, passenger_id, surge_multiplier, cancelled_by, cancel_reason, cancellation_fee, cancelled_at, scheduled_at, reminder_sent_at, accepted_at, arrived_at, started_at, completed_at FROM trips
+WHERE trip_status IN ('requested', 'bidding') AND scheduled_at < $1
+ORDER BY scheduled_at
+`
+
+func (q *Queries) ListExpiredScheduledTrips(ctx context.Context, scheduledAt sql.NullTime) ([]Trip, error) {
+	rows, err := q.db.QueryContext(ctx, listExpiredScheduledTrips, scheduledAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Trip
+	for rows.Next() {
+		var i Trip
+		if err := rows.Scan(
+			&i.ID,
+			&i.BookingID,
+			&i.TripStatus,
+			&i.PickupLocation,
+			&i.PickupLat,
+			&i.PickupLong,
+			&i.DropoffLocation,
+			&i.DropoffLat,
+			&i.DropoffLong,
+			&i.DriverID,
+			&i.DriverName,
+			&i.DriverMobile,
+			&i.CarID,
+			&i.CarType,
+			&i.CarImage,
+			&i.Fare,
+			&i.CreatedAt,
+			&i.PassengerID,
+			&i.SurgeMultiplier,
+			&i.CancelledBy,
+			&i.CancelReason,
+			&i.CancellationFee,
+			&i.CancelledAt,
+			&i.ScheduledAt,
+			&i.ReminderSentAt,
+			&i.AcceptedAt,
+			&i.ArrivedAt,
+			&i.StartedAt,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPassengerTrips = `-- name: ListPassengerTrips :many
+SELECT id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at, passenger_id, surge_multiplier, cancelled_by, cancel_reason, cancellation_fee, cancelled_at, scheduled_at, reminder_sent_at, accepted_at, arrived_at, started_at, completed_at FROM trips
+WHERE passenger_id = $1
+ORDER BY created_at DESC
+LIMIT $2
+OFFSET $3
+`
+
+type ListPassengerTripsParams struct {
+	PassengerID sql.NullInt64 `json:"passenger_id"`
+	Limit       int32         `json:"limit"`
+	Offset      int32         `json:"offset"`
+}
+
+func (q *Queries) ListPassengerTrips(ctx context.Context, arg ListPassengerTripsParams) ([]Trip, error) {
+	rows, err := q.db.QueryContext(ctx, listPassengerTrips, arg.PassengerID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Trip
+	for rows.Next() {
+		var i Trip
+		if err := rows.Scan(
+			&i.ID,
+			&i.BookingID,
+			&i.TripStatus,
+			&i.PickupLocation,
+			&i.PickupLat,
+			&i.PickupLong,
+			&i.DropoffLocation,
+			&i.DropoffLat,
+			&i.DropoffLong,
+			&i.DriverID,
+			&i.DriverName,
+			&i.DriverMobile,
+			&i.CarID,
+			&i.CarType,
+			&i.CarImage,
+			&i.Fare,
+			&i.CreatedAt,
+			&i.PassengerID,
+			&i.SurgeMultiplier,
+			&i.CancelledBy,
+			&i.CancelReason,
+			&i.CancellationFee,
+			&i.CancelledAt,
+			&i.ScheduledAt,
+			&i.ReminderSentAt,
+			&i.AcceptedAt,
+			&i.ArrivedAt,
+			&i.StartedAt,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScheduledTripsToRemind = `-- name: ListScheduledTripsToRemind :many
+SELECT id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at, passenger_id, surge_multiplier, cancelled_by, cancel_reason, cancellation_fee, cancelled_at, scheduled_at, reminder_sent_at, accepted_at, arrived_at, started_at, completed_at FROM trips
+WHERE trip_status = 'scheduled' AND reminder_sent_at IS NULL AND scheduled_at <= $1
+ORDER BY scheduled_at
+`
+
+func (q *Queries) ListScheduledTripsToRemind(ctx context.Context, scheduledAt sql.NullTime) ([]Trip, error) {
+	rows, err := q.db.QueryContext(ctx, listScheduledTripsToRemind, scheduledAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Trip
+	for rows.Next() {
+		var i Trip
+		if err := rows.Scan(
+			&i.ID,
+			&i.BookingID,
+			&i.TripStatus,
+			&i.PickupLocation,
+			&i.PickupLat,
+			&i.PickupLong,
+			&i.DropoffLocation,
+			&i.DropoffLat,
+			&i.DropoffLong,
+			&i.DriverID,
+			&i.DriverName,
+			&i.DriverMobile,
+			&i.CarID,
+			&i.CarType,
+			&i.CarImage,
+			&i.Fare,
+			&i.CreatedAt,
+			&i.PassengerID,
+			&i.SurgeMultiplier,
+			&i.CancelledBy,
+			&i.CancelReason,
+			&i.CancellationFee,
+			&i.CancelledAt,
+			&i.ScheduledAt,
+			&i.ReminderSentAt,
+			&i.AcceptedAt,
+			&i.ArrivedAt,
+			&i.StartedAt,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTripHistory = `-- name: ListTripHistory :many
+SELECT id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at, passenger_id, surge_multiplier, cancelled_by, cancel_reason, cancellation_fee, cancelled_at, scheduled_at, reminder_sent_at, accepted_at, arrived_at, started_at, completed_at FROM trips
+WHERE ($1::bigint IS NULL OR passenger_id = $1)
+  AND ($2::bigint IS NULL OR driver_id = $2)
+  AND ($3::varchar IS NULL OR trip_status = $3)
+  AND ($4::varchar IS NULL OR car_type = $4)
+  AND ($5::timestamptz IS NULL OR created_at >= $5)
+  AND ($6::timestamptz IS NULL OR created_at < $6)
+  AND ($7::timestamptz IS NULL
+    OR (created_at, id) < ($7, $8::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT $9
+`
+
+type ListTripHistoryParams struct {
+	PassengerID     sql.NullInt64  `json:"passenger_id"`
+	DriverID        sql.NullInt64  `json:"driver_id"`
+	TripStatus      sql.NullString `json:"trip_status"`
+	CarType         sql.NullString `json:"car_type"`
+	CreatedFrom     sql.NullTime   `json:"created_from"`
+	CreatedTo       sql.NullTime   `json:"created_to"`
+	CursorCreatedAt sql.NullTime   `json:"cursor_created_at"`
+	CursorID        sql.NullInt64  `json:"cursor_id"`
+	PageSize        int32          `json:"page_size"`
+}
+
+func (q *Queries) ListTripHistory(ctx context.Context, arg ListTripHistoryParams) ([]Trip, error) {
+	rows, err := q.db.QueryContext(ctx, listTripHistory,
+		arg.PassengerID,
+		arg.DriverID,
+		arg.TripStatus,
+		arg.CarType,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Trip
+	for rows.Next() {
+		var i Trip
+		if err := rows.Scan(
+			&i.ID,
+			&i.BookingID,
+			&i.TripStatus,
+			&i.PickupLocation,
+			&i.PickupLat,
+			&i.PickupLong,
+			&i.DropoffLocation,
+			&i.DropoffLat,
+			&i.DropoffLong,
+			&i.DriverID,
+			&i.DriverName,
+			&i.DriverMobile,
+			&i.CarID,
+			&i.CarType,
+			&i.CarImage,
+			&i.Fare,
+			&i.CreatedAt,
+			&i.PassengerID,
+			&i.SurgeMultiplier,
+			&i.CancelledBy,
+			&i.CancelReason,
+			&i.CancellationFee,
+			&i.CancelledAt,
+			&i.ScheduledAt,
+			&i.ReminderSentAt,
+			&i.AcceptedAt,
+			&i.ArrivedAt,
+			&i.StartedAt,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTrips = `-- name: ListTrips :many
-SELECT id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at FROM trips ORDER BY created_at DESC LIMIT $1 OFFSET $2
+SELECT id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at, passenger_id, surge_multiplier, cancelled_by, cancel_reason, cancellation_fee, cancelled_at, scheduled_at, reminder_sent_at, accepted_at, arrived_at, started_at, completed_at FROM trips ORDER BY created_at DESC LIMIT $1 OFFSET $2
 `
 
 type ListTripsParams struct {
@@ -182,6 +1016,18 @@ func (q *Queries) ListTrips(ctx context.Context, arg ListTripsParams) ([]Trip, e
 			&i.CarImage,
 			&i.Fare,
 			&i.CreatedAt,
+			&i.PassengerID,
+			&i.SurgeMultiplier,
+			&i.CancelledBy,
+			&i.CancelReason,
+			&i.CancellationFee,
+			&i.CancelledAt,
+			&i.ScheduledAt,
+			&i.ReminderSentAt,
+			&i.AcceptedAt,
+			&i.ArrivedAt,
+			&i.StartedAt,
+			&i.CompletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -196,73 +1042,38 @@ func (q *Queries) ListTrips(ctx context.Context, arg ListTripsParams) ([]Trip, e
 	return items, nil
 }
 
-const tripAccept = `-- name: TripAccept :one
+const markTripReminderSent = `-- name: MarkTripReminderSent :execrows
 UPDATE trips
-SET
-  trip_status = $2,
-  driver_id = $3,
-  driver_name = $4,
-  driver_mobile = $5,
-  fare = $6
-WHERE booking_id = $1
-RETURNING id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at
+SET reminder_sent_at = now()
+WHERE id = $1 AND reminder_sent_at IS NULL
 `
 
-type TripAcceptParams struct {
-	BookingID    string         `json:"booking_id"`
-	TripStatus   string         `json:"trip_status"`
-	DriverID     sql.NullInt64  `json:"driver_id"`
-	DriverName   sql.NullString `json:"driver_name"`
-	DriverMobile sql.NullString `json:"driver_mobile"`
-	Fare         sql.NullInt64  `json:"fare"`
-}
-
-func (q *Queries) TripAccept(ctx context.Context, arg TripAcceptParams) (Trip, error) {
-	row := q.db.QueryRowContext(ctx, tripAccept,
-		arg.BookingID,
-		arg.TripStatus,
-		arg.DriverID,
-		arg.DriverName,
-		arg.DriverMobile,
-		arg.Fare,
-	)
-	var i Trip
-	err := row.Scan(
-		&i.ID,
-		&i.BookingID,
-		&i.TripStatus,
-		&i.PickupLocation,
-		&i.PickupLat,
-		&i.PickupLong,
-		&i.DropoffLocation,
-		&i.DropoffLat,
-		&i.DropoffLong,
-		&i.DriverID,
-		&i.DriverName,
-		&i.DriverMobile,
-		&i.CarID,
-		&i.CarType,
-		&i.CarImage,
-		&i.Fare,
-		&i.CreatedAt,
-	)
-	return i, err
+func (q *Queries) MarkTripReminderSent(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markTripReminderSent, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const updateTripStatus = `-- name: UpdateTripStatus :one
 UPDATE trips
-SET trip_status = $2
-WHERE booking_id = $1
-RETURNING id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at
+SET
+  trip_status = $1,
+  arrived_at = CASE WHEN $1::varchar = 'driver_arrived' THEN now() ELSE arrived_at END,
+  started_at = CASE WHEN $1::varchar = 'in_progress' THEN now() ELSE started_at END
+WHERE booking_id = $2 AND trip_status = $3
+RETURNING id, booking_id, trip_status, pickup_location, pickup_lat, pickup_long, dropoff_location, dropoff_lat, dropoff_long, driver_id, driver_name, driver_mobile, car_id, car_type, car_image, fare, created_at, passenger_id, surge_multiplier, cancelled_by, cancel_reason, cancellation_fee, cancelled_at, scheduled_at, reminder_sent_at, accepted_at, arrived_at, started_at, completed_at
 `
 
 type UpdateTripStatusParams struct {
-	BookingID  string `json:"booking_id"`
-	TripStatus string `json:"trip_status"`
+	TripStatus    string `json:"trip_status"`
+	BookingID     string `json:"booking_id"`
+	CurrentStatus string `json:"current_status"`
 }
 
 func (q *Queries) UpdateTripStatus(ctx context.Context, arg UpdateTripStatusParams) (Trip, error) {
-	row := q.db.QueryRowContext(ctx, updateTripStatus, arg.BookingID, arg.TripStatus)
+	row := q.db.QueryRowContext(ctx, updateTripStatus, arg.TripStatus, arg.BookingID, arg.CurrentStatus)
 	var i Trip
 	err := row.Scan(
 		&i.ID,
@@ -282,6 +1093,18 @@ func (q *Queries) UpdateTripStatus(ctx context.Context, arg UpdateTripStatusPara
 		&i.CarImage,
 		&i.Fare,
 		&i.CreatedAt,
+		&i.PassengerID,
+		&i.SurgeMultiplier,
+		&i.CancelledBy,
+		&i.CancelReason,
+		&i.CancellationFee,
+		&i.CancelledAt,
+		&i.ScheduledAt,
+		&i.ReminderSentAt,
+		&i.AcceptedAt,
+		&i.ArrivedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,137 @@
+package fare
+
+import (
+	"math"
+
+	"github.com/emonoid/toribook.git/location"
+)
+
+const (
+	// roadFactor scales the straight line distance up to a typical road distance.
+	roadFactor = 1.3
+	// averageSpeedKmh is the assumed door to door city driving speed.
+	averageSpeedKmh = 20.0
+	// trafficFactor stretches the trip duration for the upper end of a fare range.
+	trafficFactor = 1.5
+)
+
+// Route is the estimated length of a trip. Multi-stop routes also list each
+// leg between consecutive points.
+type Route struct {
+	DistanceKm      float64 `json:"distance_km"`
+	DurationMinutes float64 `json:"duration_minutes"`
+	Legs            []Route `json:"legs,omitempty"`
+}
+
+// Point is a place on a route.
+type Point struct {
+	Lat  float64
+	Long float64
+}
+
+// EstimateRoute approximates the road distance and driving time between two
+// points from their great-circle distance.
+func EstimateRoute(pickupLat, pickupLong, dropoffLat, dropoffLong float64) Route {
+	distance := location.DistanceKm(pickupLat, pickupLong, dropoffLat, dropoffLong) * roadFactor
+
+	return Route{
+		DistanceKm:      distance,
+		DurationMinutes: distance / averageSpeedKmh * 60,
+	}
+}
+
+// EstimateStops estimates a route visiting points in order as the sum of its
+// legs. A route of two points is the same as EstimateRoute.
+func EstimateStops(points []Point) Route {
+	var route Route
+	for i := 1; i < len(points); i++ {
+		from, to := points[i-1], points[i]
+		leg := EstimateRoute(from.Lat, from.Long, to.Lat, to.Long)
+
+		route.DistanceKm += leg.DistanceKm
+		route.DurationMinutes += leg.DurationMinutes
+		route.Legs = append(route.Legs, leg)
+	}
+
+	if len(route.Legs) < 2 {
+		route.Legs = nil
+	}
+	return route
+}
+
+// Rule holds the pricing of one car type. All amounts are in whole currency
+// units, like trip fares.
+type Rule struct {
+	CarType     string
+	BaseFare    int64
+	PerKm       int64
+	PerMinute   int64
+	MinimumFare int64
+	BookingFee  int64
+}
+
+// Price returns the fare for a trip of the given distance and duration at a
+// surge multiplier. The minimum fare applies before surge, and the booking fee
+// is added on top without surge.
+func (r Rule) Price(distanceKm, durationMinutes, surge float64) int64 {
+	metered := float64(r.BaseFare) + distanceKm*float64(r.PerKm) + durationMinutes*float64(r.PerMinute)
+	metered = math.Max(metered, float64(r.MinimumFare))
+
+	return int64(math.Ceil(metered*surge)) + r.BookingFee
+}
+
+// Breakdown splits a price into the amounts shown to passengers. Its parts
+// always add up to Total, which equals Rule.Price for the same trip.
+type Breakdown struct {
+	BaseFare     int64 `json:"base_fare"`
+	DistanceFare int64 `json:"distance_fare"`
+	TimeFare     int64 `json:"time_fare"`
+	// MinimumFareTopUp lifts short trips to the minimum fare.
+	MinimumFareTopUp int64 `json:"minimum_fare_top_up"`
+	Surge            int64 `json:"surge"`
+	BookingFee       int64 `json:"booking_fee"`
+	Total            int64 `json:"total"`
+}
+
+// Breakdown prices a trip like Price and itemises the result. Rounding up to
+// whole units is charged to the time fare, or to the top-up when the minimum
+// fare applies.
+func (r Rule) Breakdown(distanceKm, durationMinutes, surge float64) Breakdown {
+	distance := distanceKm * float64(r.PerKm)
+	duration := durationMinutes * float64(r.PerMinute)
+	metered := float64(r.BaseFare) + distance + duration
+	subtotal := int64(math.Ceil(math.Max(metered, float64(r.MinimumFare))))
+
+	b := Breakdown{
+		BaseFare:     r.BaseFare,
+		DistanceFare: int64(math.Floor(distance)),
+		BookingFee:   r.BookingFee,
+		Total:        r.Price(distanceKm, durationMinutes, surge),
+	}
+
+	if metered < float64(r.MinimumFare) {
+		b.TimeFare = int64(math.Floor(duration))
+		b.MinimumFareTopUp = subtotal - b.BaseFare - b.DistanceFare - b.TimeFare
+	} else {
+		b.TimeFare = subtotal - b.BaseFare - b.DistanceFare
+	}
+
+	b.Surge = b.Total - b.BookingFee - subtotal
+	return b
+}
+
+// Estimate is the expected fare range of a route for one car type.
+type Estimate struct {
+	CarType string `json:"car_type"`
+	MinFare int64  `json:"min_fare"`
+	MaxFare int64  `json:"max_fare"`
+}
+
+// Estimate prices route as driven in free flowing and in heavy traffic.
+func (r Rule) Estimate(route Route, surge float64) Estimate {
+	return Estimate{
+		CarType: r.CarType,
+		MinFare: r.Price(route.DistanceKm, route.DurationMinutes, surge),
+		MaxFare: r.Price(route.DistanceKm, route.DurationMinutes*trafficFactor, surge),
+	}
+}
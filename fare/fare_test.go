@@ -0,0 +1,199 @@
+package fare
+
+import (
+	"testing"
+
+	"github.com/emonoid/toribook.git/location"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateRoute(t *testing.T) {
+	// Banani to Motijheel
+	route := EstimateRoute(23.7937, 90.4066, 23.7330, 90.4172)
+
+	straight := location.DistanceKm(23.7937, 90.4066, 23.7330, 90.4172)
+	require.InDelta(t, straight*roadFactor, route.DistanceKm, 1e-9)
+	require.InDelta(t, route.DistanceKm/averageSpeedKmh*60, route.DurationMinutes, 1e-9)
+
+	route = EstimateRoute(23.7937, 90.4066, 23.7937, 90.4066)
+	require.Zero(t, route.DistanceKm)
+	require.Zero(t, route.DurationMinutes)
+}
+
+func TestEstimateStops(t *testing.T) {
+	banani := Point{Lat: 23.7937, Long: 90.4066}
+	gulshan := Point{Lat: 23.7925, Long: 90.4078}
+	motijheel := Point{Lat: 23.7330, Long: 90.4172}
+
+	direct := EstimateStops([]Point{banani, motijheel})
+	require.Equal(t, EstimateRoute(banani.Lat, banani.Long, motijheel.Lat, motijheel.Long), direct)
+
+	route := EstimateStops([]Point{banani, gulshan, motijheel})
+	require.Len(t, route.Legs, 2)
+	require.InDelta(t, route.Legs[0].DistanceKm+route.Legs[1].DistanceKm, route.DistanceKm, 1e-9)
+	require.InDelta(t, route.Legs[0].DurationMinutes+route.Legs[1].DurationMinutes, route.DurationMinutes, 1e-9)
+	require.Greater(t, route.DistanceKm, direct.DistanceKm)
+
+	require.Zero(t, EstimateStops([]Point{banani}).DistanceKm)
+}
+
+func TestRulePrice(t *testing.T) {
+	rule := Rule{
+		CarType:     "sedan",
+		BaseFare:    50,
+		PerKm:       20,
+		PerMinute:   3,
+		MinimumFare: 120,
+		BookingFee:  10,
+	}
+
+	testCases := []struct {
+		name     string
+		distance float64
+		duration float64
+		surge    float64
+		price    int64
+	}{
+		{
+			name:     "Metered",
+			distance: 10,
+			duration: 30,
+			surge:    1,
+			price:    50 + 200 + 90 + 10,
+		},
+		{
+			name:     "RoundsUp",
+			distance: 5.01,
+			duration: 15,
+			surge:    1,
+			price:    50 + 101 + 45 + 10,
+		},
+		{
+			name:     "MinimumFare",
+			distance: 1,
+			duration: 3,
+			surge:    1,
+			price:    120 + 10,
+		},
+		{
+			name:     "Surge",
+			distance: 10,
+			duration: 30,
+			surge:    1.5,
+			price:    510 + 10,
+		},
+		{
+			name:     "SurgedMinimumFare",
+			distance: 1,
+			duration: 3,
+			surge:    1.5,
+			price:    180 + 10,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.price, rule.Price(tc.distance, tc.duration, tc.surge))
+		})
+	}
+}
+
+func TestRuleBreakdown(t *testing.T) {
+	rule := Rule{
+		CarType:     "sedan",
+		BaseFare:    50,
+		PerKm:       20,
+		PerMinute:   3,
+		MinimumFare: 120,
+		BookingFee:  10,
+	}
+
+	testCases := []struct {
+		name      string
+		distance  float64
+		duration  float64
+		surge     float64
+		breakdown Breakdown
+	}{
+		{
+			name:     "Metered",
+			distance: 10,
+			duration: 30,
+			surge:    1,
+			breakdown: Breakdown{
+				BaseFare:     50,
+				DistanceFare: 200,
+				TimeFare:     90,
+				BookingFee:   10,
+				Total:        350,
+			},
+		},
+		{
+			name:     "RoundsUp",
+			distance: 5.01,
+			duration: 15,
+			surge:    1,
+			breakdown: Breakdown{
+				BaseFare:     50,
+				DistanceFare: 100,
+				TimeFare:     46,
+				BookingFee:   10,
+				Total:        206,
+			},
+		},
+		{
+			name:     "MinimumFare",
+			distance: 1,
+			duration: 3,
+			surge:    1,
+			breakdown: Breakdown{
+				BaseFare:         50,
+				DistanceFare:     20,
+				TimeFare:         9,
+				MinimumFareTopUp: 41,
+				BookingFee:       10,
+				Total:            130,
+			},
+		},
+		{
+			name:     "Surge",
+			distance: 10,
+			duration: 30,
+			surge:    1.5,
+			breakdown: Breakdown{
+				BaseFare:     50,
+				DistanceFare: 200,
+				TimeFare:     90,
+				Surge:        170,
+				BookingFee:   10,
+				Total:        520,
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			b := rule.Breakdown(tc.distance, tc.duration, tc.surge)
+			require.Equal(t, tc.breakdown, b)
+			require.Equal(t, rule.Price(tc.distance, tc.duration, tc.surge), b.Total)
+			require.Equal(t, b.Total, b.BaseFare+b.DistanceFare+b.TimeFare+b.MinimumFareTopUp+b.Surge+b.BookingFee)
+		})
+	}
+}
+
+func TestRuleEstimate(t *testing.T) {
+	rule := Rule{CarType: "sedan", BaseFare: 50, PerKm: 20, PerMinute: 3}
+
+	estimate := rule.Estimate(Route{DistanceKm: 10, DurationMinutes: 30}, 1)
+	require.Equal(t, "sedan", estimate.CarType)
+	require.Equal(t, int64(340), estimate.MinFare)
+	require.Equal(t, int64(385), estimate.MaxFare)
+
+	estimate = rule.Estimate(Route{DistanceKm: 10, DurationMinutes: 30}, 2)
+	require.Equal(t, int64(680), estimate.MinFare)
+	require.Equal(t, int64(770), estimate.MaxFare)
+}
@@ -0,0 +1,68 @@
+package fare
+
+import (
+	"math"
+
+	"github.com/emonoid/toribook.git/location"
+)
+
+const (
+	// CellDegrees is the edge length of a surge cell, about 2km around Dhaka.
+	CellDegrees = 0.02
+	// surgeSensitivity is how much the multiplier grows for every extra open
+	// request per available driver.
+	surgeSensitivity = 0.5
+)
+
+// Cell is a square of the latitude/longitude grid that demand and supply are
+// compared in. Min bounds are inclusive, max bounds exclusive.
+type Cell struct {
+	MinLat  float64
+	MinLong float64
+	MaxLat  float64
+	MaxLong float64
+}
+
+// CellOf returns the grid cell containing the point.
+func CellOf(lat, long float64) Cell {
+	minLat := math.Floor(lat/CellDegrees) * CellDegrees
+	minLong := math.Floor(long/CellDegrees) * CellDegrees
+
+	return Cell{
+		MinLat:  minLat,
+		MinLong: minLong,
+		MaxLat:  minLat + CellDegrees,
+		MaxLong: minLong + CellDegrees,
+	}
+}
+
+// Center returns the middle of the cell.
+func (c Cell) Center() (lat, long float64) {
+	return (c.MinLat + c.MaxLat) / 2, (c.MinLong + c.MaxLong) / 2
+}
+
+// RadiusKm returns the distance from the center to the corners, so a radius
+// search around the center covers the whole cell.
+func (c Cell) RadiusKm() float64 {
+	lat, long := c.Center()
+	return location.DistanceKm(lat, long, c.MaxLat, c.MaxLong)
+}
+
+// Contains reports whether the point lies in the cell.
+func (c Cell) Contains(lat, long float64) bool {
+	return lat >= c.MinLat && lat < c.MaxLat && long >= c.MinLong && long < c.MaxLong
+}
+
+// SurgeMultiplier prices demand against supply. It stays at 1 while there is
+// an available driver for every open request, then grows with the ratio in
+// steps of 0.1 up to maxMultiplier.
+func SurgeMultiplier(openRequests, availableDrivers int64, maxMultiplier float64) float64 {
+	if openRequests <= availableDrivers || maxMultiplier <= 1 {
+		return 1
+	}
+
+	ratio := float64(openRequests) / math.Max(float64(availableDrivers), 1)
+	multiplier := math.Round((1+(ratio-1)*surgeSensitivity)*10) / 10
+
+	return math.Min(multiplier, maxMultiplier)
+}
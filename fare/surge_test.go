@@ -0,0 +1,91 @@
+package fare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCellOf(t *testing.T) {
+	cell := CellOf(23.7937, 90.4066)
+	require.InDelta(t, 23.78, cell.MinLat, 1e-9)
+	require.InDelta(t, 23.80, cell.MaxLat, 1e-9)
+	require.InDelta(t, 90.40, cell.MinLong, 1e-9)
+	require.InDelta(t, 90.42, cell.MaxLong, 1e-9)
+
+	require.True(t, cell.Contains(23.7937, 90.4066))
+	require.True(t, cell.Contains(cell.MinLat, cell.MinLong))
+	require.False(t, cell.Contains(23.8001, 90.4066))
+
+	// points in the same cell share it, neighbours do not
+	require.Equal(t, cell, CellOf(23.7801, 90.4199))
+	require.NotEqual(t, cell, CellOf(23.7799, 90.4066))
+
+	lat, long := cell.Center()
+	require.True(t, cell.Contains(lat, long))
+	require.InDelta(t, 1.5, cell.RadiusKm(), 0.1)
+
+	southWest := CellOf(-33.8688, -151.2093)
+	require.True(t, southWest.Contains(-33.8688, -151.2093))
+}
+
+func TestSurgeMultiplier(t *testing.T) {
+	testCases := []struct {
+		name       string
+		requests   int64
+		drivers    int64
+		max        float64
+		multiplier float64
+	}{
+		{
+			name:       "NoDemand",
+			requests:   0,
+			drivers:    0,
+			max:        3,
+			multiplier: 1,
+		},
+		{
+			name:       "EnoughDrivers",
+			requests:   5,
+			drivers:    5,
+			max:        3,
+			multiplier: 1,
+		},
+		{
+			name:       "TwiceTheDemand",
+			requests:   10,
+			drivers:    5,
+			max:        3,
+			multiplier: 1.5,
+		},
+		{
+			name:       "NoDrivers",
+			requests:   3,
+			drivers:    0,
+			max:        3,
+			multiplier: 2,
+		},
+		{
+			name:       "Capped",
+			requests:   40,
+			drivers:    2,
+			max:        2.5,
+			multiplier: 2.5,
+		},
+		{
+			name:       "SurgeDisabled",
+			requests:   40,
+			drivers:    2,
+			max:        1,
+			multiplier: 1,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.multiplier, SurgeMultiplier(tc.requests, tc.drivers, tc.max))
+		})
+	}
+}
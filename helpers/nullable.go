@@ -1,6 +1,9 @@
 package helpers
 
-import "database/sql"
+import (
+	"database/sql"
+	"time"
+)
 
 /// converting nullable types to sql.Null types
 func MakeNullString(s *string) sql.NullString {
@@ -46,3 +49,17 @@ func NullInt32ToPtr(n sql.NullInt32) *int32 {
 	}
 	return nil
 }
+
+func NullTimeToPtr(n sql.NullTime) *time.Time {
+	if n.Valid {
+		return &n.Time
+	}
+	return nil
+}
+
+func NullFloat64ToPtr(n sql.NullFloat64) *float64 {
+	if n.Valid {
+		return &n.Float64
+	}
+	return nil
+}
@@ -57,3 +57,10 @@ func (m *WebSocketManager) Broadcast(channel string, data interface{}) {
 	m.clients[channel] = activeConns
 }
 
+
+// ClientCount returns how many connections are subscribed to channel.
+func (m *WebSocketManager) ClientCount(channel string) int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return len(m.clients[channel])
+}
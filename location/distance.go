@@ -0,0 +1,22 @@
+package location
+
+import "math"
+
+// earthRadiusKm is the mean radius of the earth used for great-circle distances.
+const earthRadiusKm = 6371.0
+
+// DistanceKm returns the great-circle distance between two points using the
+// haversine formula.
+func DistanceKm(lat1, long1, lat2, long2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLong := toRadians(long2 - long1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLong/2)*math.Sin(dLong/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
@@ -0,0 +1,39 @@
+package location
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrLocationNotFound is returned when no recent position is known for a driver.
+var ErrLocationNotFound = errors.New("driver location not found")
+
+// Fix is a single GPS position reported by a driver.
+type Fix struct {
+	DriverID   int64     `json:"driver_id"`
+	Lat        float64   `json:"lat"`
+	Long       float64   `json:"long"`
+	Heading    float64   `json:"heading"`
+	Speed      float64   `json:"speed"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// NearbyDriver is a driver position found by a radius search.
+type NearbyDriver struct {
+	Fix
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// Store keeps the latest position of every driver.
+type Store interface {
+	SaveLocation(ctx context.Context, fix Fix) error
+	GetLocation(ctx context.Context, driverID int64) (Fix, error)
+	// Nearby returns up to count drivers with a fresh position within
+	// radiusKm of the point, closest first.
+	Nearby(ctx context.Context, lat, long, radiusKm float64, count int) ([]NearbyDriver, error)
+}
+
+// staleAfter is how long a position is kept without a newer fix; older ones
+// are treated as unknown since the driver has probably gone offline.
+const staleAfter = 10 * time.Minute
@@ -0,0 +1,69 @@
+package location
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps driver positions in process memory. It is used when
+// redis is not configured and only works for a single server instance.
+type MemoryStore struct {
+	fixes map[int64]Fix
+	lock  sync.RWMutex
+}
+
+func NewMemoryStore() Store {
+	return &MemoryStore{
+		fixes: make(map[int64]Fix),
+	}
+}
+
+// SaveLocation implements Store.
+func (store *MemoryStore) SaveLocation(ctx context.Context, fix Fix) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	store.fixes[fix.DriverID] = fix
+	return nil
+}
+
+// GetLocation implements Store.
+func (store *MemoryStore) GetLocation(ctx context.Context, driverID int64) (Fix, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	fix, ok := store.fixes[driverID]
+	if !ok || time.Since(fix.RecordedAt) > staleAfter {
+		return Fix{}, ErrLocationNotFound
+	}
+	return fix, nil
+}
+
+// Nearby implements Store.
+func (store *MemoryStore) Nearby(ctx context.Context, lat, long, radiusKm float64, count int) ([]NearbyDriver, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	var drivers []NearbyDriver
+	for _, fix := range store.fixes {
+		if time.Since(fix.RecordedAt) > staleAfter {
+			continue
+		}
+
+		distance := DistanceKm(lat, long, fix.Lat, fix.Long)
+		if distance <= radiusKm {
+			drivers = append(drivers, NearbyDriver{Fix: fix, DistanceKm: distance})
+		}
+	}
+
+	sort.Slice(drivers, func(i, j int) bool {
+		return drivers[i].DistanceKm < drivers[j].DistanceKm
+	})
+
+	if len(drivers) > count {
+		drivers = drivers[:count]
+	}
+	return drivers, nil
+}
@@ -0,0 +1,111 @@
+package location
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisStore keeps driver positions in redis so every server instance sees them.
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(client *redis.Client) Store {
+	return &RedisStore{client: client}
+}
+
+// driverLocationsGeoKey is the geo index of every driver's latest position.
+// Members do not expire on their own, so searches drop drivers whose
+// driver_location key has expired.
+const driverLocationsGeoKey = "driver_locations"
+
+func driverLocationKey(driverID int64) string {
+	return fmt.Sprintf("driver_location:%d", driverID)
+}
+
+// SaveLocation implements Store.
+func (store *RedisStore) SaveLocation(ctx context.Context, fix Fix) error {
+	fixJSON, err := json.Marshal(fix)
+	if err != nil {
+		return err
+	}
+
+	_, err = store.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, driverLocationKey(fix.DriverID), fixJSON, staleAfter)
+		pipe.GeoAdd(ctx, driverLocationsGeoKey, &redis.GeoLocation{
+			Name:      strconv.FormatInt(fix.DriverID, 10),
+			Longitude: fix.Long,
+			Latitude:  fix.Lat,
+		})
+		return nil
+	})
+	return err
+}
+
+// GetLocation implements Store.
+func (store *RedisStore) GetLocation(ctx context.Context, driverID int64) (Fix, error) {
+	var fix Fix
+
+	fixJSON, err := store.client.Get(ctx, driverLocationKey(driverID)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return fix, ErrLocationNotFound
+		}
+		return fix, err
+	}
+
+	err = json.Unmarshal(fixJSON, &fix)
+	return fix, err
+}
+
+// Nearby implements Store.
+func (store *RedisStore) Nearby(ctx context.Context, lat, long, radiusKm float64, count int) ([]NearbyDriver, error) {
+	locations, err := store.client.GeoRadius(ctx, driverLocationsGeoKey, long, lat, &redis.GeoRadiusQuery{
+		Radius:   radiusKm,
+		Unit:     "km",
+		WithDist: true,
+		Sort:     "ASC",
+		Count:    count,
+	}).Result()
+	if err != nil || len(locations) == 0 {
+		return nil, err
+	}
+
+	keys := make([]string, len(locations))
+	for i, loc := range locations {
+		keys[i] = "driver_location:" + loc.Name
+	}
+
+	fixes, err := store.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var drivers []NearbyDriver
+	var stale []interface{}
+	for i, value := range fixes {
+		fixJSON, ok := value.(string)
+		if !ok {
+			stale = append(stale, locations[i].Name)
+			continue
+		}
+
+		var fix Fix
+		if err := json.Unmarshal([]byte(fixJSON), &fix); err != nil {
+			return nil, err
+		}
+		drivers = append(drivers, NearbyDriver{Fix: fix, DistanceKm: locations[i].Dist})
+	}
+
+	if len(stale) > 0 {
+		if err := store.client.ZRem(ctx, driverLocationsGeoKey, stale...).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return drivers, nil
+}
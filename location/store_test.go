@@ -0,0 +1,127 @@
+package location
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/emonoid/toribook.git/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStores(t *testing.T) map[string]Store {
+	redisServer := miniredis.RunT(t)
+
+	return map[string]Store{
+		"Redis":  NewRedisStore(utils.NewRedisClient(redisServer.Addr())),
+		"Memory": NewMemoryStore(),
+	}
+}
+
+func randomFix(driverID int64) Fix {
+	return Fix{
+		DriverID:   driverID,
+		Lat:        23.7 + float64(utils.RandomInt(0, 1000))/10000,
+		Long:       90.4 + float64(utils.RandomInt(0, 1000))/10000,
+		Heading:    float64(utils.RandomInt(0, 359)),
+		Speed:      float64(utils.RandomInt(0, 20)),
+		RecordedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+}
+
+func TestSaveAndGetLocation(t *testing.T) {
+	for name, store := range newTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			driverID := utils.RandomInt(1, 1000)
+
+			first := randomFix(driverID)
+			require.NoError(t, store.SaveLocation(context.Background(), first))
+
+			second := randomFix(driverID)
+			require.NoError(t, store.SaveLocation(context.Background(), second))
+
+			fix, err := store.GetLocation(context.Background(), driverID)
+			require.NoError(t, err)
+			require.Equal(t, second, fix)
+		})
+	}
+}
+
+func TestGetUnknownLocation(t *testing.T) {
+	for name, store := range newTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			fix, err := store.GetLocation(context.Background(), utils.RandomInt(1, 1000))
+			require.ErrorIs(t, err, ErrLocationNotFound)
+			require.Empty(t, fix)
+		})
+	}
+}
+
+func TestStaleLocation(t *testing.T) {
+	store := NewMemoryStore()
+
+	fix := randomFix(utils.RandomInt(1, 1000))
+	fix.RecordedAt = time.Now().Add(-staleAfter - time.Minute)
+	require.NoError(t, store.SaveLocation(context.Background(), fix))
+
+	_, err := store.GetLocation(context.Background(), fix.DriverID)
+	require.ErrorIs(t, err, ErrLocationNotFound)
+}
+
+func TestNearby(t *testing.T) {
+	// a pickup in Gulshan with drivers at increasing distances
+	pickupLat, pickupLong := 23.7925, 90.4078
+
+	for name, store := range newTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			near := Fix{DriverID: 1, Lat: 23.7930, Long: 90.4080, RecordedAt: time.Now().UTC()}
+			farther := Fix{DriverID: 2, Lat: 23.8100, Long: 90.4120, RecordedAt: time.Now().UTC()}
+			outside := Fix{DriverID: 3, Lat: 23.7000, Long: 90.3500, RecordedAt: time.Now().UTC()}
+
+			for _, fix := range []Fix{outside, farther, near} {
+				require.NoError(t, store.SaveLocation(context.Background(), fix))
+			}
+
+			drivers, err := store.Nearby(context.Background(), pickupLat, pickupLong, 5, 10)
+			require.NoError(t, err)
+			require.Len(t, drivers, 2)
+
+			require.Equal(t, near.DriverID, drivers[0].DriverID)
+			require.Equal(t, farther.DriverID, drivers[1].DriverID)
+			require.Less(t, drivers[0].DistanceKm, drivers[1].DistanceKm)
+			require.InDelta(t, DistanceKm(pickupLat, pickupLong, farther.Lat, farther.Long), drivers[1].DistanceKm, 0.01)
+
+			drivers, err = store.Nearby(context.Background(), pickupLat, pickupLong, 5, 1)
+			require.NoError(t, err)
+			require.Len(t, drivers, 1)
+			require.Equal(t, near.DriverID, drivers[0].DriverID)
+		})
+	}
+}
+
+func TestNearbySkipsStaleDrivers(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	store := NewRedisStore(utils.NewRedisClient(redisServer.Addr()))
+
+	fix := Fix{DriverID: 7, Lat: 23.7930, Long: 90.4080, RecordedAt: time.Now().UTC()}
+	require.NoError(t, store.SaveLocation(context.Background(), fix))
+
+	redisServer.FastForward(staleAfter + time.Minute)
+
+	drivers, err := store.Nearby(context.Background(), fix.Lat, fix.Long, 1, 10)
+	require.NoError(t, err)
+	require.Empty(t, drivers)
+
+	// the stale member is pruned from the geo index
+	require.False(t, redisServer.Exists(driverLocationsGeoKey))
+}
+
+func TestDistanceKm(t *testing.T) {
+	require.Zero(t, DistanceKm(23.7925, 90.4078, 23.7925, 90.4078))
+
+	// Dhaka to Chattogram is roughly 213 km in a straight line
+	require.InDelta(t, 213, DistanceKm(23.8103, 90.4125, 22.3569, 91.7832), 5)
+
+	require.InDelta(t, DistanceKm(1, 2, 3, 4), DistanceKm(3, 4, 1, 2), 1e-9)
+}
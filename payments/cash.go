@@ -0,0 +1,51 @@
+package payments
+
+import "context"
+
+// CashProvider is cash on delivery: the driver collects the fare at the end
+// of the trip, so nothing is held up front and there is nobody to call back.
+type CashProvider struct{}
+
+func NewCashProvider() Provider {
+	return CashProvider{}
+}
+
+// Name implements Provider.
+func (CashProvider) Name() string {
+	return ProviderCash
+}
+
+// Authorize implements Provider. A cash payment is accepted as promised.
+func (CashProvider) Authorize(ctx context.Context, req AuthorizeRequest) (Authorization, error) {
+	if req.Amount <= 0 {
+		return Authorization{}, ErrInvalidAmount
+	}
+	return Authorization{ProviderRef: newRef("cash_"), Amount: req.Amount}, nil
+}
+
+// Capture implements Provider. The cash changes hands in the car.
+func (CashProvider) Capture(ctx context.Context, providerRef string, amount int64) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	return nil
+}
+
+// Refund implements Provider. Cash refunds are credited to the passenger's
+// wallet by the ledger, so the provider has nothing to do.
+func (CashProvider) Refund(ctx context.Context, providerRef string, amount int64) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	return nil
+}
+
+// Void implements Provider. Nothing was held, so there is nothing to release.
+func (CashProvider) Void(ctx context.Context, providerRef string) error {
+	return nil
+}
+
+// VerifyWebhook implements Provider.
+func (CashProvider) VerifyWebhook(payload []byte, signature string) (Event, error) {
+	return Event{}, ErrWebhooksUnsupported
+}
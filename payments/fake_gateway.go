@@ -0,0 +1,152 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+)
+
+// Card tokens understood by FakeGateway. Any other non-empty token is
+// authorized like FakeCardOK.
+const (
+	FakeCardOK       = "tok_visa"
+	FakeCardDeclined = "tok_declined"
+)
+
+// FakeGateway is a card gateway that runs in process memory. It keeps the
+// rules of a real gateway, like never capturing more than was authorized,
+// and signs its webhooks, so the whole payment flow can be exercised in
+// development and tests without any outside service.
+type FakeGateway struct {
+	secret  []byte
+	charges map[string]*fakeCharge
+	lock    sync.Mutex
+}
+
+type fakeCharge struct {
+	authorized int64
+	captured   int64
+	refunded   int64
+	voided     bool
+}
+
+// NewFakeGateway returns a gateway signing its webhooks with webhookSecret.
+func NewFakeGateway(webhookSecret string) *FakeGateway {
+	return &FakeGateway{
+		secret:  []byte(webhookSecret),
+		charges: make(map[string]*fakeCharge),
+	}
+}
+
+// Name implements Provider.
+func (gateway *FakeGateway) Name() string {
+	return ProviderFakeCard
+}
+
+// Authorize implements Provider.
+func (gateway *FakeGateway) Authorize(ctx context.Context, req AuthorizeRequest) (Authorization, error) {
+	if req.Amount <= 0 {
+		return Authorization{}, ErrInvalidAmount
+	}
+	if req.PaymentMethod == "" || req.PaymentMethod == FakeCardDeclined {
+		return Authorization{}, ErrDeclined
+	}
+
+	gateway.lock.Lock()
+	defer gateway.lock.Unlock()
+
+	ref := newRef("ch_")
+	gateway.charges[ref] = &fakeCharge{authorized: req.Amount}
+
+	return Authorization{ProviderRef: ref, Amount: req.Amount}, nil
+}
+
+// Capture implements Provider. A charge is captured once; the rest of the
+// authorization is released.
+func (gateway *FakeGateway) Capture(ctx context.Context, providerRef string, amount int64) error {
+	gateway.lock.Lock()
+	defer gateway.lock.Unlock()
+
+	charge, ok := gateway.charges[providerRef]
+	if !ok {
+		return ErrChargeNotFound
+	}
+	if amount <= 0 || amount > charge.authorized || charge.captured > 0 || charge.voided {
+		return ErrInvalidAmount
+	}
+
+	charge.captured = amount
+	return nil
+}
+
+// Refund implements Provider.
+func (gateway *FakeGateway) Refund(ctx context.Context, providerRef string, amount int64) error {
+	gateway.lock.Lock()
+	defer gateway.lock.Unlock()
+
+	charge, ok := gateway.charges[providerRef]
+	if !ok {
+		return ErrChargeNotFound
+	}
+	if amount <= 0 || amount > charge.captured-charge.refunded {
+		return ErrInvalidAmount
+	}
+
+	charge.refunded += amount
+	return nil
+}
+
+// Void implements Provider. Only a charge that was not captured can be
+// voided, and voiding it again is a no-op.
+func (gateway *FakeGateway) Void(ctx context.Context, providerRef string) error {
+	gateway.lock.Lock()
+	defer gateway.lock.Unlock()
+
+	charge, ok := gateway.charges[providerRef]
+	if !ok {
+		return ErrChargeNotFound
+	}
+	if charge.captured > 0 {
+		return ErrInvalidAmount
+	}
+
+	charge.voided = true
+	return nil
+}
+
+// VerifyWebhook implements Provider.
+func (gateway *FakeGateway) VerifyWebhook(payload []byte, signature string) (Event, error) {
+	if !hmac.Equal([]byte(gateway.sign(payload)), []byte(signature)) {
+		return Event{}, ErrInvalidSignature
+	}
+
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return Event{}, err
+	}
+	return event, nil
+}
+
+// Webhook returns the body and signature the gateway would send to report
+// event, for simulating gateway callbacks.
+func (gateway *FakeGateway) Webhook(event Event) ([]byte, string, error) {
+	if event.ID == "" {
+		event.ID = newRef("evt_")
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, "", err
+	}
+	return payload, gateway.sign(payload), nil
+}
+
+// sign is the hex encoded HMAC-SHA256 of payload under the webhook secret.
+func (gateway *FakeGateway) sign(payload []byte) string {
+	mac := hmac.New(sha256.New, gateway.secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
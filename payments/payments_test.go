@@ -0,0 +1,153 @@
+package payments
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	cash := NewCashProvider()
+	card := NewFakeGateway("secret")
+	registry := NewRegistry(cash, card)
+
+	provider, err := registry.Get(ProviderCash)
+	require.NoError(t, err)
+	require.Equal(t, cash, provider)
+
+	provider, err = registry.Get(ProviderFakeCard)
+	require.NoError(t, err)
+	require.Equal(t, card, provider)
+
+	_, err = registry.Get("paypal")
+	require.ErrorIs(t, err, ErrUnknownProvider)
+}
+
+func TestCashProvider(t *testing.T) {
+	ctx := context.Background()
+	cash := NewCashProvider()
+
+	auth, err := cash.Authorize(ctx, AuthorizeRequest{Amount: 350, Reference: "TB-ABC234"})
+	require.NoError(t, err)
+	require.Equal(t, int64(350), auth.Amount)
+	require.NotEmpty(t, auth.ProviderRef)
+
+	other, err := cash.Authorize(ctx, AuthorizeRequest{Amount: 350, Reference: "TB-ABC234"})
+	require.NoError(t, err)
+	require.NotEqual(t, auth.ProviderRef, other.ProviderRef)
+
+	require.NoError(t, cash.Capture(ctx, auth.ProviderRef, 350))
+	require.NoError(t, cash.Refund(ctx, auth.ProviderRef, 50))
+	require.NoError(t, cash.Void(ctx, other.ProviderRef))
+
+	_, err = cash.Authorize(ctx, AuthorizeRequest{Amount: 0})
+	require.ErrorIs(t, err, ErrInvalidAmount)
+
+	_, err = cash.VerifyWebhook([]byte("{}"), "signature")
+	require.ErrorIs(t, err, ErrWebhooksUnsupported)
+}
+
+func TestFakeGatewayCharge(t *testing.T) {
+	ctx := context.Background()
+	gateway := NewFakeGateway("secret")
+
+	auth, err := gateway.Authorize(ctx, AuthorizeRequest{Amount: 400, PaymentMethod: FakeCardOK})
+	require.NoError(t, err)
+	require.Equal(t, int64(400), auth.Amount)
+
+	// more than the hold can never be taken
+	require.ErrorIs(t, gateway.Capture(ctx, auth.ProviderRef, 401), ErrInvalidAmount)
+	require.NoError(t, gateway.Capture(ctx, auth.ProviderRef, 350))
+	require.ErrorIs(t, gateway.Capture(ctx, auth.ProviderRef, 50), ErrInvalidAmount)
+
+	require.NoError(t, gateway.Refund(ctx, auth.ProviderRef, 300))
+	require.ErrorIs(t, gateway.Refund(ctx, auth.ProviderRef, 51), ErrInvalidAmount)
+	require.NoError(t, gateway.Refund(ctx, auth.ProviderRef, 50))
+
+	require.ErrorIs(t, gateway.Capture(ctx, "ch_missing", 10), ErrChargeNotFound)
+	require.ErrorIs(t, gateway.Refund(ctx, "ch_missing", 10), ErrChargeNotFound)
+}
+
+func TestFakeGatewayVoid(t *testing.T) {
+	ctx := context.Background()
+	gateway := NewFakeGateway("secret")
+
+	auth, err := gateway.Authorize(ctx, AuthorizeRequest{Amount: 400, PaymentMethod: FakeCardOK})
+	require.NoError(t, err)
+
+	require.NoError(t, gateway.Void(ctx, auth.ProviderRef))
+	require.NoError(t, gateway.Void(ctx, auth.ProviderRef))
+	// a released hold cannot be taken any more
+	require.ErrorIs(t, gateway.Capture(ctx, auth.ProviderRef, 400), ErrInvalidAmount)
+
+	captured, err := gateway.Authorize(ctx, AuthorizeRequest{Amount: 400, PaymentMethod: FakeCardOK})
+	require.NoError(t, err)
+	require.NoError(t, gateway.Capture(ctx, captured.ProviderRef, 400))
+	require.ErrorIs(t, gateway.Void(ctx, captured.ProviderRef), ErrInvalidAmount)
+
+	require.ErrorIs(t, gateway.Void(ctx, "ch_missing"), ErrChargeNotFound)
+}
+
+func TestFakeGatewayDeclines(t *testing.T) {
+	ctx := context.Background()
+	gateway := NewFakeGateway("secret")
+
+	testCases := []struct {
+		name string
+		req  AuthorizeRequest
+		err  error
+	}{
+		{
+			name: "DeclinedCard",
+			req:  AuthorizeRequest{Amount: 400, PaymentMethod: FakeCardDeclined},
+			err:  ErrDeclined,
+		},
+		{
+			name: "NoCard",
+			req:  AuthorizeRequest{Amount: 400},
+			err:  ErrDeclined,
+		},
+		{
+			name: "NoAmount",
+			req:  AuthorizeRequest{PaymentMethod: FakeCardOK},
+			err:  ErrInvalidAmount,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := gateway.Authorize(ctx, tc.req)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestFakeGatewayWebhook(t *testing.T) {
+	gateway := NewFakeGateway("secret")
+
+	sent := Event{Type: EventRefunded, ProviderRef: "ch_123", Amount: 120}
+	payload, signature, err := gateway.Webhook(sent)
+	require.NoError(t, err)
+
+	event, err := gateway.VerifyWebhook(payload, signature)
+	require.NoError(t, err)
+	require.NotEmpty(t, event.ID)
+	require.Equal(t, sent.Type, event.Type)
+	require.Equal(t, sent.ProviderRef, event.ProviderRef)
+	require.Equal(t, sent.Amount, event.Amount)
+
+	tampered := []byte(signature)
+	tampered[0] ^= 1
+	_, err = gateway.VerifyWebhook(payload, string(tampered))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = gateway.VerifyWebhook(append(payload, ' '), signature)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	// a webhook signed with another secret is rejected
+	_, err = NewFakeGateway("other").VerifyWebhook(payload, signature)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+}
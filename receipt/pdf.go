@@ -0,0 +1,191 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+const (
+	// A4 in PDF points
+	pageWidth  = 595.0
+	pageHeight = 842.0
+	margin     = 56.0
+	// valueX is where the right column of label and value rows starts.
+	valueX = 220.0
+	// maxLineRunes keeps long addresses from running off the page.
+	maxLineRunes = 60
+
+	timeLayout = "02 Jan 2006 15:04 MST"
+)
+
+// RenderPDF lays the receipt out on a single A4 page. It only uses the
+// standard Helvetica fonts every PDF reader has, so no font files need to
+// ship with the server, and the same receipt always renders the same bytes.
+// Those fonts cover Western European text through WinAnsiEncoding; letters
+// of other scripts print as '?'.
+func RenderPDF(r Receipt) []byte {
+	page := pdfPage{y: pageHeight - margin}
+
+	page.text(margin, fontBold, 20, "Trip receipt")
+	page.y -= 30
+	page.row("Receipt number", r.Number)
+	page.row("Issued", r.IssuedAt.Format(timeLayout))
+	page.row("Booking ID", r.Trip.BookingID)
+
+	page.heading("Trip")
+	page.row("Pickup", r.Trip.PickupLocation)
+	for i, stop := range r.Trip.Stops {
+		page.row(fmt.Sprintf("Stop %d", i+1), stop)
+	}
+	page.row("Dropoff", r.Trip.DropoffLocation)
+	page.row("Requested", r.Trip.RequestedAt.Format(timeLayout))
+	if r.Trip.StartedAt != nil {
+		page.row("Started", r.Trip.StartedAt.Format(timeLayout))
+	}
+	if r.Trip.CompletedAt != nil {
+		page.row("Completed", r.Trip.CompletedAt.Format(timeLayout))
+	}
+	page.row("Distance", fmt.Sprintf("%.2f km", r.Trip.DistanceKm))
+	page.row("Duration", fmt.Sprintf("%.0f min", r.Trip.DurationMinutes))
+
+	page.heading("Driver and car")
+	page.row("Driver", r.Driver.Name)
+	page.row("Car", strings.TrimSpace(r.Car.Type+" "+r.Car.Model))
+
+	page.heading("Fare")
+	page.amountRow("Base fare", r.Fare.BaseFare)
+	page.amountRow("Distance", r.Fare.DistanceFare)
+	page.amountRow("Time", r.Fare.TimeFare)
+	page.amountRow("Minimum fare top-up", r.Fare.MinimumFareTopUp)
+	page.amountRow(fmt.Sprintf("Surge (x%.2f)", r.Fare.SurgeMultiplier), r.Fare.Surge)
+	page.amountRow("Booking fee", r.Fare.BookingFee)
+	page.amountRow("Discount", -r.Fare.Discount)
+	page.amountRow("Adjustment", r.Fare.Adjustment)
+
+	page.y -= 4
+	page.rule()
+	page.y -= 14
+	page.text(margin, fontBold, 12, "Total")
+	page.text(valueX, fontBold, 12, formatAmount(r.Currency, r.Fare.Total))
+
+	return writePDF(page.content.Bytes(), r.IssuedAt)
+}
+
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+)
+
+// pdfPage collects the drawing operators of a page, writing lines top down
+// from y.
+type pdfPage struct {
+	content bytes.Buffer
+	y       float64
+}
+
+func (p *pdfPage) text(x float64, font string, size float64, s string) {
+	fmt.Fprintf(&p.content, "BT /%s %.0f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, p.y, escapeText(s))
+}
+
+func (p *pdfPage) row(label, value string) {
+	p.text(margin, fontRegular, 11, label)
+	p.text(valueX, fontRegular, 11, value)
+	p.y -= 16
+}
+
+// amountRow prints a fare line, leaving out the ones that do not apply.
+func (p *pdfPage) amountRow(label string, amount int64) {
+	if amount == 0 {
+		return
+	}
+	p.row(label, formatAmount(Currency, amount))
+}
+
+func (p *pdfPage) heading(s string) {
+	p.y -= 10
+	p.text(margin, fontBold, 13, s)
+	p.y -= 20
+}
+
+func (p *pdfPage) rule() {
+	fmt.Fprintf(&p.content, "%.2f %.2f m %.2f %.2f l S\n", margin, p.y, pageWidth-margin, p.y)
+}
+
+func formatAmount(currency string, amount int64) string {
+	if amount < 0 {
+		return fmt.Sprintf("-%s %d", currency, -amount)
+	}
+	return fmt.Sprintf("%s %d", currency, amount)
+}
+
+// escapeText makes s safe inside a PDF string literal. Characters outside
+// ASCII are written as octal escapes of their WinAnsiEncoding byte, which is
+// the Windows-1252 code page, and anything that encoding lacks is replaced.
+func escapeText(s string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		if n == maxLineRunes {
+			b.WriteString("...")
+			break
+		}
+		n++
+
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < ' ' || r == 0x7f:
+			b.WriteByte('?')
+		case r <= '~':
+			b.WriteRune(r)
+		default:
+			if c, ok := charmap.Windows1252.EncodeRune(r); ok {
+				fmt.Fprintf(&b, "\\%03o", c)
+			} else {
+				b.WriteByte('?')
+			}
+		}
+	}
+	return b.String()
+}
+
+// writePDF wraps the content stream of a single page into a complete PDF
+// file with its cross-reference table.
+func writePDF(content []byte, created time.Time) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /%s 4 0 R /%s 5 0 R >> >> /Contents 6 0 R >>",
+			pageWidth, pageHeight, fontRegular, fontBold),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		fmt.Sprintf("<< /Title (Trip receipt) /Producer (toribook) /CreationDate (D:%s) >>",
+			created.UTC().Format("20060102150405Z")),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(objects)+1, len(objects), xref)
+
+	return buf.Bytes()
+}
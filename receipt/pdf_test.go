@@ -0,0 +1,48 @@
+package receipt
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderPDF(t *testing.T) {
+	r := Build(BuildParams{
+		Trip:     completedTrip(420),
+		Car:      db.Car{ID: 9, CarType: "sedan", CarModel: "Toyota Axio"},
+		Rule:     sedan,
+		IssuedAt: time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC),
+	})
+
+	pdf := RenderPDF(r)
+	require.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	require.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	require.Contains(t, string(pdf), "(RC-TB-ABC234) Tj")
+	require.Contains(t, string(pdf), "(sedan Toyota Axio) Tj")
+	require.Contains(t, string(pdf), "(BDT 420) Tj")
+	require.Contains(t, string(pdf), "/CreationDate (D:20260501090000Z)")
+
+	// startxref has to point at the cross-reference table
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	require.NotNil(t, m)
+	offset, err := strconv.Atoi(string(m[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(pdf[offset:], []byte("xref\n")))
+
+	require.Equal(t, pdf, RenderPDF(r))
+}
+
+func TestEscapeText(t *testing.T) {
+	require.Equal(t, `Road \(north\) \\ gate`, escapeText(`Road (north) \ gate`))
+	// Latin-1 and the WinAnsi extras keep their letters, other scripts do not
+	require.Equal(t, `Caf\351 \223Ni\361o\224 \200 5 \226 ?`, escapeText("Café “Niño” € 5 – ঢ"))
+	require.Equal(t, "a?b", escapeText("a\nb"))
+
+	long := escapeText(string(bytes.Repeat([]byte("a"), maxLineRunes+10)))
+	require.Len(t, long, maxLineRunes+3)
+}
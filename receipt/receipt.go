@@ -0,0 +1,162 @@
+package receipt
+
+import (
+	"math"
+	"time"
+
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/fare"
+	"github.com/emonoid/toribook.git/helpers"
+)
+
+// Currency of every amount on a receipt. Fares are charged in whole taka.
+const Currency = "BDT"
+
+// Receipt is the document issued to the passenger of a completed trip. It is
+// stored as issued, so fields may be added but never renamed or removed.
+type Receipt struct {
+	Number   string    `json:"receipt_number"`
+	IssuedAt time.Time `json:"issued_at"`
+	Currency string    `json:"currency"`
+	Trip     Trip      `json:"trip"`
+	Driver   Driver    `json:"driver"`
+	Car      Car       `json:"car"`
+	Fare     Fare      `json:"fare"`
+}
+
+type Trip struct {
+	BookingID       string     `json:"booking_id"`
+	PickupLocation  string     `json:"pickup_location"`
+	Stops           []string   `json:"stops"`
+	DropoffLocation string     `json:"dropoff_location"`
+	RequestedAt     time.Time  `json:"requested_at"`
+	StartedAt       *time.Time `json:"started_at"`
+	CompletedAt     *time.Time `json:"completed_at"`
+	DistanceKm      float64    `json:"distance_km"`
+	DurationMinutes float64    `json:"duration_minutes"`
+}
+
+type Driver struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type Car struct {
+	ID    int64  `json:"id"`
+	Type  string `json:"type"`
+	Model string `json:"model"`
+}
+
+// Fare itemises the price of the trip. Its lines add up to Total, the fare
+// the passenger paid, except on receipts built without a fare rule, which
+// only carry the total.
+type Fare struct {
+	BaseFare         int64   `json:"base_fare"`
+	DistanceFare     int64   `json:"distance_fare"`
+	TimeFare         int64   `json:"time_fare"`
+	MinimumFareTopUp int64   `json:"minimum_fare_top_up"`
+	SurgeMultiplier  float64 `json:"surge_multiplier"`
+	Surge            int64   `json:"surge"`
+	BookingFee       int64   `json:"booking_fee"`
+	// Discount is how much less than the metered price was agreed, e.g.
+	// through a lower bid, and Adjustment how much more.
+	Discount   int64 `json:"discount"`
+	Adjustment int64 `json:"adjustment"`
+	Total      int64 `json:"total"`
+}
+
+// BuildParams is everything a receipt is assembled from.
+type BuildParams struct {
+	Trip  db.Trip
+	Stops []db.TripStop
+	// Car and Rule may be zero when the car or its fare rule has since been
+	// removed; the receipt then only shows the total.
+	Car      db.Car
+	Rule     fare.Rule
+	IssuedAt time.Time
+}
+
+// Number is the receipt number of a trip.
+func Number(bookingID string) string {
+	return "RC-" + bookingID
+}
+
+// Build assembles the receipt of a completed trip. The distance is the
+// estimated route through every stop; the duration is the time actually
+// driven when the trip recorded its start and end.
+func Build(arg BuildParams) Receipt {
+	trip := arg.Trip
+
+	points := []fare.Point{{Lat: trip.PickupLat, Long: trip.PickupLong}}
+	stops := make([]string, 0, len(arg.Stops))
+	for _, stop := range arg.Stops {
+		points = append(points, fare.Point{Lat: stop.Lat, Long: stop.Long})
+		stops = append(stops, stop.Location)
+	}
+	points = append(points, fare.Point{Lat: trip.DropoffLat, Long: trip.DropoffLong})
+
+	route := fare.EstimateStops(points)
+	if trip.StartedAt.Valid && trip.CompletedAt.Valid {
+		route.DurationMinutes = trip.CompletedAt.Time.Sub(trip.StartedAt.Time).Minutes()
+	}
+
+	breakdown := arg.Rule.Breakdown(route.DistanceKm, route.DurationMinutes, trip.SurgeMultiplier)
+	total := breakdown.Total
+	if trip.Fare.Valid {
+		total = trip.Fare.Int64
+	}
+
+	r := Receipt{
+		Number:   Number(trip.BookingID),
+		IssuedAt: arg.IssuedAt,
+		Currency: Currency,
+		Trip: Trip{
+			BookingID:       trip.BookingID,
+			PickupLocation:  trip.PickupLocation,
+			Stops:           stops,
+			DropoffLocation: trip.DropoffLocation,
+			RequestedAt:     trip.CreatedAt,
+			StartedAt:       helpers.NullTimeToPtr(trip.StartedAt),
+			CompletedAt:     helpers.NullTimeToPtr(trip.CompletedAt),
+			DistanceKm:      round(route.DistanceKm, 2),
+			DurationMinutes: round(route.DurationMinutes, 1),
+		},
+		Driver: Driver{
+			ID:   trip.DriverID.Int64,
+			Name: trip.DriverName.String,
+		},
+		Car: Car{
+			ID:    trip.CarID.Int64,
+			Type:  trip.CarType.String,
+			Model: arg.Car.CarModel,
+		},
+		Fare: Fare{
+			BaseFare:         breakdown.BaseFare,
+			DistanceFare:     breakdown.DistanceFare,
+			TimeFare:         breakdown.TimeFare,
+			MinimumFareTopUp: breakdown.MinimumFareTopUp,
+			SurgeMultiplier:  trip.SurgeMultiplier,
+			Surge:            breakdown.Surge,
+			BookingFee:       breakdown.BookingFee,
+			Total:            total,
+		},
+	}
+
+	// without a rule there is no metered price to compare the fare with
+	if arg.Rule == (fare.Rule{}) {
+		return r
+	}
+
+	if total < breakdown.Total {
+		r.Fare.Discount = breakdown.Total - total
+	} else {
+		r.Fare.Adjustment = total - breakdown.Total
+	}
+
+	return r
+}
+
+func round(v float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(v*scale) / scale
+}
@@ -0,0 +1,135 @@
+package receipt
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	db "github.com/emonoid/toribook.git/db/sqlc"
+	"github.com/emonoid/toribook.git/fare"
+	"github.com/stretchr/testify/require"
+)
+
+var sedan = fare.Rule{
+	CarType:     "sedan",
+	BaseFare:    50,
+	PerKm:       20,
+	PerMinute:   3,
+	MinimumFare: 120,
+	BookingFee:  10,
+}
+
+func completedTrip(fareAmount int64) db.Trip {
+	requested := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	return db.Trip{
+		ID:              7,
+		BookingID:       "TB-ABC234",
+		TripStatus:      db.TripStatusCompleted,
+		PickupLocation:  "Banani",
+		PickupLat:       23.7937,
+		PickupLong:      90.4066,
+		DropoffLocation: "Motijheel",
+		DropoffLat:      23.7330,
+		DropoffLong:     90.4172,
+		DriverID:        sql.NullInt64{Int64: 3, Valid: true},
+		DriverName:      sql.NullString{String: "Rahim Uddin", Valid: true},
+		CarID:           sql.NullInt64{Int64: 9, Valid: true},
+		CarType:         sql.NullString{String: "sedan", Valid: true},
+		Fare:            sql.NullInt64{Int64: fareAmount, Valid: true},
+		SurgeMultiplier: 1.2,
+		CreatedAt:       requested,
+		StartedAt:       sql.NullTime{Time: requested.Add(10 * time.Minute), Valid: true},
+		CompletedAt:     sql.NullTime{Time: requested.Add(40 * time.Minute), Valid: true},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	issuedAt := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	trip := completedTrip(0)
+	route := fare.EstimateRoute(trip.PickupLat, trip.PickupLong, trip.DropoffLat, trip.DropoffLong)
+	metered := sedan.Breakdown(route.DistanceKm, 30, trip.SurgeMultiplier)
+
+	testCases := []struct {
+		name       string
+		fare       int64
+		discount   int64
+		adjustment int64
+	}{
+		{
+			name: "MeteredFare",
+			fare: metered.Total,
+		},
+		{
+			name:     "LowerBid",
+			fare:     metered.Total - 25,
+			discount: 25,
+		},
+		{
+			name:       "HigherBid",
+			fare:       metered.Total + 40,
+			adjustment: 40,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			r := Build(BuildParams{
+				Trip:     completedTrip(tc.fare),
+				Car:      db.Car{ID: 9, CarType: "sedan", CarModel: "Toyota Axio"},
+				Rule:     sedan,
+				IssuedAt: issuedAt,
+			})
+
+			require.Equal(t, "RC-TB-ABC234", r.Number)
+			require.Equal(t, issuedAt, r.IssuedAt)
+			require.Equal(t, Currency, r.Currency)
+			require.Equal(t, "Rahim Uddin", r.Driver.Name)
+			require.Equal(t, "Toyota Axio", r.Car.Model)
+			require.Empty(t, r.Trip.Stops)
+			require.Equal(t, 30.0, r.Trip.DurationMinutes)
+			require.InDelta(t, route.DistanceKm, r.Trip.DistanceKm, 0.005)
+
+			require.Equal(t, metered.BaseFare, r.Fare.BaseFare)
+			require.Equal(t, metered.Surge, r.Fare.Surge)
+			require.Equal(t, tc.discount, r.Fare.Discount)
+			require.Equal(t, tc.adjustment, r.Fare.Adjustment)
+			require.Equal(t, tc.fare, r.Fare.Total)
+
+			f := r.Fare
+			require.Equal(t, f.Total, f.BaseFare+f.DistanceFare+f.TimeFare+f.MinimumFareTopUp+f.Surge+f.BookingFee-f.Discount+f.Adjustment)
+		})
+	}
+}
+
+func TestBuildWithoutRule(t *testing.T) {
+	trip := completedTrip(480)
+	r := Build(BuildParams{Trip: trip})
+
+	// only the total is shown, not a 480 adjustment over zero lines
+	require.Equal(t, Fare{SurgeMultiplier: trip.SurgeMultiplier, Total: 480}, r.Fare)
+}
+
+func TestBuildWithStops(t *testing.T) {
+	trip := completedTrip(500)
+	trip.StartedAt = sql.NullTime{}
+	stops := []db.TripStop{
+		{TripID: trip.ID, StopOrder: 1, Location: "Gulshan", Lat: 23.7925, Long: 90.4078},
+	}
+
+	r := Build(BuildParams{Trip: trip, Stops: stops, Rule: sedan})
+
+	route := fare.EstimateStops([]fare.Point{
+		{Lat: trip.PickupLat, Long: trip.PickupLong},
+		{Lat: stops[0].Lat, Long: stops[0].Long},
+		{Lat: trip.DropoffLat, Long: trip.DropoffLong},
+	})
+	require.Equal(t, []string{"Gulshan"}, r.Trip.Stops)
+	require.InDelta(t, route.DistanceKm, r.Trip.DistanceKm, 0.005)
+	// without a recorded start the estimated duration is used
+	require.InDelta(t, route.DurationMinutes, r.Trip.DurationMinutes, 0.05)
+	require.Nil(t, r.Trip.StartedAt)
+	require.Equal(t, int64(500), r.Fare.Total)
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 const minSecretKeySize = 32
@@ -22,14 +23,15 @@ func NewJWTMaker(secretKey string) (Maker, error){
 	return  &JWTMaker{secretKey: secretKey}, nil
 }
 
-func (maker *JWTMaker) CreateToken(username string, duration time.Duration) (string, error){
-  payload, err:= NewPayload(username, duration)
+func (maker *JWTMaker) CreateToken(username string, role string, userID int64, tokenType string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error){
+  payload, err:= NewPayload(username, role, userID, tokenType, sessionID, duration)
   if err != nil {
-	return "", err
+	return "", payload, err
   }
 
   jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
-  return jwtToken.SignedString([]byte(maker.secretKey))
+  token, err := jwtToken.SignedString([]byte(maker.secretKey))
+  return token, payload, err
 }
 
 func (maker *JWTMaker) VerifyToken(token string) (*Payload, error){
@@ -2,11 +2,12 @@ package token
 
 import (
 	"time"
- 
+
+	"github.com/google/uuid"
 )
 
 type Maker interface {
-	CreateToken(user_data string, duration time.Duration) (string, error)
+	CreateToken(user_data string, role string, userID int64, tokenType string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error)
 
 	VerifyToken(token string) (*Payload, error)
 }
\ No newline at end of file
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/aead/chacha20poly1305"
+	"github.com/google/uuid"
 	"github.com/o1egl/paseto"
 )
 
@@ -28,13 +29,14 @@ func NewPasetoMaker(symmectricKey []byte) (Maker, error) {
 
 
 // CreateToken implements Maker.
-func (maker *PasetoMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(username, duration)
+func (maker *PasetoMaker) CreateToken(username string, role string, userID int64, tokenType string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, userID, tokenType, sessionID, duration)
 	if err != nil {
-		return "", err
+		return "", payload, err
 	}
 
-	return maker.paseto.Encrypt(maker.symmectricKey, payload, nil)
+	token, err := maker.paseto.Encrypt(maker.symmectricKey, payload, nil)
+	return token, payload, err
 }
 
 // VerifyToken implements Maker.
@@ -0,0 +1,54 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emonoid/toribook.git/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasetoMaker(t *testing.T) {
+	maker, err := NewPasetoMaker([]byte(utils.RandomString(32)))
+	require.NoError(t, err)
+
+	username := utils.RandomEmail()
+	userID := utils.RandomInt(1, 1000)
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(username, RolePassenger, userID, TokenTypeRefresh, uuid.Nil, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, RolePassenger, payload.Role)
+	require.Equal(t, userID, payload.UserID)
+	require.Equal(t, TokenTypeRefresh, payload.TokenType)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpireAt, time.Second)
+}
+
+func TestExpiredPasetoToken(t *testing.T) {
+	maker, err := NewPasetoMaker([]byte(utils.RandomString(32)))
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken(utils.RandomMobile(), RoleDriver, utils.RandomInt(1, 1000), TokenTypeAccess, uuid.Nil, -time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.Error(t, err)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
@@ -8,54 +8,77 @@ import (
 	"github.com/google/uuid"
 )
 
+// Roles a token can be issued for.
+const (
+	RolePassenger = "passenger"
+	RoleDriver    = "driver"
+)
+
+// Token types. Access tokens authorize API requests; refresh tokens are only
+// good for getting a new access token.
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
 type Payload struct {
-	ID       uuid.UUID `json:"id"`
-	Username string    `json:"username"`
-	IssuedAt time.Time `json:"issued_at"`
-	ExpireAt time.Time `json:"expired_at"`
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	UserID    int64     `json:"user_id"`
+	TokenType string    `json:"token_type"`
+	// SessionID is the session an access token was issued in, which is the
+	// id of the refresh token issued with it. It is empty on refresh tokens.
+	SessionID uuid.UUID `json:"session_id"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpireAt  time.Time `json:"expired_at"`
 }
 
 // GetAudience implements jwt.Claims.
 func (payload *Payload) GetAudience() (jwt.ClaimStrings, error) {
-	panic("unimplemented")
+	return nil, nil
 }
 
 // GetExpirationTime implements jwt.Claims.
 func (payload *Payload) GetExpirationTime() (*jwt.NumericDate, error) {
-	panic("unimplemented")
+	return jwt.NewNumericDate(payload.ExpireAt), nil
 }
 
 // GetIssuedAt implements jwt.Claims.
 func (payload *Payload) GetIssuedAt() (*jwt.NumericDate, error) {
-	panic("unimplemented")
+	return jwt.NewNumericDate(payload.IssuedAt), nil
 }
 
 // GetIssuer implements jwt.Claims.
 func (payload *Payload) GetIssuer() (string, error) {
-	panic("unimplemented")
+	return "", nil
 }
 
 // GetNotBefore implements jwt.Claims.
 func (payload *Payload) GetNotBefore() (*jwt.NumericDate, error) {
-	panic("unimplemented")
+	return nil, nil
 }
 
 // GetSubject implements jwt.Claims.
 func (payload *Payload) GetSubject() (string, error) {
-	panic("unimplemented")
+	return payload.Username, nil
 }
 
-func NewPayload(username string, duration time.Duration) (*Payload, error) {
+func NewPayload(username string, role string, userID int64, tokenType string, sessionID uuid.UUID, duration time.Duration) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
 	}
 
 	payload := &Payload{
-		ID:       tokenID,
-		Username: username,
-		IssuedAt: time.Now(),
-		ExpireAt: time.Now().Add(duration),
+		ID:        tokenID,
+		Username:  username,
+		Role:      role,
+		UserID:    userID,
+		TokenType: tokenType,
+		SessionID: sessionID,
+		IssuedAt:  time.Now(),
+		ExpireAt:  time.Now().Add(duration),
 	}
 
 	return payload, nil
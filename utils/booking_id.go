@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+const (
+	bookingIDPrefix = "TB-"
+	bookingIDLength = 6
+	// bookingIDAlphabet leaves out characters that are easily misread
+	// over the phone or on a small screen (0/O, 1/I/L, U/V).
+	bookingIDAlphabet = "23456789ABCDEFGHJKMNPQRSTWXYZ"
+)
+
+// RandomBookingID returns a short, human readable booking code such as TB-7K3Q9X.
+func RandomBookingID() (string, error) {
+	code := make([]byte, bookingIDLength)
+	max := big.NewInt(int64(len(bookingIDAlphabet)))
+
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate booking id: %w", err)
+		}
+		code[i] = bookingIDAlphabet[n.Int64()]
+	}
+
+	return bookingIDPrefix + string(code), nil
+}
@@ -13,6 +13,26 @@ type Config struct {
 	ServerAddress string `mapstructure:"SERVER_ADDRESS"`
 	TokenSymmetricKey string `mapstructure:"TOKEN_SYMMETRIC_KEY"`
 	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"` 
+	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	RedisAddress string `mapstructure:"REDIS_ADDRESS"`
+	DispatchRadiusKm float64 `mapstructure:"DISPATCH_RADIUS_KM"`
+	DispatchRadiusStepKm float64 `mapstructure:"DISPATCH_RADIUS_STEP_KM"`
+	DispatchMaxRadiusKm float64 `mapstructure:"DISPATCH_MAX_RADIUS_KM"`
+	DispatchBidTimeout time.Duration `mapstructure:"DISPATCH_BID_TIMEOUT"`
+	SurgeMaxMultiplier float64 `mapstructure:"SURGE_MAX_MULTIPLIER"`
+	SurgeWindow time.Duration `mapstructure:"SURGE_WINDOW"`
+	ScheduleOpenBefore time.Duration `mapstructure:"SCHEDULE_OPEN_BEFORE"`
+	ScheduleReminderBefore time.Duration `mapstructure:"SCHEDULE_REMINDER_BEFORE"`
+	ScheduleExpireAfter time.Duration `mapstructure:"SCHEDULE_EXPIRE_AFTER"`
+	SchedulerInterval time.Duration `mapstructure:"SCHEDULER_INTERVAL"`
+	CommissionRate float64 `mapstructure:"COMMISSION_RATE"`
+	PaymentWebhookSecret string `mapstructure:"PAYMENT_WEBHOOK_SECRET"`
+	// PaymentFakeGateway enables the fake card gateway, which accepts any
+	// test card. Only for development and tests.
+	PaymentFakeGateway bool `mapstructure:"PAYMENT_FAKE_GATEWAY"`
+	// AdminAPIKey authorizes the platform's admin routes. They are closed
+	// while it is empty.
+	AdminAPIKey string `mapstructure:"ADMIN_API_KEY"`
 }
 
 func LoadConfig(path string) (config Config, err error){
@@ -32,8 +52,8 @@ func LoadConfig(path string) (config Config, err error){
 }
 
 
-func NewRedisClient() *redis.Client {
+func NewRedisClient(address string) *redis.Client {
 	return redis.NewClient(&redis.Options{
-		Addr: "localhost:6379",
+		Addr: address,
 	})
 }
\ No newline at end of file